@@ -0,0 +1,94 @@
+package aria2
+
+import (
+	"context"
+	"time"
+)
+
+// API aria2 RPC 客户端提供的全部操作
+// 界面代码依赖该接口而不是 *Client，测试时可替换为其他实现，或配合 aria2test 使用
+type API interface {
+	SetTimeout(timeout time.Duration)
+	Close() error
+
+	GetVersion() (*Version, error)
+	GetVersionContext(ctx context.Context) (*Version, error)
+	GetGlobalStat() (*GlobalStat, error)
+	GetGlobalStatContext(ctx context.Context) (*GlobalStat, error)
+
+	TellStatus(gid string, keys ...string) (*TellStatus, error)
+	TellStatusContext(ctx context.Context, gid string, keys ...string) (*TellStatus, error)
+	TellActive(keys ...string) ([]TellStatus, error)
+	TellActiveContext(ctx context.Context, keys ...string) ([]TellStatus, error)
+	TellWaiting(offset int, num int, keys ...string) ([]TellStatus, error)
+	TellWaitingContext(ctx context.Context, offset int, num int, keys ...string) ([]TellStatus, error)
+	TellStopped(offset int, num int, keys ...string) ([]TellStatus, error)
+	TellStoppedContext(ctx context.Context, offset int, num int, keys ...string) ([]TellStatus, error)
+
+	AddURI(uris []string, options map[string]interface{}) (string, error)
+	AddURIContext(ctx context.Context, uris []string, options map[string]interface{}) (string, error)
+	AddTorrent(torrent []byte, webSeeds []string, options map[string]interface{}, position int) (string, error)
+	AddTorrentContext(ctx context.Context, torrent []byte, webSeeds []string, options map[string]interface{}, position int) (string, error)
+	AddMetalink(metalink []byte, options map[string]interface{}, position int) ([]string, error)
+	AddMetalinkContext(ctx context.Context, metalink []byte, options map[string]interface{}, position int) ([]string, error)
+
+	Pause(gid string) error
+	PauseContext(ctx context.Context, gid string) error
+	ForcePause(gid string) error
+	ForcePauseContext(ctx context.Context, gid string) error
+	Unpause(gid string) error
+	UnpauseContext(ctx context.Context, gid string) error
+	Remove(gid string) error
+	RemoveContext(ctx context.Context, gid string) error
+	ForceRemove(gid string) error
+	ForceRemoveContext(ctx context.Context, gid string) error
+	RemoveDownloadResult(gid string) error
+	RemoveDownloadResultContext(ctx context.Context, gid string) error
+	PurgeDownloadResult() error
+	PurgeDownloadResultContext(ctx context.Context) error
+	PauseAll() error
+	PauseAllContext(ctx context.Context) error
+	ForcePauseAll() error
+	ForcePauseAllContext(ctx context.Context) error
+	UnpauseAll() error
+	UnpauseAllContext(ctx context.Context) error
+	ChangePosition(gid string, pos int, how PositionHow) (int, error)
+	ChangePositionContext(ctx context.Context, gid string, pos int, how PositionHow) (int, error)
+
+	GetPeers(gid string) ([]Peer, error)
+	GetPeersContext(ctx context.Context, gid string) ([]Peer, error)
+	GetServers(gid string) ([]FileServers, error)
+	GetServersContext(ctx context.Context, gid string) ([]FileServers, error)
+	GetFiles(gid string) ([]FileInfo, error)
+	GetFilesContext(ctx context.Context, gid string) ([]FileInfo, error)
+	GetURIs(gid string) ([]URI, error)
+	GetURIsContext(ctx context.Context, gid string) ([]URI, error)
+
+	GetOption(gid string) (Options, error)
+	GetOptionContext(ctx context.Context, gid string) (Options, error)
+	ChangeOption(gid string, options Options) error
+	ChangeOptionContext(ctx context.Context, gid string, options Options) error
+	GetGlobalOption() (Options, error)
+	GetGlobalOptionContext(ctx context.Context) (Options, error)
+	ChangeGlobalOption(options Options) error
+	ChangeGlobalOptionContext(ctx context.Context, options Options) error
+
+	SaveSession() error
+	SaveSessionContext(ctx context.Context) error
+	GetSessionInfo() (*SessionInfo, error)
+	GetSessionInfoContext(ctx context.Context) (*SessionInfo, error)
+	Shutdown() error
+	ShutdownContext(ctx context.Context) error
+	ForceShutdown() error
+	ForceShutdownContext(ctx context.Context) error
+
+	Multicall(calls []Call) ([]CallResult, error)
+	MulticallContext(ctx context.Context, calls []Call) ([]CallResult, error)
+
+	SupportsNotifications() bool
+	Subscribe(handler func(Event), types ...EventType) *Subscription
+	SubscribeChan(ch chan<- Event, types ...EventType) *Subscription
+}
+
+// 确保 *Client 实现了 API
+var _ API = (*Client)(nil)
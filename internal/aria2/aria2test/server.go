@@ -0,0 +1,441 @@
+// Package aria2test 提供进程内的 aria2 JSON-RPC 模拟服务，用于在没有 aria2c 的情况下测试客户端和界面逻辑
+//
+// 模拟服务同时支持 HTTP 和 WebSocket，按 aria2 的规则维护活动、等待和已停止队列，
+// 校验 RPC 密钥，并可以为指定方法注入错误：
+//
+//	srv := aria2test.NewServer("secret")
+//	defer srv.Close()
+//	gid := srv.AddTask("http://example.com/file.iso")
+//	client := srv.Client()
+//	client.Pause(gid)
+package aria2test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+// Path 模拟服务的 RPC 请求路径
+const Path = "/jsonrpc"
+
+// Server 模拟的 aria2 RPC 服务
+type Server struct {
+	// URL HTTP 接口地址，例如 http://127.0.0.1:12345/jsonrpc
+	URL string
+	// WSURL WebSocket 接口地址，例如 ws://127.0.0.1:12345/jsonrpc
+	WSURL string
+	// Host 和 Port 为监听地址，可直接传给 aria2.NewClient
+	Host string
+	Port int
+
+	token      string
+	httpServer *httptest.Server
+
+	mu            sync.Mutex
+	tasks         map[string]*task
+	active        []string
+	waiting       []string
+	stopped       []string
+	nextGID       uint64
+	maxConcurrent int
+	globalOptions aria2.Options
+	failures      map[string]*aria2.RPCError
+	calls         []string
+	events        []notification
+	sessionID     string
+	sessionSaves  int
+	shutDown      bool
+
+	connsMu sync.Mutex
+	conns   map[*wsConn]bool
+}
+
+// notification 等待推送给 WebSocket 客户端的事件
+type notification struct {
+	method string
+	gid    string
+}
+
+// wsConn WebSocket 连接，响应和通知可能来自不同协程，写入时需要加锁
+type wsConn struct {
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+// rpcRequest 收到的 JSON-RPC 请求
+type rpcRequest struct {
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+	ID     json.RawMessage   `json:"id"`
+}
+
+// rpcResponse 返回的 JSON-RPC 响应
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *aria2.RPCError `json:"error,omitempty"`
+}
+
+// NewServer 启动模拟服务，token 为空时不校验密钥
+func NewServer(token string) *Server {
+	s := &Server{
+		token:         token,
+		tasks:         make(map[string]*task),
+		maxConcurrent: 5,
+		globalOptions: aria2.Options{
+			"dir":                        "/downloads",
+			"max-concurrent-downloads":   "5",
+			"max-overall-download-limit": "0",
+			"max-overall-upload-limit":   "0",
+		},
+		failures:  make(map[string]*aria2.RPCError),
+		conns:     make(map[*wsConn]bool),
+		sessionID: "aria2test0000000000000000000000000000000",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(Path, s.serveHTTP)
+	s.httpServer = httptest.NewServer(mux)
+
+	s.URL = s.httpServer.URL + Path
+	s.WSURL = "ws" + strings.TrimPrefix(s.httpServer.URL, "http") + Path
+	host, port, _ := net.SplitHostPort(s.httpServer.Listener.Addr().String())
+	s.Host = host
+	s.Port, _ = strconv.Atoi(port)
+
+	return s
+}
+
+// Close 关闭模拟服务和全部 WebSocket 连接
+func (s *Server) Close() {
+	s.connsMu.Lock()
+	for c := range s.conns {
+		c.conn.Close()
+	}
+	s.connsMu.Unlock()
+
+	s.httpServer.Close()
+}
+
+// Client 创建通过 HTTP 连接模拟服务的客户端
+func (s *Server) Client() *aria2.Client {
+	return aria2.NewClient(s.Host, s.Port, s.token, "http", Path)
+}
+
+// WSClient 创建通过 WebSocket 连接模拟服务的客户端，可接收任务事件通知
+func (s *Server) WSClient() *aria2.Client {
+	return aria2.NewClient(s.Host, s.Port, s.token, "ws", Path)
+}
+
+// FailMethod 让之后对 method 的调用都返回指定错误，直到调用 ClearFailures
+// method 为完整方法名，例如 aria2.pause
+func (s *Server) FailMethod(method string, code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = &aria2.RPCError{Code: code, Message: message}
+}
+
+// ClearFailures 取消全部注入的错误
+func (s *Server) ClearFailures() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = make(map[string]*aria2.RPCError)
+}
+
+// Calls 返回收到的方法名，按调用顺序排列；system.multicall 中的调用逐个记录
+func (s *Server) Calls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+// ResetCalls 清空调用记录
+func (s *Server) ResetCalls() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = nil
+}
+
+// serveHTTP 处理 HTTP 请求，Upgrade 请求转交 WebSocket 处理
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	// 关闭后的 aria2 不再接受连接，这里直接断开
+	if s.IsShutdown() {
+		panic(http.ErrAbortHandler)
+	}
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		websocket.Server{Handler: s.serveWS}.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	response := s.handleMessage(body)
+	s.flushEvents()
+
+	w.Header().Set("Content-Type", "application/json-rpc")
+	// 与 aria2 一致，出错的请求返回 400 状态码，响应体仍是 JSON-RPC 错误
+	if resp, ok := response.(rpcResponse); ok && resp.Error != nil {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// serveWS 处理一条 WebSocket 连接上的请求
+func (s *Server) serveWS(conn *websocket.Conn) {
+	c := &wsConn{conn: conn}
+	s.connsMu.Lock()
+	s.conns[c] = true
+	s.connsMu.Unlock()
+
+	defer func() {
+		s.connsMu.Lock()
+		delete(s.conns, c)
+		s.connsMu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		var message string
+		if err := websocket.Message.Receive(conn, &message); err != nil {
+			return
+		}
+
+		response := s.handleMessage([]byte(message))
+		data, _ := json.Marshal(response)
+
+		c.mu.Lock()
+		err := websocket.Message.Send(conn, string(data))
+		c.mu.Unlock()
+		if err != nil || s.IsShutdown() {
+			return
+		}
+
+		s.flushEvents()
+	}
+}
+
+// handleMessage 处理单个请求或批量请求
+func (s *Server) handleMessage(body []byte) interface{} {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		var requests []rpcRequest
+		if err := json.Unmarshal(trimmed, &requests); err != nil {
+			return parseError()
+		}
+		responses := make([]rpcResponse, len(requests))
+		for i, request := range requests {
+			responses[i] = s.handleRequest(request)
+		}
+		return responses
+	}
+
+	var request rpcRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return parseError()
+	}
+	return s.handleRequest(request)
+}
+
+// parseError 请求不是合法 JSON 时的响应
+func parseError() rpcResponse {
+	return rpcResponse{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage("null"),
+		Error:   &aria2.RPCError{Code: aria2.CodeParseError, Message: "Parse error."},
+	}
+}
+
+// handleRequest 处理单个请求
+func (s *Server) handleRequest(request rpcRequest) rpcResponse {
+	response := rpcResponse{JSONRPC: "2.0", ID: request.ID}
+	if len(response.ID) == 0 {
+		response.ID = json.RawMessage("null")
+	}
+
+	var result interface{}
+	var err *aria2.RPCError
+	if request.Method == "system.multicall" {
+		result, err = s.multicall(request.Params)
+	} else {
+		result, err = s.call(request.Method, request.Params)
+	}
+
+	if err != nil {
+		response.Error = err
+	} else {
+		response.Result = result
+	}
+	return response
+}
+
+// multicall 依次执行 system.multicall 中的调用
+// 成功的结果包装为单元素数组，失败的调用返回错误对象，与 aria2 一致
+func (s *Server) multicall(params []json.RawMessage) (interface{}, *aria2.RPCError) {
+	var entries []struct {
+		MethodName string            `json:"methodName"`
+		Params     []json.RawMessage `json:"params"`
+	}
+	if len(params) != 1 || json.Unmarshal(params[0], &entries) != nil {
+		return nil, invalidParams()
+	}
+
+	results := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		if entry.MethodName == "system.multicall" {
+			results[i] = &aria2.RPCError{Code: 1, Message: "Recursive system.multicall forbidden."}
+			continue
+		}
+		result, err := s.call(entry.MethodName, entry.Params)
+		if err != nil {
+			results[i] = err
+		} else {
+			results[i] = []interface{}{result}
+		}
+	}
+	return results, nil
+}
+
+// call 校验密钥后执行单个方法
+func (s *Server) call(method string, params []json.RawMessage) (interface{}, *aria2.RPCError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls = append(s.calls, method)
+
+	if err := s.failures[method]; err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(method, "aria2.") {
+		var ok bool
+		if params, ok = s.checkToken(params); !ok {
+			return nil, &aria2.RPCError{Code: 1, Message: "Unauthorized"}
+		}
+	}
+
+	handler, ok := methods[method]
+	if !ok {
+		return nil, &aria2.RPCError{Code: aria2.CodeMethodNotFound, Message: "Method not found."}
+	}
+	return handler(s, args(params))
+}
+
+// checkToken 去掉参数中的 token 并校验，与 aria2 一样未设置密钥时接受任何请求
+func (s *Server) checkToken(params []json.RawMessage) ([]json.RawMessage, bool) {
+	var first string
+	if len(params) > 0 && json.Unmarshal(params[0], &first) == nil && strings.HasPrefix(first, "token:") {
+		params = params[1:]
+		return params, s.token == "" || strings.TrimPrefix(first, "token:") == s.token
+	}
+	return params, s.token == ""
+}
+
+// flushEvents 将积累的事件推送给所有 WebSocket 连接
+func (s *Server) flushEvents() {
+	s.mu.Lock()
+	events := s.events
+	s.events = nil
+	s.mu.Unlock()
+
+	if len(events) == 0 {
+		return
+	}
+
+	s.connsMu.Lock()
+	conns := make([]*wsConn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.connsMu.Unlock()
+
+	for _, event := range events {
+		data, _ := json.Marshal(map[string]interface{}{
+			"jsonrpc": "2.0",
+			"method":  event.method,
+			"params":  []interface{}{map[string]string{"gid": event.gid}},
+		})
+		for _, c := range conns {
+			c.mu.Lock()
+			websocket.Message.Send(c.conn, string(data))
+			c.mu.Unlock()
+		}
+	}
+}
+
+// invalidParams 参数错误
+func invalidParams() *aria2.RPCError {
+	return &aria2.RPCError{Code: 1, Message: "Invalid parameters."}
+}
+
+// args 方法参数，按位置读取
+type args []json.RawMessage
+
+// stringAt 读取第 i 个字符串参数
+func (a args) stringAt(i int) (string, bool) {
+	var v string
+	if i >= len(a) || json.Unmarshal(a[i], &v) != nil {
+		return "", false
+	}
+	return v, true
+}
+
+// intAt 读取第 i 个整数参数
+func (a args) intAt(i int) (int, bool) {
+	var v int
+	if i >= len(a) || json.Unmarshal(a[i], &v) != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// stringsAt 读取第 i 个字符串数组参数，参数不存在时返回 nil
+func (a args) stringsAt(i int) []string {
+	var v []string
+	if i < len(a) {
+		json.Unmarshal(a[i], &v)
+	}
+	return v
+}
+
+// optionsAt 读取第 i 个选项参数，值统一转为字符串
+func (a args) optionsAt(i int) aria2.Options {
+	options := aria2.Options{}
+	if i >= len(a) {
+		return options
+	}
+	var raw map[string]interface{}
+	if json.Unmarshal(a[i], &raw) != nil {
+		return options
+	}
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string:
+			options[key] = v
+		default:
+			data, _ := json.Marshal(v)
+			options[key] = string(data)
+		}
+	}
+	return options
+}
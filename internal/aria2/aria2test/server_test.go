@@ -0,0 +1,274 @@
+package aria2test_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+	"github.com/chenyb888/aria2GoUI/internal/aria2/aria2test"
+)
+
+// addTasks 添加 n 个任务，返回按添加顺序排列的 GID
+func addTasks(srv *aria2test.Server, n int) []string {
+	gids := make([]string, n)
+	for i := range gids {
+		gids[i] = srv.AddTask("http://example.com/file.iso")
+	}
+	return gids
+}
+
+// checkQueues 检查活动队列和等待队列
+func checkQueues(t *testing.T, srv *aria2test.Server, active, waiting []string) {
+	t.Helper()
+
+	if got := srv.Active(); !reflect.DeepEqual(got, active) {
+		t.Errorf("active = %v, want %v", got, active)
+	}
+	if got := srv.Waiting(); !reflect.DeepEqual(got, waiting) {
+		t.Errorf("waiting = %v, want %v", got, waiting)
+	}
+}
+
+func TestPauseAndUnpause(t *testing.T) {
+	srv := aria2test.NewServer("secret")
+	defer srv.Close()
+	client := srv.Client()
+	defer client.Close()
+
+	srv.SetMaxConcurrent(2)
+	g := addTasks(srv, 4)
+	checkQueues(t, srv, []string{g[0], g[1]}, []string{g[2], g[3]})
+
+	// 暂停的活动任务回到等待队列最前面，空出的位置由下一个等待任务补上
+	if err := client.Pause(g[0]); err != nil {
+		t.Fatal(err)
+	}
+	checkQueues(t, srv, []string{g[1], g[2]}, []string{g[0], g[3]})
+	if status := srv.Status(g[0]); status != "paused" {
+		t.Errorf("status after pause = %q, want paused", status)
+	}
+
+	// 恢复后在原位置等待，没有空位时不会开始
+	if err := client.Unpause(g[0]); err != nil {
+		t.Fatal(err)
+	}
+	checkQueues(t, srv, []string{g[1], g[2]}, []string{g[0], g[3]})
+	if status := srv.Status(g[0]); status != "waiting" {
+		t.Errorf("status after unpause = %q, want waiting", status)
+	}
+
+	if err := client.Unpause(g[0]); err == nil {
+		t.Error("unpausing a waiting task succeeded")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	srv := aria2test.NewServer("")
+	defer srv.Close()
+	client := srv.Client()
+	defer client.Close()
+
+	srv.SetMaxConcurrent(1)
+	g := addTasks(srv, 3)
+
+	if err := client.Remove(g[0]); err != nil {
+		t.Fatal(err)
+	}
+	checkQueues(t, srv, []string{g[1]}, []string{g[2]})
+	if got := srv.Stopped(); !reflect.DeepEqual(got, []string{g[0]}) {
+		t.Errorf("stopped = %v, want %v", got, []string{g[0]})
+	}
+	if status := srv.Status(g[0]); status != "removed" {
+		t.Errorf("status after remove = %q, want removed", status)
+	}
+
+	// 已停止的任务不能再次移除，只能删除下载结果
+	if err := client.Remove(g[0]); err == nil {
+		t.Error("removing a stopped task succeeded")
+	}
+	if err := client.RemoveDownloadResult(g[0]); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.Stopped()) != 0 || srv.Status(g[0]) != "" {
+		t.Errorf("download result of %s was not removed", g[0])
+	}
+}
+
+func TestChangePosition(t *testing.T) {
+	srv := aria2test.NewServer("")
+	defer srv.Close()
+	client := srv.Client()
+	defer client.Close()
+
+	srv.SetMaxConcurrent(0)
+	g := addTasks(srv, 4)
+
+	tests := []struct {
+		gid     string
+		pos     int
+		how     aria2.PositionHow
+		want    int
+		waiting []string
+	}{
+		{g[3], 0, aria2.PosSet, 0, []string{g[3], g[0], g[1], g[2]}},
+		{g[3], 1, aria2.PosCur, 1, []string{g[0], g[3], g[1], g[2]}},
+		{g[0], 0, aria2.PosEnd, 3, []string{g[3], g[1], g[2], g[0]}},
+		// 超出队列范围的位置被限制在队列两端
+		{g[2], -10, aria2.PosCur, 0, []string{g[2], g[3], g[1], g[0]}},
+		{g[2], 10, aria2.PosSet, 3, []string{g[3], g[1], g[0], g[2]}},
+	}
+	for _, tt := range tests {
+		got, err := client.ChangePosition(tt.gid, tt.pos, tt.how)
+		if err != nil {
+			t.Fatalf("ChangePosition(%s, %d, %s): %v", tt.gid, tt.pos, tt.how, err)
+		}
+		if got != tt.want {
+			t.Errorf("ChangePosition(%s, %d, %s) = %d, want %d", tt.gid, tt.pos, tt.how, got, tt.want)
+		}
+		checkQueues(t, srv, nil, tt.waiting)
+	}
+
+	// 不在等待队列中的任务不能调整位置
+	srv.SetMaxConcurrent(1)
+	if _, err := client.ChangePosition(srv.Active()[0], 0, aria2.PosSet); err == nil {
+		t.Error("changing the position of an active task succeeded")
+	}
+}
+
+func TestMulticall(t *testing.T) {
+	srv := aria2test.NewServer("secret")
+	defer srv.Close()
+	client := srv.Client()
+	defer client.Close()
+
+	gid := srv.AddTask("http://example.com/file.iso")
+	srv.ResetCalls()
+
+	results, err := client.Multicall([]aria2.Call{
+		{Method: "aria2.tellStatus", Params: []interface{}{gid, []string{"gid", "status"}}},
+		{Method: "aria2.tellStatus", Params: []interface{}{"ffffffffffffffff"}},
+		{Method: "aria2.pause", Params: []interface{}{gid}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+
+	var status aria2.TellStatus
+	if err := results[0].Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.GID != gid || status.Status != "active" {
+		t.Errorf("tellStatus = %s %s, want %s active", status.GID, status.Status, gid)
+	}
+
+	// 单个调用失败不影响其他调用
+	if !errors.Is(results[1].Err, aria2.ErrTaskNotFound) {
+		t.Errorf("tellStatus of a missing task = %v, want ErrTaskNotFound", results[1].Err)
+	}
+	if results[2].Err != nil {
+		t.Errorf("pause = %v", results[2].Err)
+	}
+	if status := srv.Status(gid); status != "paused" {
+		t.Errorf("status after multicall = %q, want paused", status)
+	}
+
+	want := []string{"aria2.tellStatus", "aria2.tellStatus", "aria2.pause"}
+	if got := srv.Calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	srv := aria2test.NewServer("secret")
+	defer srv.Close()
+	client := srv.Client()
+	defer client.Close()
+
+	wrongToken := aria2.NewClient(srv.Host, srv.Port, "wrong", "http", aria2test.Path)
+	defer wrongToken.Close()
+	if _, err := wrongToken.TellActive(); !errors.Is(err, aria2.ErrUnauthorized) {
+		t.Errorf("wrong token: %v, want ErrUnauthorized", err)
+	}
+
+	if _, err := client.TellStatus("ffffffffffffffff"); !errors.Is(err, aria2.ErrTaskNotFound) {
+		t.Errorf("missing task: %v, want ErrTaskNotFound", err)
+	}
+
+	gid := srv.AddTask("http://example.com/file.iso")
+	srv.FailMethod("aria2.pause", 1, "Unauthorized")
+	if err := client.Pause(gid); !errors.Is(err, aria2.ErrUnauthorized) {
+		t.Errorf("injected error: %v, want ErrUnauthorized", err)
+	}
+	srv.ClearFailures()
+	if err := client.Pause(gid); err != nil {
+		t.Errorf("pause after ClearFailures: %v", err)
+	}
+
+	results, err := client.Multicall([]aria2.Call{{Method: "aria2.noSuchMethod"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, aria2.ErrMethodNotFound) {
+		t.Errorf("unknown method: %v, want ErrMethodNotFound", results[0].Err)
+	}
+}
+
+func TestWebSocketNotifications(t *testing.T) {
+	srv := aria2test.NewServer("secret")
+	defer srv.Close()
+	client := srv.WSClient()
+	defer client.Close()
+
+	events := make(chan aria2.Event, 16)
+	sub := client.SubscribeChan(events)
+	defer sub.Unsubscribe()
+
+	// 请求和响应同样经过 WebSocket，完成后连接已经建立，之后的事件不会丢失
+	if _, err := client.GetVersion(); err != nil {
+		t.Fatal(err)
+	}
+
+	next := func() aria2.Event {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a notification")
+			return aria2.Event{}
+		}
+	}
+
+	gid, err := client.AddURI([]string{"http://example.com/file.iso"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event := next(); event != (aria2.Event{Type: aria2.EventDownloadStart, GID: gid}) {
+		t.Errorf("after addUri got %+v, want onDownloadStart for %s", event, gid)
+	}
+
+	if err := client.Pause(gid); err != nil {
+		t.Fatal(err)
+	}
+	if event := next(); event != (aria2.Event{Type: aria2.EventDownloadPause, GID: gid}) {
+		t.Errorf("after pause got %+v, want onDownloadPause for %s", event, gid)
+	}
+
+	if err := client.Unpause(gid); err != nil {
+		t.Fatal(err)
+	}
+	if event := next(); event.Type != aria2.EventDownloadStart {
+		t.Errorf("after unpause got %+v, want onDownloadStart", event)
+	}
+
+	// 服务端触发的事件同样推送给客户端
+	srv.Complete(gid)
+	if event := next(); event != (aria2.Event{Type: aria2.EventDownloadComplete, GID: gid}) {
+		t.Errorf("after completion got %+v, want onDownloadComplete for %s", event, gid)
+	}
+}
@@ -0,0 +1,717 @@
+package aria2test
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+// defaultLength 新任务的默认大小
+const defaultLength = 1 << 20
+
+// task 模拟的下载任务
+type task struct {
+	gid             string
+	status          string
+	dir             string
+	name            string
+	uris            []string
+	totalLength     int64
+	completedLength int64
+	downloadSpeed   int64
+	infoHash        string
+	errorCode       string
+	errorMessage    string
+	options         aria2.Options
+}
+
+// statusMap 按 aria2 的编码方式返回任务状态，数字字段均为字符串
+func (t *task) statusMap() map[string]interface{} {
+	connections := "0"
+	if t.status == "active" {
+		connections = "1"
+	}
+
+	status := map[string]interface{}{
+		"gid":             t.gid,
+		"status":          t.status,
+		"totalLength":     strconv.FormatInt(t.totalLength, 10),
+		"completedLength": strconv.FormatInt(t.completedLength, 10),
+		"uploadLength":    "0",
+		"bitfield":        "",
+		"downloadSpeed":   strconv.FormatInt(t.downloadSpeed, 10),
+		"uploadSpeed":     "0",
+		"pieceLength":     "1048576",
+		"numPieces":       strconv.FormatInt((t.totalLength+1<<20-1)>>20, 10),
+		"connections":     connections,
+		"dir":             t.dir,
+		"files":           []interface{}{t.file()},
+	}
+	if t.errorCode != "" {
+		status["errorCode"] = t.errorCode
+		status["errorMessage"] = t.errorMessage
+	}
+	if t.infoHash != "" {
+		status["infoHash"] = t.infoHash
+		status["numSeeders"] = "0"
+		status["seeder"] = "false"
+		status["bittorrent"] = map[string]interface{}{
+			"announceList": [][]string{},
+			"mode":         "single",
+			"info":         map[string]string{"name": t.name},
+		}
+	}
+	return status
+}
+
+// file 任务的文件信息，模拟任务只有一个文件
+func (t *task) file() map[string]interface{} {
+	uris := make([]map[string]string, len(t.uris))
+	for i, uri := range t.uris {
+		uris[i] = map[string]string{"uri": uri, "status": "used"}
+	}
+	return map[string]interface{}{
+		"index":           "1",
+		"path":            path.Join(t.dir, t.name),
+		"length":          strconv.FormatInt(t.totalLength, 10),
+		"completedLength": strconv.FormatInt(t.completedLength, 10),
+		"selected":        "true",
+		"uris":            uris,
+	}
+}
+
+// methods 支持的 RPC 方法
+var methods = map[string]func(s *Server, a args) (interface{}, *aria2.RPCError){
+	"aria2.getVersion":           (*Server).getVersion,
+	"aria2.tellStatus":           (*Server).tellStatus,
+	"aria2.tellActive":           (*Server).tellActive,
+	"aria2.tellWaiting":          (*Server).tellWaiting,
+	"aria2.tellStopped":          (*Server).tellStopped,
+	"aria2.addUri":               (*Server).addURI,
+	"aria2.addTorrent":           (*Server).addTorrent,
+	"aria2.addMetalink":          (*Server).addMetalink,
+	"aria2.pause":                (*Server).pause,
+	"aria2.forcePause":           (*Server).pause,
+	"aria2.pauseAll":             (*Server).pauseAll,
+	"aria2.forcePauseAll":        (*Server).pauseAll,
+	"aria2.unpause":              (*Server).unpause,
+	"aria2.unpauseAll":           (*Server).unpauseAll,
+	"aria2.remove":               (*Server).remove,
+	"aria2.forceRemove":          (*Server).remove,
+	"aria2.removeDownloadResult": (*Server).removeDownloadResult,
+	"aria2.purgeDownloadResult":  (*Server).purgeDownloadResult,
+	"aria2.changePosition":       (*Server).changePosition,
+	"aria2.getGlobalStat":        (*Server).getGlobalStat,
+	"aria2.getOption":            (*Server).getOption,
+	"aria2.changeOption":         (*Server).changeOption,
+	"aria2.getGlobalOption":      (*Server).getGlobalOption,
+	"aria2.changeGlobalOption":   (*Server).changeGlobalOption,
+	"aria2.getFiles":             (*Server).getFiles,
+	"aria2.getUris":              (*Server).getURIs,
+	"aria2.getPeers":             (*Server).getPeers,
+	"aria2.getServers":           (*Server).getServers,
+	"aria2.saveSession":          (*Server).saveSession,
+	"aria2.getSessionInfo":       (*Server).getSessionInfo,
+	"aria2.shutdown":             (*Server).shutdown,
+	"aria2.forceShutdown":        (*Server).shutdown,
+}
+
+func init() {
+	// listMethods 需要读取 methods，不能直接写在初始化表达式中
+	methods["system.listMethods"] = (*Server).listMethods
+}
+
+// AddTask 添加一个 HTTP 下载任务，与 aria2.addUri 一样进入等待队列后按并发数开始
+func (s *Server) AddTask(uri string) string {
+	s.mu.Lock()
+	gid := s.newTask([]string{uri}, aria2.Options{}, -1)
+	s.mu.Unlock()
+
+	s.flushEvents()
+	return gid
+}
+
+// Status 返回任务状态，任务不存在时返回空字符串
+func (s *Server) Status(gid string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tasks[gid]; ok {
+		return t.status
+	}
+	return ""
+}
+
+// Active 返回活动任务的 GID
+func (s *Server) Active() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.active...)
+}
+
+// Waiting 返回等待队列（含暂停任务）的 GID，按队列顺序排列
+func (s *Server) Waiting() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.waiting...)
+}
+
+// Stopped 返回已停止任务的 GID，按停止先后排列
+func (s *Server) Stopped() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.stopped...)
+}
+
+// SessionSaves 返回 aria2.saveSession 被调用的次数
+func (s *Server) SessionSaves() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sessionSaves
+}
+
+// IsShutdown 是否已收到 aria2.shutdown 或 aria2.forceShutdown
+func (s *Server) IsShutdown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.shutDown
+}
+
+// SetMaxConcurrent 设置同时下载的任务数，相当于修改 max-concurrent-downloads
+// 设为 0 时所有任务都留在等待队列中，便于测试队列排序
+func (s *Server) SetMaxConcurrent(n int) {
+	s.mu.Lock()
+	s.maxConcurrent = n
+	s.globalOptions["max-concurrent-downloads"] = strconv.Itoa(n)
+	s.schedule()
+	s.mu.Unlock()
+
+	s.flushEvents()
+}
+
+// SetProgress 设置活动任务的已完成大小和下载速度
+func (s *Server) SetProgress(gid string, completedLength int64, downloadSpeed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.tasks[gid]; ok {
+		t.completedLength = completedLength
+		t.downloadSpeed = downloadSpeed
+	}
+}
+
+// Complete 让活动任务下载完成
+func (s *Server) Complete(gid string) {
+	s.mu.Lock()
+	if t, ok := s.tasks[gid]; ok && t.status == "active" {
+		t.completedLength = t.totalLength
+		s.stop(t, "complete", "aria2.onDownloadComplete")
+		s.schedule()
+	}
+	s.mu.Unlock()
+
+	s.flushEvents()
+}
+
+// Fail 让活动任务以指定错误码失败
+func (s *Server) Fail(gid string, code int, message string) {
+	s.mu.Lock()
+	if t, ok := s.tasks[gid]; ok && t.status == "active" {
+		t.errorCode = strconv.Itoa(code)
+		t.errorMessage = message
+		s.stop(t, "error", "aria2.onDownloadError")
+		s.schedule()
+	}
+	s.mu.Unlock()
+
+	s.flushEvents()
+}
+
+// newTask 创建任务并放入等待队列，position 小于 0 时追加到末尾
+func (s *Server) newTask(uris []string, options aria2.Options, position int) string {
+	s.nextGID++
+	gid := fmt.Sprintf("%016x", s.nextGID)
+
+	dir := options["dir"]
+	if dir == "" {
+		dir = s.globalOptions["dir"]
+	}
+	name := options["out"]
+	if name == "" && len(uris) > 0 {
+		name = path.Base(uris[0])
+	}
+
+	taskOptions := aria2.Options{"dir": dir}
+	for key, value := range options {
+		taskOptions[key] = value
+	}
+
+	t := &task{
+		gid:         gid,
+		status:      "waiting",
+		dir:         dir,
+		name:        name,
+		uris:        uris,
+		totalLength: defaultLength,
+		options:     taskOptions,
+	}
+	if options["pause"] == "true" {
+		t.status = "paused"
+	}
+	s.tasks[gid] = t
+	s.waiting = insertAt(s.waiting, gid, position)
+	s.schedule()
+	return gid
+}
+
+// schedule 按 max-concurrent-downloads 将等待队列前面的任务转为活动
+func (s *Server) schedule() {
+	for i := 0; i < len(s.waiting) && len(s.active) < s.maxConcurrent; {
+		t := s.tasks[s.waiting[i]]
+		if t.status != "waiting" {
+			i++
+			continue
+		}
+		s.waiting = removeGID(s.waiting, t.gid)
+		s.active = append(s.active, t.gid)
+		t.status = "active"
+		s.events = append(s.events, notification{"aria2.onDownloadStart", t.gid})
+	}
+}
+
+// stop 将任务移入已停止列表
+func (s *Server) stop(t *task, status string, event string) {
+	s.active = removeGID(s.active, t.gid)
+	s.waiting = removeGID(s.waiting, t.gid)
+	s.stopped = append(s.stopped, t.gid)
+	t.status = status
+	t.downloadSpeed = 0
+	s.events = append(s.events, notification{event, t.gid})
+}
+
+// lookup 查找任务，不存在时返回与 aria2 相同的错误
+func (s *Server) lookup(a args) (*task, *aria2.RPCError) {
+	gid, ok := a.stringAt(0)
+	if !ok {
+		return nil, invalidParams()
+	}
+	t, ok := s.tasks[gid]
+	if !ok {
+		return nil, &aria2.RPCError{Code: 1, Message: fmt.Sprintf("GID %s is not found", gid)}
+	}
+	return t, nil
+}
+
+func (s *Server) getVersion(a args) (interface{}, *aria2.RPCError) {
+	return map[string]interface{}{
+		"version":         "1.37.0",
+		"enabledFeatures": []string{"BitTorrent", "Metalink", "WebSocket"},
+	}, nil
+}
+
+func (s *Server) tellStatus(a args) (interface{}, *aria2.RPCError) {
+	t, err := s.lookup(a)
+	if err != nil {
+		return nil, err
+	}
+	return filterKeys(t.statusMap(), a.stringsAt(1)), nil
+}
+
+func (s *Server) tellActive(a args) (interface{}, *aria2.RPCError) {
+	return s.statusList(s.active, a.stringsAt(0)), nil
+}
+
+func (s *Server) tellWaiting(a args) (interface{}, *aria2.RPCError) {
+	return s.pagedStatusList(s.waiting, a)
+}
+
+func (s *Server) tellStopped(a args) (interface{}, *aria2.RPCError) {
+	return s.pagedStatusList(s.stopped, a)
+}
+
+// pagedStatusList 按 offset 和 num 截取列表
+// offset 为负数时从末尾开始计算并倒序返回，与 aria2 一致
+func (s *Server) pagedStatusList(gids []string, a args) (interface{}, *aria2.RPCError) {
+	offset, ok1 := a.intAt(0)
+	num, ok2 := a.intAt(1)
+	if !ok1 || !ok2 || num < 0 {
+		return nil, invalidParams()
+	}
+
+	var page []string
+	if offset >= 0 {
+		for i := offset; i < len(gids) && len(page) < num; i++ {
+			page = append(page, gids[i])
+		}
+	} else {
+		for i := len(gids) + offset; i >= 0 && len(page) < num; i-- {
+			if i < len(gids) {
+				page = append(page, gids[i])
+			}
+		}
+	}
+	return s.statusList(page, a.stringsAt(2)), nil
+}
+
+// statusList 返回多个任务的状态
+func (s *Server) statusList(gids []string, keys []string) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(gids))
+	for _, gid := range gids {
+		list = append(list, filterKeys(s.tasks[gid].statusMap(), keys))
+	}
+	return list
+}
+
+// filterKeys 只保留 keys 中的字段，keys 为空时返回全部字段
+func filterKeys(status map[string]interface{}, keys []string) map[string]interface{} {
+	if len(keys) == 0 {
+		return status
+	}
+	filtered := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if value, ok := status[key]; ok {
+			filtered[key] = value
+		}
+	}
+	return filtered
+}
+
+func (s *Server) addURI(a args) (interface{}, *aria2.RPCError) {
+	uris := a.stringsAt(0)
+	if len(uris) == 0 {
+		return nil, &aria2.RPCError{Code: 1, Message: "No URI to download."}
+	}
+	position, ok := a.intAt(2)
+	if !ok {
+		position = -1
+	}
+	return s.newTask(uris, a.optionsAt(1), position), nil
+}
+
+func (s *Server) addTorrent(a args) (interface{}, *aria2.RPCError) {
+	torrent, ok := a.stringAt(0)
+	if !ok || torrent == "" {
+		return nil, invalidParams()
+	}
+	position, ok := a.intAt(3)
+	if !ok {
+		position = -1
+	}
+
+	options := a.optionsAt(2)
+	if options["out"] == "" {
+		options["out"] = "torrent-" + torrent[:min(8, len(torrent))]
+	}
+	gid := s.newTask(a.stringsAt(1), options, position)
+	s.tasks[gid].infoHash = hex.EncodeToString([]byte(gid + gid[:4]))
+	return gid, nil
+}
+
+func (s *Server) addMetalink(a args) (interface{}, *aria2.RPCError) {
+	metalink, ok := a.stringAt(0)
+	if !ok || metalink == "" {
+		return nil, invalidParams()
+	}
+	position, ok := a.intAt(2)
+	if !ok {
+		position = -1
+	}
+
+	options := a.optionsAt(1)
+	if options["out"] == "" {
+		options["out"] = "metalink-" + metalink[:min(8, len(metalink))]
+	}
+	return []string{s.newTask(nil, options, position)}, nil
+}
+
+func (s *Server) pause(a args) (interface{}, *aria2.RPCError) {
+	t, err := s.lookup(a)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.status {
+	case "active":
+		// 暂停的活动任务回到等待队列最前面
+		s.active = removeGID(s.active, t.gid)
+		s.waiting = insertAt(s.waiting, t.gid, 0)
+	case "waiting":
+	default:
+		return nil, &aria2.RPCError{Code: 1, Message: fmt.Sprintf("GID#%s cannot be paused now", t.gid)}
+	}
+
+	t.status = "paused"
+	t.downloadSpeed = 0
+	s.events = append(s.events, notification{"aria2.onDownloadPause", t.gid})
+	s.schedule()
+	return t.gid, nil
+}
+
+func (s *Server) pauseAll(a args) (interface{}, *aria2.RPCError) {
+	for i := len(s.active) - 1; i >= 0; i-- {
+		s.waiting = insertAt(s.waiting, s.active[i], 0)
+	}
+	s.active = nil
+
+	for _, gid := range s.waiting {
+		t := s.tasks[gid]
+		if t.status != "paused" {
+			t.status = "paused"
+			t.downloadSpeed = 0
+			s.events = append(s.events, notification{"aria2.onDownloadPause", gid})
+		}
+	}
+	return "OK", nil
+}
+
+func (s *Server) unpause(a args) (interface{}, *aria2.RPCError) {
+	t, err := s.lookup(a)
+	if err != nil {
+		return nil, err
+	}
+	if t.status != "paused" {
+		return nil, &aria2.RPCError{Code: 1, Message: fmt.Sprintf("GID#%s cannot be unpaused now", t.gid)}
+	}
+
+	t.status = "waiting"
+	s.schedule()
+	return t.gid, nil
+}
+
+func (s *Server) unpauseAll(a args) (interface{}, *aria2.RPCError) {
+	for _, gid := range s.waiting {
+		if t := s.tasks[gid]; t.status == "paused" {
+			t.status = "waiting"
+		}
+	}
+	s.schedule()
+	return "OK", nil
+}
+
+func (s *Server) remove(a args) (interface{}, *aria2.RPCError) {
+	gid, ok := a.stringAt(0)
+	if !ok {
+		return nil, invalidParams()
+	}
+	t, ok := s.tasks[gid]
+	if !ok || t.status == "complete" || t.status == "error" || t.status == "removed" {
+		return nil, &aria2.RPCError{Code: 1, Message: fmt.Sprintf("Active Download not found for GID#%s", gid)}
+	}
+
+	s.stop(t, "removed", "aria2.onDownloadStop")
+	s.schedule()
+	return gid, nil
+}
+
+func (s *Server) removeDownloadResult(a args) (interface{}, *aria2.RPCError) {
+	gid, ok := a.stringAt(0)
+	if !ok {
+		return nil, invalidParams()
+	}
+	t, ok := s.tasks[gid]
+	if !ok || (t.status != "complete" && t.status != "error" && t.status != "removed") {
+		return nil, &aria2.RPCError{Code: 1, Message: fmt.Sprintf("Could not remove download result of GID#%s", gid)}
+	}
+
+	s.stopped = removeGID(s.stopped, gid)
+	delete(s.tasks, gid)
+	return "OK", nil
+}
+
+func (s *Server) purgeDownloadResult(a args) (interface{}, *aria2.RPCError) {
+	for _, gid := range s.stopped {
+		delete(s.tasks, gid)
+	}
+	s.stopped = nil
+	return "OK", nil
+}
+
+func (s *Server) changePosition(a args) (interface{}, *aria2.RPCError) {
+	gid, ok1 := a.stringAt(0)
+	pos, ok2 := a.intAt(1)
+	how, ok3 := a.stringAt(2)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, invalidParams()
+	}
+
+	index := indexOf(s.waiting, gid)
+	if index < 0 {
+		return nil, &aria2.RPCError{Code: 1, Message: fmt.Sprintf("GID#%s not found in the waiting queue.", gid)}
+	}
+
+	// 目标位置按移除该任务前的队列计算，并限制在队列范围内
+	last := len(s.waiting) - 1
+	var dest int
+	switch aria2.PositionHow(how) {
+	case aria2.PosSet:
+		dest = pos
+	case aria2.PosCur:
+		dest = index + pos
+	case aria2.PosEnd:
+		dest = last + pos
+	default:
+		return nil, &aria2.RPCError{Code: 1, Message: "Illegal argument."}
+	}
+	if dest < 0 {
+		dest = 0
+	}
+	if dest > last {
+		dest = last
+	}
+
+	s.waiting = insertAt(removeGID(s.waiting, gid), gid, dest)
+	return dest, nil
+}
+
+func (s *Server) getGlobalStat(a args) (interface{}, *aria2.RPCError) {
+	var speed int64
+	for _, gid := range s.active {
+		speed += s.tasks[gid].downloadSpeed
+	}
+	return map[string]string{
+		"downloadSpeed":   strconv.FormatInt(speed, 10),
+		"uploadSpeed":     "0",
+		"numActive":       strconv.Itoa(len(s.active)),
+		"numWaiting":      strconv.Itoa(len(s.waiting)),
+		"numStopped":      strconv.Itoa(len(s.stopped)),
+		"numStoppedTotal": strconv.Itoa(len(s.stopped)),
+	}, nil
+}
+
+func (s *Server) getOption(a args) (interface{}, *aria2.RPCError) {
+	t, err := s.lookup(a)
+	if err != nil {
+		return nil, err
+	}
+	return t.options, nil
+}
+
+func (s *Server) changeOption(a args) (interface{}, *aria2.RPCError) {
+	t, err := s.lookup(a)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range a.optionsAt(1) {
+		t.options[key] = value
+	}
+	return "OK", nil
+}
+
+func (s *Server) getGlobalOption(a args) (interface{}, *aria2.RPCError) {
+	return s.globalOptions, nil
+}
+
+func (s *Server) changeGlobalOption(a args) (interface{}, *aria2.RPCError) {
+	options := a.optionsAt(0)
+	if value, ok := options["max-concurrent-downloads"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, &aria2.RPCError{Code: 1, Message: "max-concurrent-downloads must be a number between 1 and *"}
+		}
+		s.maxConcurrent = n
+	}
+	for key, value := range options {
+		s.globalOptions[key] = value
+	}
+	s.schedule()
+	return "OK", nil
+}
+
+func (s *Server) getFiles(a args) (interface{}, *aria2.RPCError) {
+	t, err := s.lookup(a)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{t.file()}, nil
+}
+
+func (s *Server) getURIs(a args) (interface{}, *aria2.RPCError) {
+	t, err := s.lookup(a)
+	if err != nil {
+		return nil, err
+	}
+	uris := make([]map[string]string, len(t.uris))
+	for i, uri := range t.uris {
+		uris[i] = map[string]string{"uri": uri, "status": "used"}
+	}
+	return uris, nil
+}
+
+func (s *Server) getPeers(a args) (interface{}, *aria2.RPCError) {
+	if _, err := s.lookup(a); err != nil {
+		return nil, err
+	}
+	return []interface{}{}, nil
+}
+
+func (s *Server) getServers(a args) (interface{}, *aria2.RPCError) {
+	t, err := s.lookup(a)
+	if err != nil {
+		return nil, err
+	}
+	if t.status != "active" {
+		return nil, &aria2.RPCError{Code: 1, Message: fmt.Sprintf("No active download for GID#%s", t.gid)}
+	}
+
+	servers := make([]map[string]string, len(t.uris))
+	for i, uri := range t.uris {
+		servers[i] = map[string]string{
+			"uri":           uri,
+			"currentUri":    uri,
+			"downloadSpeed": strconv.FormatInt(t.downloadSpeed, 10),
+		}
+	}
+	return []interface{}{map[string]interface{}{"index": "1", "servers": servers}}, nil
+}
+
+func (s *Server) saveSession(a args) (interface{}, *aria2.RPCError) {
+	s.sessionSaves++
+	return "OK", nil
+}
+
+func (s *Server) getSessionInfo(a args) (interface{}, *aria2.RPCError) {
+	return map[string]string{"sessionId": s.sessionID}, nil
+}
+
+func (s *Server) shutdown(a args) (interface{}, *aria2.RPCError) {
+	s.shutDown = true
+	return "OK", nil
+}
+
+func (s *Server) listMethods(a args) (interface{}, *aria2.RPCError) {
+	names := make([]string, 0, len(methods)+1)
+	for name := range methods {
+		names = append(names, name)
+	}
+	return append(names, "system.multicall"), nil
+}
+
+// insertAt 将 gid 插入到 position 处，position 小于 0 或超出范围时追加到末尾
+func insertAt(gids []string, gid string, position int) []string {
+	if position < 0 || position >= len(gids) {
+		return append(gids, gid)
+	}
+	gids = append(gids, "")
+	copy(gids[position+1:], gids[position:])
+	gids[position] = gid
+	return gids
+}
+
+// removeGID 从列表中移除 gid
+func removeGID(gids []string, gid string) []string {
+	if i := indexOf(gids, gid); i >= 0 {
+		return append(gids[:i], gids[i+1:]...)
+	}
+	return gids
+}
+
+// indexOf 返回 gid 在列表中的位置，不存在时返回 -1
+func indexOf(gids []string, gid string) int {
+	for i, g := range gids {
+		if g == gid {
+			return i
+		}
+	}
+	return -1
+}
@@ -1,29 +1,55 @@
 package aria2
 
 import (
-	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
 )
 
 // Client aria2 RPC 客户端
 type Client struct {
-	rpcURL string
-	token  string
-	client *http.Client
+	rpcURL    string
+	token     string
+	transport transport
+	timeout   time.Duration
+	lastID    uint64
+	events    eventHub
 }
 
 // NewClient 创建新的 aria2 RPC 客户端
+// protocol 为 ws 或 wss 时使用 WebSocket 长连接，否则使用 HTTP POST
 func NewClient(host string, port int, token string, protocol string, path string) *Client {
+	return NewClientTLS(host, port, token, protocol, path, nil)
+}
+
+// NewClientTLS 创建使用指定 TLS 配置的客户端，对 https 和 wss 连接生效
+// tlsConfig 为 nil 时与 NewClient 相同；可通过 TLSOptions.Config 构建
+func NewClientTLS(host string, port int, token string, protocol string, path string, tlsConfig *tls.Config) *Client {
 	rpcURL := fmt.Sprintf("%s://%s:%d%s", protocol, host, port, path)
 	return &Client{
-		rpcURL: rpcURL,
-		token:  token,
-		client: &http.Client{},
+		rpcURL:    rpcURL,
+		token:     token,
+		transport: newTransport(protocol, rpcURL, tlsConfig),
 	}
 }
 
+// SetTimeout 设置调用的默认超时时间，0 表示不限制
+// 调用方传入的 ctx 已带截止时间时以 ctx 为准
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// Close 关闭客户端持有的连接
+func (c *Client) Close() error {
+	return c.transport.close()
+}
+
 // RPCRequest RPC 请求结构
 type RPCRequest struct {
 	JSONRPC string        `json:"jsonrpc"`
@@ -40,7 +66,7 @@ type RPCResponse struct {
 	ID      string          `json:"id"`
 }
 
-// RPCError RPC 错误结构
+// RPCError aria2 返回的 RPC 错误，可通过 errors.Is 判断 ErrUnauthorized 等类别
 type RPCError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -52,22 +78,22 @@ type Version struct {
 	EnabledFeatures []string `json:"enabledFeatures"`
 }
 
-// TellStatus 任务状态
+// TellStatus 任务状态，长度以字节为单位，速度以字节每秒为单位
 type TellStatus struct {
 	GID           string            `json:"gid"`
 	Status        string            `json:"status"`
-	TotalLength   string            `json:"totalLength"`
-	CompletedLength string          `json:"completedLength"`
-	UploadLength  string            `json:"uploadLength"`
+	TotalLength   Int64             `json:"totalLength"`
+	CompletedLength Int64           `json:"completedLength"`
+	UploadLength  Int64             `json:"uploadLength"`
 	Bitfield      string            `json:"bitfield"`
-	DownloadSpeed string            `json:"downloadSpeed"`
-	UploadSpeed   string            `json:"uploadSpeed"`
+	DownloadSpeed Int64             `json:"downloadSpeed"`
+	UploadSpeed   Int64             `json:"uploadSpeed"`
 	InfoHash      string            `json:"infoHash"`
-	NumSeeders    int               `json:"numSeeders"`
+	NumSeeders    Int64             `json:"numSeeders"`
 	Seeder        string            `json:"seeder"`
-	PieceLength   string            `json:"pieceLength"`
-	NumPieces     int               `json:"numPieces"`
-	Connections   int               `json:"connections"`
+	PieceLength   Int64             `json:"pieceLength"`
+	NumPieces     Int64             `json:"numPieces"`
+	Connections   Int64             `json:"connections"`
 	ErrorCode     string            `json:"errorCode"`
 	ErrorMessage  string            `json:"errorMessage"`
 	FollowedBy    []string          `json:"followedBy"`
@@ -75,16 +101,16 @@ type TellStatus struct {
 	Dir           string            `json:"dir"`
 	Files         []FileInfo        `json:"files"`
 	Bittorrent    *BittorrentInfo   `json:"bittorrent"`
-	VerifiedLength string           `json:"verifiedLength"`
+	VerifiedLength Int64            `json:"verifiedLength"`
 	VerifyIntegrityPending string   `json:"verifyIntegrityPending"`
 }
 
 // FileInfo 文件信息
 type FileInfo struct {
-	Index    string   `json:"index"`
+	Index    Int64    `json:"index"`
 	Path     string   `json:"path"`
-	Length   string   `json:"length"`
-	CompletedLength string `json:"completedLength"`
+	Length   Int64    `json:"length"`
+	CompletedLength Int64 `json:"completedLength"`
 	Selected string   `json:"selected"`
 	URIs     []URI    `json:"uris"`
 }
@@ -106,284 +132,338 @@ type BittorrentInfo struct {
 	} `json:"info"`
 }
 
-// GetVersion 获取 aria2 版本信息
-func (c *Client) GetVersion() (*Version, error) {
-	request := RPCRequest{
-		JSONRPC: "2.0",
-		Method:  "aria2.getVersion",
-		Params:  []interface{}{"token:" + c.token},
-		ID:      "1",
-	}
+// TellStatus 获取单个任务的状态，keys 为空时返回全部字段
+func (c *Client) TellStatus(gid string, keys ...string) (*TellStatus, error) {
+	return c.TellStatusContext(context.Background(), gid, keys...)
+}
 
-	response, err := c.sendRequest(request)
-	if err != nil {
+// TellStatusContext 获取单个任务的状态，可通过 ctx 取消
+func (c *Client) TellStatusContext(ctx context.Context, gid string, keys ...string) (*TellStatus, error) {
+	var task TellStatus
+	if err := c.call(ctx, "aria2.tellStatus", keyParams([]interface{}{gid}, keys), &task); err != nil {
 		return nil, err
 	}
+	return &task, nil
+}
 
-	if response.Error != nil {
-		return nil, fmt.Errorf("RPC error: %s", response.Error.Message)
-	}
+// GetVersion 获取 aria2 版本信息
+func (c *Client) GetVersion() (*Version, error) {
+	return c.GetVersionContext(context.Background())
+}
 
+// GetVersionContext 获取 aria2 版本信息，可通过 ctx 取消
+func (c *Client) GetVersionContext(ctx context.Context) (*Version, error) {
 	var version Version
-	if err := json.Unmarshal(response.Result, &version); err != nil {
+	if err := c.call(ctx, "aria2.getVersion", nil, &version); err != nil {
 		return nil, err
 	}
-
 	return &version, nil
 }
 
-// TellActive 获取活动任务列表
-func (c *Client) TellActive() ([]TellStatus, error) {
-	request := RPCRequest{
-		JSONRPC: "2.0",
-		Method:  "aria2.tellActive",
-		Params:  []interface{}{"token:" + c.token},
-		ID:      "1",
-	}
-
-	response, err := c.sendRequest(request)
-	if err != nil {
-		return nil, err
-	}
-
-	if response.Error != nil {
-		return nil, fmt.Errorf("RPC error: %s", response.Error.Message)
-	}
+// TellActive 获取活动任务列表，keys 为空时返回全部字段
+func (c *Client) TellActive(keys ...string) ([]TellStatus, error) {
+	return c.TellActiveContext(context.Background(), keys...)
+}
 
+// TellActiveContext 获取活动任务列表，可通过 ctx 取消
+func (c *Client) TellActiveContext(ctx context.Context, keys ...string) ([]TellStatus, error) {
 	var tasks []TellStatus
-	if err := json.Unmarshal(response.Result, &tasks); err != nil {
+	if err := c.call(ctx, "aria2.tellActive", keyParams(nil, keys), &tasks); err != nil {
 		return nil, err
 	}
-
 	return tasks, nil
 }
 
-// TellWaiting 获取等待任务列表
-func (c *Client) TellWaiting(offset int, num int) ([]TellStatus, error) {
-	request := RPCRequest{
-		JSONRPC: "2.0",
-		Method:  "aria2.tellWaiting",
-		Params:  []interface{}{"token:" + c.token, offset, num},
-		ID:      "1",
-	}
-
-	response, err := c.sendRequest(request)
-	if err != nil {
-		return nil, err
-	}
-
-	if response.Error != nil {
-		return nil, fmt.Errorf("RPC error: %s", response.Error.Message)
-	}
+// TellWaiting 获取等待任务列表，keys 为空时返回全部字段
+func (c *Client) TellWaiting(offset int, num int, keys ...string) ([]TellStatus, error) {
+	return c.TellWaitingContext(context.Background(), offset, num, keys...)
+}
 
+// TellWaitingContext 获取等待任务列表，可通过 ctx 取消
+func (c *Client) TellWaitingContext(ctx context.Context, offset int, num int, keys ...string) ([]TellStatus, error) {
 	var tasks []TellStatus
-	if err := json.Unmarshal(response.Result, &tasks); err != nil {
+	if err := c.call(ctx, "aria2.tellWaiting", keyParams([]interface{}{offset, num}, keys), &tasks); err != nil {
 		return nil, err
 	}
-
 	return tasks, nil
 }
 
-// TellStopped 获取已停止任务列表
-func (c *Client) TellStopped(offset int, num int) ([]TellStatus, error) {
-	request := RPCRequest{
-		JSONRPC: "2.0",
-		Method:  "aria2.tellStopped",
-		Params:  []interface{}{"token:" + c.token, offset, num},
-		ID:      "1",
-	}
-
-	response, err := c.sendRequest(request)
-	if err != nil {
-		return nil, err
-	}
-
-	if response.Error != nil {
-		return nil, fmt.Errorf("RPC error: %s", response.Error.Message)
-	}
+// TellStopped 获取已停止任务列表，keys 为空时返回全部字段
+func (c *Client) TellStopped(offset int, num int, keys ...string) ([]TellStatus, error) {
+	return c.TellStoppedContext(context.Background(), offset, num, keys...)
+}
 
+// TellStoppedContext 获取已停止任务列表，可通过 ctx 取消
+func (c *Client) TellStoppedContext(ctx context.Context, offset int, num int, keys ...string) ([]TellStatus, error) {
 	var tasks []TellStatus
-	if err := json.Unmarshal(response.Result, &tasks); err != nil {
+	if err := c.call(ctx, "aria2.tellStopped", keyParams([]interface{}{offset, num}, keys), &tasks); err != nil {
 		return nil, err
 	}
-
 	return tasks, nil
 }
 
+// keyParams 在参数末尾追加要返回的字段列表
+// aria2 只返回 keys 中列出的字段，可避免每次都传输 bitfield、files 等体积较大的字段
+func keyParams(params []interface{}, keys []string) []interface{} {
+	if len(keys) == 0 {
+		return params
+	}
+	return append(params, keys)
+}
+
 // AddURI 添加下载任务
 func (c *Client) AddURI(uris []string, options map[string]interface{}) (string, error) {
-	request := RPCRequest{
-		JSONRPC: "2.0",
-		Method:  "aria2.addUri",
-		Params:  []interface{}{"token:" + c.token, uris, options},
-		ID:      "1",
-	}
+	return c.AddURIContext(context.Background(), uris, options)
+}
 
-	response, err := c.sendRequest(request)
-	if err != nil {
+// AddURIContext 添加下载任务，可通过 ctx 取消
+func (c *Client) AddURIContext(ctx context.Context, uris []string, options map[string]interface{}) (string, error) {
+	var gid string
+	if err := c.call(ctx, "aria2.addUri", []interface{}{uris, options}, &gid); err != nil {
 		return "", err
 	}
+	return gid, nil
+}
 
-	if response.Error != nil {
-		return "", fmt.Errorf("RPC error: %s", response.Error.Message)
+// AddTorrent 上传种子文件添加下载任务
+// webSeeds 为可选的 Web 种子地址，position 小于 0 时追加到等待队列末尾
+func (c *Client) AddTorrent(torrent []byte, webSeeds []string, options map[string]interface{}, position int) (string, error) {
+	return c.AddTorrentContext(context.Background(), torrent, webSeeds, options, position)
+}
+
+// AddTorrentContext 上传种子文件添加下载任务，可通过 ctx 取消
+func (c *Client) AddTorrentContext(ctx context.Context, torrent []byte, webSeeds []string, options map[string]interface{}, position int) (string, error) {
+	if webSeeds == nil {
+		webSeeds = []string{}
 	}
+	params := append([]interface{}{base64.StdEncoding.EncodeToString(torrent), webSeeds}, optionParams(options, position)...)
 
 	var gid string
-	if err := json.Unmarshal(response.Result, &gid); err != nil {
+	if err := c.call(ctx, "aria2.addTorrent", params, &gid); err != nil {
 		return "", err
 	}
-
 	return gid, nil
 }
 
-// Pause 暂停任务
-func (c *Client) Pause(gid string) error {
-	request := RPCRequest{
-		JSONRPC: "2.0",
-		Method:  "aria2.pause",
-		Params:  []interface{}{"token:" + c.token, gid},
-		ID:      "1",
-	}
+// AddMetalink 上传 Metalink 文件添加下载任务，返回新建任务的 GID 列表
+// position 小于 0 时追加到等待队列末尾
+func (c *Client) AddMetalink(metalink []byte, options map[string]interface{}, position int) ([]string, error) {
+	return c.AddMetalinkContext(context.Background(), metalink, options, position)
+}
 
-	response, err := c.sendRequest(request)
-	if err != nil {
-		return err
+// AddMetalinkContext 上传 Metalink 文件添加下载任务，可通过 ctx 取消
+func (c *Client) AddMetalinkContext(ctx context.Context, metalink []byte, options map[string]interface{}, position int) ([]string, error) {
+	params := append([]interface{}{base64.StdEncoding.EncodeToString(metalink)}, optionParams(options, position)...)
+
+	var gids []string
+	if err := c.call(ctx, "aria2.addMetalink", params, &gids); err != nil {
+		return nil, err
 	}
+	return gids, nil
+}
 
-	if response.Error != nil {
-		return fmt.Errorf("RPC error: %s", response.Error.Message)
+// optionParams 构造选项和队列位置参数，位置小于 0 时省略
+func optionParams(options map[string]interface{}, position int) []interface{} {
+	if options == nil {
+		options = map[string]interface{}{}
+	}
+	if position < 0 {
+		return []interface{}{options}
 	}
+	return []interface{}{options, position}
+}
 
-	return nil
+// Pause 暂停任务
+func (c *Client) Pause(gid string) error {
+	return c.PauseContext(context.Background(), gid)
+}
+
+// PauseContext 暂停任务，可通过 ctx 取消
+func (c *Client) PauseContext(ctx context.Context, gid string) error {
+	return c.call(ctx, "aria2.pause", []interface{}{gid}, nil)
 }
 
 // Unpause 恢复任务
 func (c *Client) Unpause(gid string) error {
-	request := RPCRequest{
-		JSONRPC: "2.0",
-		Method:  "aria2.unpause",
-		Params:  []interface{}{"token:" + c.token, gid},
-		ID:      "1",
-	}
-
-	response, err := c.sendRequest(request)
-	if err != nil {
-		return err
-	}
-
-	if response.Error != nil {
-		return fmt.Errorf("RPC error: %s", response.Error.Message)
-	}
+	return c.UnpauseContext(context.Background(), gid)
+}
 
-	return nil
+// UnpauseContext 恢复任务，可通过 ctx 取消
+func (c *Client) UnpauseContext(ctx context.Context, gid string) error {
+	return c.call(ctx, "aria2.unpause", []interface{}{gid}, nil)
 }
 
 // Remove 删除任务
 func (c *Client) Remove(gid string) error {
-	request := RPCRequest{
-		JSONRPC: "2.0",
-		Method:  "aria2.remove",
-		Params:  []interface{}{"token:" + c.token, gid},
-		ID:      "1",
-	}
+	return c.RemoveContext(context.Background(), gid)
+}
 
-	response, err := c.sendRequest(request)
-	if err != nil {
-		return err
-	}
+// RemoveContext 删除任务，可通过 ctx 取消
+func (c *Client) RemoveContext(ctx context.Context, gid string) error {
+	return c.call(ctx, "aria2.remove", []interface{}{gid}, nil)
+}
 
-	if response.Error != nil {
-		return fmt.Errorf("RPC error: %s", response.Error.Message)
-	}
+// ForcePause 强制暂停任务，不等待与服务器注销等收尾操作
+func (c *Client) ForcePause(gid string) error {
+	return c.ForcePauseContext(context.Background(), gid)
+}
+
+// ForcePauseContext 强制暂停任务，可通过 ctx 取消
+func (c *Client) ForcePauseContext(ctx context.Context, gid string) error {
+	return c.call(ctx, "aria2.forcePause", []interface{}{gid}, nil)
+}
 
-	return nil
+// ForceRemove 强制删除任务，用于卡在收尾阶段无法正常删除的任务
+func (c *Client) ForceRemove(gid string) error {
+	return c.ForceRemoveContext(context.Background(), gid)
+}
+
+// ForceRemoveContext 强制删除任务，可通过 ctx 取消
+func (c *Client) ForceRemoveContext(ctx context.Context, gid string) error {
+	return c.call(ctx, "aria2.forceRemove", []interface{}{gid}, nil)
+}
+
+// RemoveDownloadResult 从已停止列表中移除已完成、出错或已删除的任务记录
+func (c *Client) RemoveDownloadResult(gid string) error {
+	return c.RemoveDownloadResultContext(context.Background(), gid)
+}
+
+// RemoveDownloadResultContext 从已停止列表中移除任务记录，可通过 ctx 取消
+func (c *Client) RemoveDownloadResultContext(ctx context.Context, gid string) error {
+	return c.call(ctx, "aria2.removeDownloadResult", []interface{}{gid}, nil)
+}
+
+// PurgeDownloadResult 清空已停止列表中的全部任务记录
+func (c *Client) PurgeDownloadResult() error {
+	return c.PurgeDownloadResultContext(context.Background())
+}
+
+// PurgeDownloadResultContext 清空已停止列表中的全部任务记录，可通过 ctx 取消
+func (c *Client) PurgeDownloadResultContext(ctx context.Context) error {
+	return c.call(ctx, "aria2.purgeDownloadResult", nil, nil)
 }
 
 // PauseAll 暂停所有任务
 func (c *Client) PauseAll() error {
-	request := RPCRequest{
-		JSONRPC: "2.0",
-		Method:  "aria2.pauseAll",
-		Params:  []interface{}{"token:" + c.token},
-		ID:      "1",
-	}
+	return c.PauseAllContext(context.Background())
+}
 
-	response, err := c.sendRequest(request)
-	if err != nil {
-		return err
-	}
+// PauseAllContext 暂停所有任务，可通过 ctx 取消
+func (c *Client) PauseAllContext(ctx context.Context) error {
+	return c.call(ctx, "aria2.pauseAll", nil, nil)
+}
 
-	if response.Error != nil {
-		return fmt.Errorf("RPC error: %s", response.Error.Message)
-	}
+// ForcePauseAll 强制暂停所有任务
+func (c *Client) ForcePauseAll() error {
+	return c.ForcePauseAllContext(context.Background())
+}
 
-	return nil
+// ForcePauseAllContext 强制暂停所有任务，可通过 ctx 取消
+func (c *Client) ForcePauseAllContext(ctx context.Context) error {
+	return c.call(ctx, "aria2.forcePauseAll", nil, nil)
 }
 
 // UnpauseAll 恢复所有任务
 func (c *Client) UnpauseAll() error {
-	request := RPCRequest{
-		JSONRPC: "2.0",
-		Method:  "aria2.unpauseAll",
-		Params:  []interface{}{"token:" + c.token},
-		ID:      "1",
-	}
+	return c.UnpauseAllContext(context.Background())
+}
 
-	response, err := c.sendRequest(request)
-	if err != nil {
-		return err
-	}
+// UnpauseAllContext 恢复所有任务，可通过 ctx 取消
+func (c *Client) UnpauseAllContext(ctx context.Context) error {
+	return c.call(ctx, "aria2.unpauseAll", nil, nil)
+}
 
-	if response.Error != nil {
-		return fmt.Errorf("RPC error: %s", response.Error.Message)
+// PositionHow 调整队列位置时的参照方式
+type PositionHow string
+
+// changePosition 支持的参照方式
+const (
+	PosSet PositionHow = "POS_SET" // 相对队列开头
+	PosCur PositionHow = "POS_CUR" // 相对任务当前位置
+	PosEnd PositionHow = "POS_END" // 相对队列末尾
+)
+
+// ChangePosition 调整等待任务在队列中的位置，返回调整后的位置
+func (c *Client) ChangePosition(gid string, pos int, how PositionHow) (int, error) {
+	return c.ChangePositionContext(context.Background(), gid, pos, how)
+}
+
+// ChangePositionContext 调整等待任务在队列中的位置，可通过 ctx 取消
+func (c *Client) ChangePositionContext(ctx context.Context, gid string, pos int, how PositionHow) (int, error) {
+	var newPos int
+	if err := c.call(ctx, "aria2.changePosition", []interface{}{gid, pos, string(how)}, &newPos); err != nil {
+		return 0, err
 	}
+	return newPos, nil
+}
 
-	return nil
+// GlobalStat 全局统计信息
+type GlobalStat struct {
+	DownloadSpeed   Int64 `json:"downloadSpeed"`
+	UploadSpeed     Int64 `json:"uploadSpeed"`
+	NumActive       Int64 `json:"numActive"`
+	NumWaiting      Int64 `json:"numWaiting"`
+	NumStopped      Int64 `json:"numStopped"`
+	NumStoppedTotal Int64 `json:"numStoppedTotal"`
 }
 
 // GetGlobalStat 获取全局统计信息
-func (c *Client) GetGlobalStat() (map[string]string, error) {
+func (c *Client) GetGlobalStat() (*GlobalStat, error) {
+	return c.GetGlobalStatContext(context.Background())
+}
+
+// GetGlobalStatContext 获取全局统计信息，可通过 ctx 取消
+func (c *Client) GetGlobalStatContext(ctx context.Context) (*GlobalStat, error) {
+	var stats GlobalStat
+	if err := c.call(ctx, "aria2.getGlobalStat", nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// call 调用带 token 的 aria2 方法，并把结果解码到 result（为 nil 时忽略结果）
+func (c *Client) call(ctx context.Context, method string, params []interface{}, result interface{}) error {
 	request := RPCRequest{
 		JSONRPC: "2.0",
-		Method:  "aria2.getGlobalStat",
-		Params:  []interface{}{"token:" + c.token},
+		Method:  method,
+		Params:  append([]interface{}{"token:" + c.token}, params...),
 		ID:      "1",
 	}
 
-	response, err := c.sendRequest(request)
+	response, err := c.sendRequest(ctx, request)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if response.Error != nil {
-		return nil, fmt.Errorf("RPC error: %s", response.Error.Message)
+		return response.Error
 	}
 
-	var stats map[string]string
-	if err := json.Unmarshal(response.Result, &stats); err != nil {
-		return nil, err
+	if result == nil {
+		return nil
 	}
-
-	return stats, nil
+	return json.Unmarshal(response.Result, result)
 }
 
 // sendRequest 发送 RPC 请求
-func (c *Client) sendRequest(request RPCRequest) (*RPCResponse, error) {
-	data, err := json.Marshal(request)
-	if err != nil {
-		return nil, err
+// ctx 没有截止时间时使用客户端的默认超时
+func (c *Client) sendRequest(ctx context.Context, request RPCRequest) (*RPCResponse, error) {
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
 	}
 
-	resp, err := c.client.Post(c.rpcURL, "application/json", bytes.NewBuffer(data))
+	// WebSocket 连接上的响应按 ID 对应请求，因此每个请求使用唯一 ID
+	request.ID = strconv.FormatUint(atomic.AddUint64(&c.lastID, 1), 10)
+	response, err := c.transport.roundTrip(ctx, request)
 	if err != nil {
-		return nil, err
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			return nil, err
+		}
+		return nil, &TransportError{URL: c.rpcURL, Err: err}
 	}
-	defer resp.Body.Close()
 
-	var rpcResponse RPCResponse
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResponse); err != nil {
-		return nil, err
-	}
-
-	return &rpcResponse, nil
-}
\ No newline at end of file
+	return response, nil
+}
@@ -0,0 +1,120 @@
+package aria2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// wsaeConnRefused Windows 上连接被拒绝的错误码（WSAECONNREFUSED）
+const wsaeConnRefused = syscall.Errno(10061)
+
+// 可通过 errors.Is 判断的错误类别
+var (
+	// ErrUnauthorized RPC 密钥错误
+	ErrUnauthorized = errors.New("aria2: unauthorized")
+
+	// ErrNotFound RPC 请求路径不存在
+	ErrNotFound = errors.New("aria2: rpc path not found")
+
+	// ErrTaskNotFound GID 对应的任务不存在
+	ErrTaskNotFound = errors.New("aria2: task not found")
+
+	// ErrMethodNotFound aria2 不支持调用的方法
+	ErrMethodNotFound = errors.New("aria2: method not found")
+)
+
+// JSON-RPC 规范定义的错误码，aria2 自身的错误统一使用 1
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+)
+
+// Error 实现 error 接口
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("RPC error %d: %s", e.Code, e.Message)
+}
+
+// Is 将 aria2 返回的错误映射到对应的错误类别
+func (e *RPCError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.Message == "Unauthorized"
+	case ErrMethodNotFound:
+		return e.Code == CodeMethodNotFound
+	case ErrTaskNotFound:
+		return strings.HasPrefix(e.Message, "GID ") && strings.HasSuffix(e.Message, "is not found")
+	}
+	return false
+}
+
+// HTTPError RPC 接口返回了非 200 且不是 JSON-RPC 错误的 HTTP 响应
+type HTTPError struct {
+	StatusCode int
+	Status     string
+}
+
+// Error 实现 error 接口
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected HTTP status: %s", e.Status)
+}
+
+// Is 将 HTTP 状态码映射到对应的错误类别
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// TransportError 请求没有到达 aria2 或没有收到完整响应，例如连接被拒绝、超时或连接断开
+type TransportError struct {
+	URL string
+	Err error
+}
+
+// Error 实现 error 接口
+func (e *TransportError) Error() string {
+	return fmt.Sprintf("aria2 transport error (%s): %v", e.URL, e.Err)
+}
+
+// Unwrap 返回底层错误
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout 是否因超时失败
+func (e *TransportError) Timeout() bool {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(e.Err, &netErr) && netErr.Timeout()
+}
+
+// ConnectionRefused 是否因目标端口没有服务监听而被拒绝连接
+func (e *TransportError) ConnectionRefused() bool {
+	var errno syscall.Errno
+	if !errors.As(e.Err, &errno) {
+		return false
+	}
+	return errno == syscall.ECONNREFUSED || errno == wsaeConnRefused
+}
+
+// IsRetryable 错误是否属于临时性的传输错误，重试可能成功
+func IsRetryable(err error) bool {
+	var transportErr *TransportError
+	if !errors.As(err, &transportErr) {
+		return false
+	}
+	return !errors.Is(err, context.Canceled)
+}
@@ -0,0 +1,145 @@
+package aria2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestRPCErrorIs(t *testing.T) {
+	tests := []struct {
+		err  *RPCError
+		want error
+	}{
+		{&RPCError{Code: 1, Message: "Unauthorized"}, ErrUnauthorized},
+		{&RPCError{Code: CodeMethodNotFound, Message: "Method not found."}, ErrMethodNotFound},
+		{&RPCError{Code: 1, Message: "GID 2089b05ecca3d829 is not found"}, ErrTaskNotFound},
+		{&RPCError{Code: 1, Message: "Invalid GID 2089"}, nil},
+		{&RPCError{Code: 1, Message: "unauthorized"}, nil},
+		{&RPCError{Code: 1, Message: "GID 2089b05ecca3d829 cannot be paused now"}, nil},
+	}
+
+	kinds := []error{ErrUnauthorized, ErrNotFound, ErrTaskNotFound, ErrMethodNotFound}
+	for _, tt := range tests {
+		for _, kind := range kinds {
+			// 包装后的错误同样可以判断
+			wrapped := fmt.Errorf("call: %w", tt.err)
+			if got := errors.Is(wrapped, kind); got != (kind == tt.want) {
+				t.Errorf("errors.Is(%v, %v) = %v", tt.err, kind, got)
+			}
+		}
+	}
+}
+
+func TestHTTPErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   error
+	}{
+		{http.StatusUnauthorized, "Unauthorized", ErrUnauthorized},
+		{http.StatusForbidden, "Forbidden", ErrUnauthorized},
+		{http.StatusNotFound, "Not Found", ErrNotFound},
+		{http.StatusInternalServerError, "Internal Server Error", nil},
+		// 非 200 状态码但响应体为 JSON，且没有 JSON-RPC 错误
+		{http.StatusBadGateway, `{"jsonrpc":"2.0","id":"1","result":"OK"}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			client := newTestClient(t, srv, "http")
+			_, err := client.GetVersion()
+
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.status {
+				t.Fatalf("got %v, want HTTPError %d", err, tt.status)
+			}
+			for _, kind := range []error{ErrUnauthorized, ErrNotFound, ErrTaskNotFound} {
+				if got := errors.Is(err, kind); got != (kind == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", err, kind, got)
+				}
+			}
+			if IsRetryable(err) {
+				t.Errorf("%v is retryable", err)
+			}
+		})
+	}
+}
+
+func TestRPCErrorWithHTTPStatus(t *testing.T) {
+	// aria2 对 RPC 错误返回 400，响应体中的 JSON-RPC 错误优先
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"jsonrpc":"2.0","id":"1","error":{"code":1,"message":"Unauthorized"}}`)
+	}))
+	defer srv.Close()
+
+	_, err := newTestClient(t, srv, "http").GetVersion()
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("got %v, want RPCError Unauthorized", err)
+	}
+}
+
+func TestTransportErrors(t *testing.T) {
+	// 取得一个空闲端口后立即关闭，连接会被拒绝
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	for _, protocol := range []string{"http", "ws"} {
+		t.Run(protocol, func(t *testing.T) {
+			client := NewClient("127.0.0.1", port, "", protocol, "/jsonrpc")
+			defer client.Close()
+
+			_, err := client.GetVersion()
+			var transportErr *TransportError
+			if !errors.As(err, &transportErr) {
+				t.Fatalf("got %v, want TransportError", err)
+			}
+			if !transportErr.ConnectionRefused() || transportErr.Timeout() {
+				t.Errorf("%v: refused %v, timeout %v", err, transportErr.ConnectionRefused(), transportErr.Timeout())
+			}
+			if !IsRetryable(err) {
+				t.Errorf("%v is not retryable", err)
+			}
+
+			// 调用方取消的请求不应重试
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if _, err := client.GetVersionContext(ctx); !errors.Is(err, context.Canceled) || IsRetryable(err) {
+				t.Errorf("canceled call: %v, retryable %v", err, IsRetryable(err))
+			}
+		})
+	}
+}
+
+// newTestClient 创建连接 httptest 服务的客户端
+func newTestClient(t *testing.T, srv *httptest.Server, protocol string) *Client {
+	t.Helper()
+
+	host, portText, err := net.SplitHostPort(srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(portText)
+	client := NewClient(host, port, "", protocol, "/jsonrpc")
+	t.Cleanup(func() {
+		client.Close()
+	})
+	return client
+}
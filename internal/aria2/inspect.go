@@ -0,0 +1,87 @@
+package aria2
+
+import (
+	"context"
+)
+
+// Peer BitTorrent 任务连接的节点
+type Peer struct {
+	PeerID        string `json:"peerId"`
+	IP            string `json:"ip"`
+	Port          string `json:"port"`
+	Bitfield      string `json:"bitfield"`
+	AmChoking     string `json:"amChoking"`   // 本端是否阻塞对方
+	PeerChoking   string `json:"peerChoking"` // 对方是否阻塞本端
+	DownloadSpeed Int64  `json:"downloadSpeed"`
+	UploadSpeed   Int64  `json:"uploadSpeed"`
+	Seeder        string `json:"seeder"`
+}
+
+// FileServers 单个文件正在使用的服务器
+type FileServers struct {
+	Index   Int64    `json:"index"`
+	Servers []Server `json:"servers"`
+}
+
+// Server HTTP/FTP 任务连接的服务器
+type Server struct {
+	URI           string `json:"uri"`
+	CurrentURI    string `json:"currentUri"` // 发生重定向时与 URI 不同
+	DownloadSpeed Int64  `json:"downloadSpeed"`
+}
+
+// GetPeers 获取 BitTorrent 任务的节点列表
+func (c *Client) GetPeers(gid string) ([]Peer, error) {
+	return c.GetPeersContext(context.Background(), gid)
+}
+
+// GetPeersContext 获取 BitTorrent 任务的节点列表，可通过 ctx 取消
+func (c *Client) GetPeersContext(ctx context.Context, gid string) ([]Peer, error) {
+	var peers []Peer
+	if err := c.call(ctx, "aria2.getPeers", []interface{}{gid}, &peers); err != nil {
+		return nil, err
+	}
+	return peers, nil
+}
+
+// GetServers 获取 HTTP/FTP 任务当前连接的服务器
+func (c *Client) GetServers(gid string) ([]FileServers, error) {
+	return c.GetServersContext(context.Background(), gid)
+}
+
+// GetServersContext 获取 HTTP/FTP 任务当前连接的服务器，可通过 ctx 取消
+func (c *Client) GetServersContext(ctx context.Context, gid string) ([]FileServers, error) {
+	var servers []FileServers
+	if err := c.call(ctx, "aria2.getServers", []interface{}{gid}, &servers); err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
+
+// GetFiles 获取任务的文件列表
+func (c *Client) GetFiles(gid string) ([]FileInfo, error) {
+	return c.GetFilesContext(context.Background(), gid)
+}
+
+// GetFilesContext 获取任务的文件列表，可通过 ctx 取消
+func (c *Client) GetFilesContext(ctx context.Context, gid string) ([]FileInfo, error) {
+	var files []FileInfo
+	if err := c.call(ctx, "aria2.getFiles", []interface{}{gid}, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// GetURIs 获取任务使用的 URI 列表
+func (c *Client) GetURIs(gid string) ([]URI, error) {
+	return c.GetURIsContext(context.Background(), gid)
+}
+
+// GetURIsContext 获取任务使用的 URI 列表，可通过 ctx 取消
+func (c *Client) GetURIsContext(ctx context.Context, gid string) ([]URI, error) {
+	var uris []URI
+	if err := c.call(ctx, "aria2.getUris", []interface{}{gid}, &uris); err != nil {
+		return nil, err
+	}
+	return uris, nil
+}
@@ -0,0 +1,109 @@
+package aria2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Call 批量请求中的单个调用，Params 不需要包含 token
+type Call struct {
+	Method string
+	Params []interface{}
+}
+
+// CallResult 批量请求中单个调用的结果
+type CallResult struct {
+	Result json.RawMessage
+	Err    error
+}
+
+// Decode 将调用结果解码到 v，调用失败时返回调用的错误
+func (r CallResult) Decode(v interface{}) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	return json.Unmarshal(r.Result, v)
+}
+
+// multicallEntry system.multicall 参数中的单个方法
+type multicallEntry struct {
+	MethodName string        `json:"methodName"`
+	Params     []interface{} `json:"params"`
+}
+
+// Multicall 通过 system.multicall 在一次请求中执行多个调用
+// 返回的结果与 calls 一一对应；单个调用失败只体现在对应结果的 Err 中
+func (c *Client) Multicall(calls []Call) ([]CallResult, error) {
+	return c.MulticallContext(context.Background(), calls)
+}
+
+// MulticallContext 通过 system.multicall 执行多个调用，可通过 ctx 取消
+func (c *Client) MulticallContext(ctx context.Context, calls []Call) ([]CallResult, error) {
+	if len(calls) == 0 {
+		return nil, nil
+	}
+
+	entries := make([]multicallEntry, len(calls))
+	for i, call := range calls {
+		params := call.Params
+		// aria2.* 方法需要在参数最前面带上 token，system.* 方法不需要
+		if strings.HasPrefix(call.Method, "aria2.") {
+			params = append([]interface{}{"token:" + c.token}, call.Params...)
+		}
+		if params == nil {
+			params = []interface{}{}
+		}
+		entries[i] = multicallEntry{MethodName: call.Method, Params: params}
+	}
+
+	request := RPCRequest{
+		JSONRPC: "2.0",
+		Method:  "system.multicall",
+		Params:  []interface{}{entries},
+		ID:      "1",
+	}
+
+	response, err := c.sendRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.Error != nil {
+		return nil, response.Error
+	}
+
+	var raw []json.RawMessage
+	if err := json.Unmarshal(response.Result, &raw); err != nil {
+		return nil, err
+	}
+
+	if len(raw) != len(calls) {
+		return nil, fmt.Errorf("system.multicall returned %d results for %d calls", len(raw), len(calls))
+	}
+
+	// 成功的调用结果被包装在单元素数组中，失败的调用返回错误对象
+	results := make([]CallResult, len(raw))
+	for i, item := range raw {
+		if bytes.HasPrefix(bytes.TrimSpace(item), []byte("[")) {
+			var wrapped []json.RawMessage
+			if err := json.Unmarshal(item, &wrapped); err != nil {
+				results[i].Err = err
+			} else if len(wrapped) > 0 {
+				results[i].Result = wrapped[0]
+			}
+			continue
+		}
+
+		fault := &RPCError{}
+		if err := json.Unmarshal(item, fault); err != nil {
+			results[i].Err = err
+		} else {
+			results[i].Err = fault
+		}
+	}
+
+	return results, nil
+}
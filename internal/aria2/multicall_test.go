@@ -0,0 +1,137 @@
+package aria2_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+	"github.com/chenyb888/aria2GoUI/internal/aria2/aria2test"
+)
+
+func TestMulticallEntryFaults(t *testing.T) {
+	srv := aria2test.NewServer("secret")
+	defer srv.Close()
+
+	for _, protocol := range []string{"http", "ws"} {
+		t.Run(protocol, func(t *testing.T) {
+			client := aria2.NewClient(srv.Host, srv.Port, "secret", protocol, aria2test.Path)
+			defer client.Close()
+
+			gid := srv.AddTask("http://example.com/file.iso")
+			srv.FailMethod("aria2.getOption", 1, "Injected failure")
+			defer srv.ClearFailures()
+			srv.ResetCalls()
+
+			results, err := client.Multicall([]aria2.Call{
+				{Method: "aria2.tellStatus", Params: []interface{}{"ffffffffffffffff"}},
+				// system.* 方法不带 token，aria2.* 方法由客户端加上 token
+				{Method: "system.listMethods"},
+				{Method: "aria2.getOption", Params: []interface{}{gid}},
+				{Method: "aria2.tellStatus", Params: []interface{}{gid, []string{"gid", "totalLength"}}},
+				{Method: "aria2.noSuchMethod"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 5 {
+				t.Fatalf("got %d results, want 5", len(results))
+			}
+
+			// 结果与调用一一对应，失败的调用不影响后面的调用
+			if !errors.Is(results[0].Err, aria2.ErrTaskNotFound) {
+				t.Errorf("results[0] = %v, want ErrTaskNotFound", results[0].Err)
+			}
+			var rpcErr *aria2.RPCError
+			if !errors.As(results[2].Err, &rpcErr) || rpcErr.Message != "Injected failure" {
+				t.Errorf("results[2] = %v, want the injected failure", results[2].Err)
+			}
+			var status aria2.TellStatus
+			if err := results[3].Decode(&status); err != nil {
+				t.Fatalf("results[3]: %v", err)
+			}
+			if status.GID != gid || status.TotalLength <= 0 {
+				t.Errorf("results[3] = %+v", status)
+			}
+			if !errors.Is(results[4].Err, aria2.ErrMethodNotFound) {
+				t.Errorf("results[4] = %v, want ErrMethodNotFound", results[4].Err)
+			}
+			if err := results[4].Decode(&status); err != results[4].Err {
+				t.Errorf("Decode of a failed call = %v, want %v", err, results[4].Err)
+			}
+
+			want := []string{"aria2.tellStatus", "system.listMethods", "aria2.getOption", "aria2.tellStatus", "aria2.noSuchMethod"}
+			if got := srv.Calls(); !reflect.DeepEqual(got, want) {
+				t.Errorf("calls = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMulticallWrongToken(t *testing.T) {
+	srv := aria2test.NewServer("secret")
+	defer srv.Close()
+	client := aria2.NewClient(srv.Host, srv.Port, "wrong", "http", aria2test.Path)
+	defer client.Close()
+
+	// 密钥错误只体现在 aria2.* 调用的结果中
+	results, err := client.Multicall([]aria2.Call{
+		{Method: "aria2.getVersion"},
+		{Method: "system.listMethods"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(results[0].Err, aria2.ErrUnauthorized) {
+		t.Errorf("results[0] = %v, want ErrUnauthorized", results[0].Err)
+	}
+	if results[1].Err != nil {
+		t.Errorf("results[1] = %v", results[1].Err)
+	}
+}
+
+func TestMulticallEmpty(t *testing.T) {
+	srv := aria2test.NewServer("")
+	defer srv.Close()
+	client := srv.Client()
+	defer client.Close()
+
+	results, err := client.Multicall(nil)
+	if results != nil || err != nil {
+		t.Errorf("got %v, %v, want no results", results, err)
+	}
+	if calls := srv.Calls(); len(calls) != 0 {
+		t.Errorf("sent %v for no calls", calls)
+	}
+}
+
+func TestMulticallResultCount(t *testing.T) {
+	tests := map[string]string{
+		"too few":     `{"jsonrpc":"2.0","id":%q,"result":[["OK"]]}`,
+		"whole fails": `{"jsonrpc":"2.0","id":%q,"error":{"code":1,"message":"Unauthorized"}}`,
+	}
+
+	for name, body := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, body, "1")
+			}))
+			defer srv.Close()
+
+			host, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+			portNum, _ := strconv.Atoi(port)
+			client := aria2.NewClient(host, portNum, "", "http", "/jsonrpc")
+			defer client.Close()
+
+			results, err := client.Multicall([]aria2.Call{{Method: "aria2.pause"}, {Method: "aria2.pause"}})
+			if err == nil {
+				t.Errorf("got %v, want an error", results)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package aria2
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// EventType aria2 推送通知的类型，取值为通知的方法名
+type EventType string
+
+// aria2 支持的推送通知
+const (
+	EventDownloadStart      EventType = "aria2.onDownloadStart"
+	EventDownloadPause      EventType = "aria2.onDownloadPause"
+	EventDownloadStop       EventType = "aria2.onDownloadStop"
+	EventDownloadComplete   EventType = "aria2.onDownloadComplete"
+	EventDownloadError      EventType = "aria2.onDownloadError"
+	EventBtDownloadComplete EventType = "aria2.onBtDownloadComplete"
+)
+
+// Event aria2 推送的任务事件
+type Event struct {
+	Type EventType
+	GID  string
+}
+
+// Subscription 事件订阅，调用 Unsubscribe 取消
+type Subscription struct {
+	client *Client
+	id     uint64
+}
+
+// subscriber 已注册的事件处理函数
+type subscriber struct {
+	handler func(Event)
+	types   map[EventType]bool // 为空表示接收全部类型
+}
+
+// eventHub 管理订阅者并分发事件
+type eventHub struct {
+	mu          sync.Mutex
+	lastID      uint64
+	subscribers map[uint64]*subscriber
+}
+
+// SupportsNotifications 当前连接是否能收到推送通知
+// aria2 只在 WebSocket 连接上推送通知，HTTP 连接只能轮询
+func (c *Client) SupportsNotifications() bool {
+	_, ok := c.transport.(*wsTransport)
+	return ok
+}
+
+// Subscribe 注册事件处理函数，types 为空时接收全部类型
+// handler 在读取连接的协程中同步调用，不应长时间阻塞
+func (c *Client) Subscribe(handler func(Event), types ...EventType) *Subscription {
+	sub := &subscriber{handler: handler}
+	if len(types) > 0 {
+		sub.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	c.events.mu.Lock()
+	if c.events.subscribers == nil {
+		c.events.subscribers = make(map[uint64]*subscriber)
+	}
+	c.events.lastID++
+	id := c.events.lastID
+	c.events.subscribers[id] = sub
+
+	// 有订阅者时保持 WebSocket 连接，以便及时收到通知
+	// 持有 events.mu 调用，避免与 Unsubscribe 交错后停止监听
+	if ws, ok := c.transport.(*wsTransport); ok {
+		ws.listen(c.dispatchEvent)
+	}
+	c.events.mu.Unlock()
+
+	return &Subscription{client: c, id: id}
+}
+
+// SubscribeChan 将事件发送到通道，types 为空时接收全部类型
+// 通道已满时丢弃事件，避免阻塞连接
+func (c *Client) SubscribeChan(ch chan<- Event, types ...EventType) *Subscription {
+	return c.Subscribe(func(event Event) {
+		select {
+		case ch <- event:
+		default:
+		}
+	}, types...)
+}
+
+// Unsubscribe 取消订阅，可重复调用
+// 最后一个订阅者取消后不再在后台保持 WebSocket 连接
+func (s *Subscription) Unsubscribe() {
+	if s == nil || s.client == nil {
+		return
+	}
+
+	hub := &s.client.events
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+
+	if _, ok := hub.subscribers[s.id]; !ok {
+		return
+	}
+	delete(hub.subscribers, s.id)
+	if ws, ok := s.client.transport.(*wsTransport); ok && len(hub.subscribers) == 0 {
+		ws.unlisten()
+	}
+}
+
+// dispatchEvent 解析通知消息并分发给订阅者
+func (c *Client) dispatchEvent(method string, params json.RawMessage) {
+	var args []struct {
+		GID string `json:"gid"`
+	}
+	if err := json.Unmarshal(params, &args); err != nil {
+		return
+	}
+
+	c.events.mu.Lock()
+	handlers := make([]*subscriber, 0, len(c.events.subscribers))
+	for _, sub := range c.events.subscribers {
+		handlers = append(handlers, sub)
+	}
+	c.events.mu.Unlock()
+
+	for _, arg := range args {
+		event := Event{Type: EventType(method), GID: arg.GID}
+		for _, sub := range handlers {
+			if sub.types == nil || sub.types[event.Type] {
+				sub.handler(event)
+			}
+		}
+	}
+}
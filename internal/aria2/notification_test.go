@@ -0,0 +1,82 @@
+package aria2
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDispatchEvent(t *testing.T) {
+	client := NewClient("127.0.0.1", 6800, "", "ws", "/jsonrpc")
+	defer client.Close()
+
+	var all, completions []Event
+	allSub := client.Subscribe(func(event Event) {
+		all = append(all, event)
+	})
+	client.Subscribe(func(event Event) {
+		completions = append(completions, event)
+	}, EventDownloadComplete, EventBtDownloadComplete)
+
+	// 一条通知可以包含多个任务
+	client.dispatchEvent(string(EventDownloadStart), json.RawMessage(`[{"gid":"a"},{"gid":"b"}]`))
+	client.dispatchEvent(string(EventBtDownloadComplete), json.RawMessage(`[{"gid":"c"}]`))
+	// 无法解析的参数被忽略
+	client.dispatchEvent(string(EventDownloadError), json.RawMessage(`{"gid":"d"}`))
+
+	wantAll := []Event{
+		{Type: EventDownloadStart, GID: "a"},
+		{Type: EventDownloadStart, GID: "b"},
+		{Type: EventBtDownloadComplete, GID: "c"},
+	}
+	if !reflect.DeepEqual(all, wantAll) {
+		t.Errorf("all events = %+v, want %+v", all, wantAll)
+	}
+	wantCompletions := []Event{{Type: EventBtDownloadComplete, GID: "c"}}
+	if !reflect.DeepEqual(completions, wantCompletions) {
+		t.Errorf("completion events = %+v, want %+v", completions, wantCompletions)
+	}
+
+	// 取消后不再收到事件，重复取消没有影响
+	allSub.Unsubscribe()
+	allSub.Unsubscribe()
+	client.dispatchEvent(string(EventDownloadComplete), json.RawMessage(`[{"gid":"e"}]`))
+	if len(all) != len(wantAll) {
+		t.Errorf("unsubscribed handler got %+v", all[len(wantAll):])
+	}
+	if len(completions) != 2 || completions[1] != (Event{Type: EventDownloadComplete, GID: "e"}) {
+		t.Errorf("completion events = %+v", completions)
+	}
+}
+
+func TestSubscribeChanDropsWhenFull(t *testing.T) {
+	client := NewClient("127.0.0.1", 6800, "", "ws", "/jsonrpc")
+	defer client.Close()
+
+	events := make(chan Event, 1)
+	client.SubscribeChan(events, EventDownloadStop)
+
+	// 通道已满时丢弃事件，不阻塞读取连接的协程
+	client.dispatchEvent(string(EventDownloadStop), json.RawMessage(`[{"gid":"a"},{"gid":"b"}]`))
+	client.dispatchEvent(string(EventDownloadStart), json.RawMessage(`[{"gid":"c"}]`))
+
+	if event := <-events; event != (Event{Type: EventDownloadStop, GID: "a"}) {
+		t.Errorf("got %+v", event)
+	}
+	select {
+	case event := <-events:
+		t.Errorf("got unexpected %+v", event)
+	default:
+	}
+}
+
+func TestSupportsNotifications(t *testing.T) {
+	tests := map[string]bool{"ws": true, "wss": true, "http": false, "https": false}
+	for protocol, want := range tests {
+		client := NewClient("127.0.0.1", 6800, "", protocol, "/jsonrpc")
+		if got := client.SupportsNotifications(); got != want {
+			t.Errorf("%s: got %v, want %v", protocol, got, want)
+		}
+		client.Close()
+	}
+}
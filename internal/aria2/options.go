@@ -0,0 +1,97 @@
+package aria2
+
+import (
+	"context"
+)
+
+// Options aria2 选项，键为选项名（如 max-download-limit），值统一为字符串
+type Options map[string]string
+
+// GetOption 获取任务的选项
+func (c *Client) GetOption(gid string) (Options, error) {
+	return c.GetOptionContext(context.Background(), gid)
+}
+
+// GetOptionContext 获取任务的选项，可通过 ctx 取消
+func (c *Client) GetOptionContext(ctx context.Context, gid string) (Options, error) {
+	var options Options
+	if err := c.call(ctx, "aria2.getOption", []interface{}{gid}, &options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// ChangeOption 修改任务的选项
+// 活动中的任务只能修改部分选项，其余选项 aria2 会返回错误
+func (c *Client) ChangeOption(gid string, options Options) error {
+	return c.ChangeOptionContext(context.Background(), gid, options)
+}
+
+// ChangeOptionContext 修改任务的选项，可通过 ctx 取消
+func (c *Client) ChangeOptionContext(ctx context.Context, gid string, options Options) error {
+	return c.call(ctx, "aria2.changeOption", []interface{}{gid, options}, nil)
+}
+
+// GetGlobalOption 获取全局选项
+func (c *Client) GetGlobalOption() (Options, error) {
+	return c.GetGlobalOptionContext(context.Background())
+}
+
+// GetGlobalOptionContext 获取全局选项，可通过 ctx 取消
+func (c *Client) GetGlobalOptionContext(ctx context.Context) (Options, error) {
+	var options Options
+	if err := c.call(ctx, "aria2.getGlobalOption", nil, &options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// ChangeGlobalOption 修改全局选项，无需重启 aria2 即可生效
+func (c *Client) ChangeGlobalOption(options Options) error {
+	return c.ChangeGlobalOptionContext(context.Background(), options)
+}
+
+// ChangeGlobalOptionContext 修改全局选项，可通过 ctx 取消
+func (c *Client) ChangeGlobalOptionContext(ctx context.Context, options Options) error {
+	return c.call(ctx, "aria2.changeGlobalOption", []interface{}{options}, nil)
+}
+
+// startupOptions aria2 只在启动时读取的常用选项，changeGlobalOption 会忽略对它们的修改
+var startupOptions = map[string]bool{
+	"listen-port":             true,
+	"dht-listen-port":         true,
+	"enable-dht":              true,
+	"enable-dht6":             true,
+	"dht-file-path":           true,
+	"dht-file-path6":          true,
+	"dht-entry-point":         true,
+	"dht-entry-point6":        true,
+	"disk-cache":              true,
+	"enable-rpc":              true,
+	"rpc-listen-all":          true,
+	"rpc-listen-port":         true,
+	"rpc-secret":              true,
+	"rpc-secure":              true,
+	"rpc-certificate":         true,
+	"rpc-private-key":         true,
+	"rpc-allow-origin-all":    true,
+	"rpc-max-request-size":    true,
+	"input-file":              true,
+	"conf-path":               true,
+	"daemon":                  true,
+	"save-session-interval":   true,
+	"auto-save-interval":      true,
+	"event-poll":              true,
+	"stop-with-process":       true,
+	"on-download-start":       true,
+	"on-download-pause":       true,
+	"on-download-stop":        true,
+	"on-download-complete":    true,
+	"on-download-error":       true,
+	"on-bt-download-complete": true,
+}
+
+// RestartRequired 判断修改该全局选项后是否需要重启 aria2 才能生效
+func RestartRequired(key string) bool {
+	return startupOptions[key]
+}
@@ -0,0 +1,55 @@
+package aria2
+
+import (
+	"context"
+)
+
+// SessionInfo aria2 会话信息
+type SessionInfo struct {
+	SessionID string `json:"sessionId"`
+}
+
+// SaveSession 将当前会话保存到 aria2 的 --save-session 文件
+// aria2 没有配置 --save-session 时返回错误
+func (c *Client) SaveSession() error {
+	return c.SaveSessionContext(context.Background())
+}
+
+// SaveSessionContext 保存会话，可通过 ctx 取消
+func (c *Client) SaveSessionContext(ctx context.Context) error {
+	return c.call(ctx, "aria2.saveSession", nil, nil)
+}
+
+// GetSessionInfo 获取会话信息，aria2 每次启动都会生成新的会话 ID
+func (c *Client) GetSessionInfo() (*SessionInfo, error) {
+	return c.GetSessionInfoContext(context.Background())
+}
+
+// GetSessionInfoContext 获取会话信息，可通过 ctx 取消
+func (c *Client) GetSessionInfoContext(ctx context.Context) (*SessionInfo, error) {
+	var info SessionInfo
+	if err := c.call(ctx, "aria2.getSessionInfo", nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Shutdown 关闭 aria2，活动任务会先正常停止
+func (c *Client) Shutdown() error {
+	return c.ShutdownContext(context.Background())
+}
+
+// ShutdownContext 关闭 aria2，可通过 ctx 取消
+func (c *Client) ShutdownContext(ctx context.Context) error {
+	return c.call(ctx, "aria2.shutdown", nil, nil)
+}
+
+// ForceShutdown 立即关闭 aria2，不等待 BitTorrent tracker 注销等耗时操作
+func (c *Client) ForceShutdown() error {
+	return c.ForceShutdownContext(context.Background())
+}
+
+// ForceShutdownContext 立即关闭 aria2，可通过 ctx 取消
+func (c *Client) ForceShutdownContext(ctx context.Context) error {
+	return c.call(ctx, "aria2.forceShutdown", nil, nil)
+}
@@ -0,0 +1,257 @@
+package aria2
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ConnState 与 aria2 的连接状态
+type ConnState int
+
+const (
+	// StateConnecting 正在建立连接，尚未成功过
+	StateConnecting ConnState = iota
+	// StateConnected 连接正常
+	StateConnected
+	// StateDegraded 已连接过但最近的请求失败，正在重试
+	StateDegraded
+	// StateOffline 连续多次失败，认为 aria2 不可用
+	StateOffline
+)
+
+// String 返回状态名称
+func (s ConnState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDegraded:
+		return "degraded"
+	case StateOffline:
+		return "offline"
+	}
+	return "unknown"
+}
+
+const (
+	// offlineThreshold 连续失败多少次后视为离线
+	offlineThreshold = 3
+
+	defaultMinBackoff    = time.Second
+	defaultMaxBackoff    = 30 * time.Second
+	defaultCheckInterval = 10 * time.Second
+)
+
+// Supervisor 监视客户端与 aria2 的连接
+// 连接正常时定期检查，失败后按指数退避加随机抖动重试，状态变化时通知调用方
+type Supervisor struct {
+	client  API
+	onState func(state ConnState, err error)
+
+	minBackoff    time.Duration
+	maxBackoff    time.Duration
+	checkInterval time.Duration
+
+	mu            sync.Mutex
+	state         ConnState
+	lastErr       error
+	autoReconnect bool
+
+	kick chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewSupervisor 创建连接监视器，onState 在状态变化时从监视协程中调用
+func NewSupervisor(client API, onState func(state ConnState, err error)) *Supervisor {
+	return &Supervisor{
+		client:        client,
+		onState:       onState,
+		minBackoff:    defaultMinBackoff,
+		maxBackoff:    defaultMaxBackoff,
+		checkInterval: defaultCheckInterval,
+		state:         StateConnecting,
+		autoReconnect: true,
+		kick:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// SetAutoReconnect 设置失败后是否自动重试
+// 关闭后离线状态会一直保持，直到调用 Check
+func (s *Supervisor) SetAutoReconnect(enabled bool) {
+	s.mu.Lock()
+	s.autoReconnect = enabled
+	s.mu.Unlock()
+}
+
+// SetCheckInterval 设置连接正常时的检查间隔
+func (s *Supervisor) SetCheckInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.checkInterval = interval
+	s.mu.Unlock()
+}
+
+// State 返回当前状态和最近一次失败的错误
+func (s *Supervisor) State() (ConnState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state, s.lastErr
+}
+
+// Start 启动监视协程
+func (s *Supervisor) Start() {
+	go s.run()
+}
+
+// Stop 停止监视并等待监视协程退出，之后不会再调用 onState
+func (s *Supervisor) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+// Check 立即检查一次连接，不等待下一次定时检查或退避结束
+func (s *Supervisor) Check() {
+	select {
+	case s.kick <- struct{}{}:
+	default:
+	}
+}
+
+// ReportError 报告其他调用遇到的错误
+// 传输错误说明连接可能已经断开，会立即触发一次检查
+func (s *Supervisor) ReportError(err error) {
+	if IsRetryable(err) {
+		s.Check()
+	}
+}
+
+// run 监视循环
+func (s *Supervisor) run() {
+	defer close(s.done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-s.done:
+		}
+	}()
+
+	failures := 0
+	for {
+		_, err := s.client.GetVersionContext(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		var wait time.Duration
+		if err == nil {
+			failures = 0
+			s.setState(StateConnected, nil)
+			wait = s.interval()
+		} else {
+			failures++
+			if s.retrying() {
+				s.setState(s.failedState(failures), err)
+				wait = s.backoff(failures)
+			} else {
+				// 不自动重连时直接离线，等待手动检查
+				s.setState(StateOffline, err)
+				wait = -1
+			}
+		}
+
+		if !s.wait(wait) {
+			return
+		}
+	}
+}
+
+// failedState 根据连续失败次数和之前的状态计算新状态
+func (s *Supervisor) failedState(failures int) ConnState {
+	s.mu.Lock()
+	previous := s.state
+	s.mu.Unlock()
+
+	switch {
+	case failures >= offlineThreshold:
+		return StateOffline
+	case previous == StateConnected || previous == StateDegraded:
+		return StateDegraded
+	default:
+		return StateConnecting
+	}
+}
+
+// setState 更新状态，状态改变时通知调用方
+func (s *Supervisor) setState(state ConnState, err error) {
+	s.mu.Lock()
+	changed := s.state != state
+	s.state = state
+	s.lastErr = err
+	s.mu.Unlock()
+
+	if changed && s.onState != nil {
+		s.onState(state, err)
+	}
+}
+
+// wait 等待下一次检查；d 小于 0 时只等待 Check。返回 false 表示已停止
+func (s *Supervisor) wait(d time.Duration) bool {
+	var timeout <-chan time.Time
+	if d >= 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-timeout:
+	case <-s.kick:
+	case <-s.stop:
+		return false
+	}
+	return true
+}
+
+// backoff 计算第 failures 次失败后的等待时间
+// 按指数增长到 maxBackoff，并在后一半区间内随机抖动，避免多个客户端同时重连
+func (s *Supervisor) backoff(failures int) time.Duration {
+	d := s.minBackoff
+	for i := 1; i < failures && d < s.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > s.maxBackoff {
+		d = s.maxBackoff
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// interval 返回连接正常时的检查间隔
+func (s *Supervisor) interval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkInterval
+}
+
+// retrying 失败后是否自动重试
+func (s *Supervisor) retrying() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.autoReconnect
+}
@@ -0,0 +1,144 @@
+package aria2
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSupervisorBackoff(t *testing.T) {
+	s := NewSupervisor(nil, nil)
+	s.minBackoff = time.Second
+	s.maxBackoff = 30 * time.Second
+
+	// 第 n 次失败后的上限按指数增长到 maxBackoff，实际等待在上限的后一半区间内
+	limits := []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second,
+		30 * time.Second, 30 * time.Second, 30 * time.Second,
+	}
+	for i, limit := range limits {
+		failures := i + 1
+		for j := 0; j < 100; j++ {
+			if d := s.backoff(failures); d < limit/2 || d > limit {
+				t.Fatalf("backoff(%d) = %v, want between %v and %v", failures, d, limit/2, limit)
+			}
+		}
+	}
+
+	// 失败次数很大时不会溢出
+	if d := s.backoff(1000); d < 15*time.Second || d > 30*time.Second {
+		t.Errorf("backoff(1000) = %v", d)
+	}
+}
+
+// versionAPI 只实现 GetVersionContext 的 API，依次返回 results 中的错误，之后一直成功
+type versionAPI struct {
+	API
+
+	mu      sync.Mutex
+	results []error
+}
+
+func (v *versionAPI) GetVersionContext(ctx context.Context) (*Version, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if len(v.results) == 0 {
+		return &Version{Version: "1.37.0"}, nil
+	}
+	err := v.results[0]
+	v.results = v.results[1:]
+	return nil, err
+}
+
+// stateRecorder 记录 Supervisor 报告的状态
+type stateRecorder struct {
+	states chan ConnState
+}
+
+func newStateRecorder() *stateRecorder {
+	return &stateRecorder{states: make(chan ConnState, 16)}
+}
+
+func (r *stateRecorder) onState(state ConnState, err error) {
+	r.states <- state
+}
+
+// expect 等待接下来的状态依次为 want
+func (r *stateRecorder) expect(t *testing.T, want ...ConnState) {
+	t.Helper()
+
+	for _, w := range want {
+		select {
+		case got := <-r.states:
+			if got != w {
+				t.Fatalf("got state %v, want %v", got, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for state %v", w)
+		}
+	}
+}
+
+// newTestSupervisor 创建退避间隔很短的 Supervisor
+func newTestSupervisor(client API, recorder *stateRecorder) *Supervisor {
+	s := NewSupervisor(client, recorder.onState)
+	s.minBackoff = time.Millisecond
+	s.maxBackoff = 4 * time.Millisecond
+	s.SetCheckInterval(time.Hour)
+	return s
+}
+
+func TestSupervisorStates(t *testing.T) {
+	down := &TransportError{URL: "http://127.0.0.1:6800/jsonrpc", Err: errors.New("connection refused")}
+	client := &versionAPI{results: []error{down, down, down, down, nil, down, down}}
+	recorder := newStateRecorder()
+	s := newTestSupervisor(client, recorder)
+	s.Start()
+	defer s.Stop()
+
+	// 从未连接成功时保持 Connecting，连续失败 3 次后离线，恢复后重新连接
+	recorder.expect(t, StateOffline, StateConnected)
+
+	// 连接正常时要等检查间隔，Check 立即检查；已连接过的失败进入 Degraded，未到阈值就恢复
+	s.Check()
+	recorder.expect(t, StateDegraded, StateConnected)
+
+	if state, err := s.State(); state != StateConnected || err != nil {
+		t.Errorf("State() = %v, %v", state, err)
+	}
+}
+
+func TestSupervisorNoAutoReconnect(t *testing.T) {
+	down := &TransportError{URL: "http://127.0.0.1:6800/jsonrpc", Err: errors.New("connection refused")}
+	client := &versionAPI{results: []error{down}}
+	recorder := newStateRecorder()
+	s := newTestSupervisor(client, recorder)
+	s.SetAutoReconnect(false)
+	s.Start()
+	defer s.Stop()
+
+	// 不自动重连时第一次失败就离线，之后只在 Check 时重试
+	recorder.expect(t, StateOffline)
+	if state, err := s.State(); state != StateOffline || !errors.Is(err, down) {
+		t.Errorf("State() = %v, %v", state, err)
+	}
+	select {
+	case state := <-recorder.states:
+		t.Fatalf("got %v without Check", state)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// 非传输错误不会触发检查
+	s.ReportError(&RPCError{Code: 1, Message: "Unauthorized"})
+	select {
+	case state := <-recorder.states:
+		t.Fatalf("got %v after an RPC error", state)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.ReportError(down)
+	recorder.expect(t, StateConnected)
+}
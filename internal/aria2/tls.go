@@ -0,0 +1,79 @@
+package aria2
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/chenyb888/aria2GoUI/internal/config"
+)
+
+// TLSOptions https/wss 连接的 TLS 设置
+//
+// 使用自签名证书时，可把服务器证书本身作为 CAFile，相当于只信任这一张证书。
+type TLSOptions struct {
+	// CAFile PEM 格式的 CA 证书，为空时使用系统证书
+	CAFile string
+	// CertFile 和 KeyFile 为 PEM 格式的客户端证书和私钥，aria2 前面的代理要求客户端证书时使用
+	CertFile string
+	KeyFile  string
+	// ServerName 校验证书时使用的主机名，为空时使用连接地址中的主机名
+	ServerName string
+	// InsecureSkipVerify 不校验服务器证书，仅用于调试
+	InsecureSkipVerify bool
+}
+
+// TLSOptionsFrom 将配置文件中的 TLS 设置转换为 TLSOptions，创建客户端时统一经由此处转换
+func TLSOptionsFrom(settings config.TLSConfig) TLSOptions {
+	return TLSOptions{
+		CAFile:             settings.CAFile,
+		CertFile:           settings.CertFile,
+		KeyFile:            settings.KeyFile,
+		ServerName:         settings.ServerName,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+}
+
+// IsZero 是否没有任何设置
+func (o TLSOptions) IsZero() bool {
+	return o == TLSOptions{}
+}
+
+// Config 根据设置构建 tls.Config，没有任何设置时返回 nil，使用默认配置
+func (o TLSOptions) Config() (*tls.Config, error) {
+	if o.IsZero() {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	if o.CAFile != "" {
+		data, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("aria2: read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("aria2: no PEM certificates found in %s", o.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, errors.New("aria2: client certificate and key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("aria2: load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
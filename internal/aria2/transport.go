@@ -0,0 +1,89 @@
+package aria2
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+)
+
+// transport RPC 传输层，负责把请求送达 aria2 并取回对应的响应
+type transport interface {
+	roundTrip(ctx context.Context, request RPCRequest) (*RPCResponse, error)
+	close() error
+}
+
+// newTransport 根据协议选择传输层：ws/wss 使用 WebSocket，其余使用 HTTP POST
+// tlsConfig 为 nil 时使用默认 TLS 配置
+func newTransport(protocol string, rpcURL string, tlsConfig *tls.Config) transport {
+	switch protocol {
+	case "ws", "wss":
+		return newWSTransport(rpcURL, tlsConfig)
+	default:
+		return newHTTPTransport(rpcURL, tlsConfig)
+	}
+}
+
+// httpTransport 基于 HTTP POST 的传输层
+type httpTransport struct {
+	rpcURL string
+	client *http.Client
+}
+
+// newHTTPTransport 创建 HTTP 传输层
+func newHTTPTransport(rpcURL string, tlsConfig *tls.Config) *httpTransport {
+	client := &http.Client{}
+	if tlsConfig != nil {
+		// 在默认传输的基础上替换 TLS 配置，保留代理和连接池等设置
+		base := http.DefaultTransport.(*http.Transport).Clone()
+		base.TLSClientConfig = tlsConfig
+		client.Transport = base
+	}
+
+	return &httpTransport{
+		rpcURL: rpcURL,
+		client: client,
+	}
+}
+
+// roundTrip 通过一次 HTTP POST 发送请求
+func (t *httpTransport) roundTrip(ctx context.Context, request RPCRequest) (*RPCResponse, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.rpcURL, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// aria2 对密钥错误等 RPC 错误也会返回非 200 状态码，此时响应体仍是 JSON-RPC 错误
+	var rpcResponse RPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResponse); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+		}
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK && rpcResponse.Error == nil {
+		return nil, &HTTPError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return &rpcResponse, nil
+}
+
+// close 释放空闲连接
+func (t *httpTransport) close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
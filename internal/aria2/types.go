@@ -0,0 +1,114 @@
+package aria2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Int64 aria2 返回的整数字段
+// aria2 把所有数字编码为字符串（如 "1048576"），解码时同时接受字符串和 JSON 数字，
+// 无法解析的值返回错误而不是当作 0
+type Int64 int64
+
+// UnmarshalJSON 实现 json.Unmarshaler
+func (n *Int64) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		// 部分字段在没有数据时返回空字符串
+		if text == "" {
+			*n = 0
+			return nil
+		}
+	}
+
+	v, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("aria2: invalid integer %s", data)
+	}
+	*n = Int64(v)
+	return nil
+}
+
+// MarshalJSON 实现 json.Marshaler，与 aria2 一样编码为字符串
+func (n Int64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(int64(n), 10))
+}
+
+// Progress 下载进度，范围 0 到 1；总大小未知时返回 0
+func (t *TellStatus) Progress() float64 {
+	return progress(t.CompletedLength, t.TotalLength)
+}
+
+// ETA 按当前下载速度估算的剩余时间
+// 速度为 0 或总大小未知时无法估算，第二个返回值为 false
+func (t *TellStatus) ETA() (time.Duration, bool) {
+	if t.DownloadSpeed <= 0 || t.TotalLength <= 0 {
+		return 0, false
+	}
+	remaining := t.TotalLength - t.CompletedLength
+	if remaining < 0 {
+		remaining = 0
+	}
+	seconds := float64(remaining) / float64(t.DownloadSpeed)
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second), true
+}
+
+// Name 任务名称
+// BitTorrent 任务使用种子中的名称，其他任务使用第一个文件的文件名，
+// 文件名尚未确定时使用第一个 URI 的最后一段；都没有时返回空字符串
+func (t *TellStatus) Name() string {
+	if t.Bittorrent != nil && t.Bittorrent.Info.Name != "" {
+		return t.Bittorrent.Info.Name
+	}
+	if len(t.Files) == 0 {
+		return ""
+	}
+
+	file := t.Files[0]
+	if file.Path != "" {
+		return filepath.Base(file.Path)
+	}
+	if len(file.URIs) > 0 && file.URIs[0].URI != "" {
+		return uriName(file.URIs[0].URI)
+	}
+	return ""
+}
+
+// uriName 返回下载地址中的文件名，不含查询参数；路径中没有文件名时返回主机名
+func uriName(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return path.Base(uri)
+	}
+	if name := path.Base(u.Path); name != "/" && name != "." {
+		return name
+	}
+	return u.Host
+}
+
+// Progress 文件的下载进度，范围 0 到 1
+func (f *FileInfo) Progress() float64 {
+	return progress(f.CompletedLength, f.Length)
+}
+
+// progress 计算 completed 占 total 的比例
+func progress(completed, total Int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(completed) / float64(total)
+}
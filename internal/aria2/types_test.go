@@ -0,0 +1,109 @@
+package aria2
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestInt64UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json string
+		want Int64
+	}{
+		{`"1048576"`, 1048576},
+		{`1048576`, 1048576},
+		{`"0"`, 0},
+		{`""`, 0},
+		{`null`, 7},
+		{`"-1"`, -1},
+		{` "42" `, 42},
+		{`"9223372036854775807"`, 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		// null 保持原值，与 encoding/json 对其他类型的处理一致
+		n := Int64(7)
+		if err := json.Unmarshal([]byte(tt.json), &n); err != nil {
+			t.Errorf("%s: %v", tt.json, err)
+			continue
+		}
+		if n != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.json, n, tt.want)
+		}
+	}
+
+	// 无法解析的值返回错误，而不是当作 0
+	for _, data := range []string{`"abc"`, `"1.5"`, `1.5`, `true`, `"9223372036854775808"`, `{}`} {
+		var n Int64
+		if err := json.Unmarshal([]byte(data), &n); err == nil {
+			t.Errorf("%s: got %d, want an error", data, n)
+		}
+	}
+}
+
+func TestInt64InStatus(t *testing.T) {
+	data := `{
+		"gid": "2089b05ecca3d829",
+		"totalLength": "34896138",
+		"completedLength": "",
+		"downloadSpeed": 1024,
+		"files": [{"index": "1", "length": "34896138", "completedLength": "0", "path": "/downloads/file.iso"}]
+	}`
+
+	var status TellStatus
+	if err := json.Unmarshal([]byte(data), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.TotalLength != 34896138 || status.CompletedLength != 0 || status.DownloadSpeed != 1024 {
+		t.Errorf("got %+v", status)
+	}
+	if len(status.Files) != 1 || status.Files[0].Index != 1 || status.Files[0].Length != 34896138 {
+		t.Errorf("got files %+v", status.Files)
+	}
+
+	if err := json.Unmarshal([]byte(`{"totalLength": "n/a"}`), &status); err == nil {
+		t.Error("invalid totalLength was accepted")
+	}
+}
+
+func TestInt64MarshalJSON(t *testing.T) {
+	// 与 aria2 一样编码为字符串
+	data, err := json.Marshal(struct {
+		N Int64 `json:"n"`
+	}{N: 1048576})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"n":"1048576"}` {
+		t.Errorf("got %s", data)
+	}
+}
+
+func TestTellStatusName(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"torrent name", `{"bittorrent":{"info":{"name":"ubuntu"}},"files":[{"path":"/dl/ubuntu/a.iso"}]}`, "ubuntu"},
+		{"torrent without info", `{"bittorrent":{},"files":[{"path":"/dl/a.iso"}]}`, "a.iso"},
+		{"file path", `{"files":[{"path":"/dl/a.iso","uris":[{"uri":"http://example.com/b.iso"}]}]}`, "a.iso"},
+		// 尚未确定文件名时使用地址中的文件名，不含查询参数
+		{"uri", `{"files":[{"path":"","uris":[{"uri":"http://example.com/dir/b.iso?token=a/b"}]}]}`, "b.iso"},
+		{"escaped uri", `{"files":[{"path":"","uris":[{"uri":"http://example.com/my%20file.iso"}]}]}`, "my file.iso"},
+		{"uri without file", `{"files":[{"path":"","uris":[{"uri":"http://example.com/"}]}]}`, "example.com"},
+		{"no files", `{}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status TellStatus
+			if err := json.Unmarshal([]byte(tt.json), &status); err != nil {
+				t.Fatal(err)
+			}
+			if got := status.Name(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
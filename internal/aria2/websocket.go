@@ -0,0 +1,361 @@
+package aria2
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// ErrConnectionClosed WebSocket 连接在收到响应前断开
+var ErrConnectionClosed = errors.New("websocket connection closed")
+
+const (
+	// reconnectInterval 监听通知期间检查并重建连接的间隔
+	reconnectInterval = 3 * time.Second
+
+	// keepAliveDialTimeout 后台重连时建立连接的超时时间
+	keepAliveDialTimeout = 10 * time.Second
+)
+
+// wsTransport 基于 WebSocket 的传输层
+//
+// 所有请求复用同一条长连接，响应按请求 ID 分发给等待者。
+// 连接断开后，下一次请求会自动重新建立连接。
+type wsTransport struct {
+	rpcURL    string
+	tlsConfig *tls.Config
+	done      chan struct{}
+	// wake 取消监听后唤醒 keepAlive，使其及时退出
+	wake chan struct{}
+
+	mu        sync.Mutex
+	conn      *websocket.Conn
+	pending   map[string]chan *RPCResponse
+	closed    bool
+	listening bool
+	// keepingAlive keepAlive 协程是否在运行
+	keepingAlive bool
+	notify       func(method string, params json.RawMessage)
+}
+
+// wsMessage aria2 通过 WebSocket 推送的消息，既可能是响应也可能是通知
+type wsMessage struct {
+	RPCResponse
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// newWSTransport 创建 WebSocket 传输层，连接在第一次请求时建立
+func newWSTransport(rpcURL string, tlsConfig *tls.Config) *wsTransport {
+	return &wsTransport{
+		rpcURL:    rpcURL,
+		tlsConfig: tlsConfig,
+		done:      make(chan struct{}),
+		wake:      make(chan struct{}, 1),
+		pending:   make(map[string]chan *RPCResponse),
+	}
+}
+
+// roundTrip 发送请求并等待相同 ID 的响应
+func (t *wsTransport) roundTrip(ctx context.Context, request RPCRequest) (*RPCResponse, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// 复用的旧连接可能已经失效，此时重新建立连接后再试一次
+	for attempt := 0; ; attempt++ {
+		conn, fresh, ch, err := t.register(ctx, request.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := websocket.Message.Send(conn, string(data)); err != nil {
+			t.unregister(request.ID)
+			t.drop(conn)
+			if fresh || attempt > 0 {
+				return nil, err
+			}
+			continue
+		}
+
+		select {
+		case response, ok := <-ch:
+			if !ok {
+				return nil, ErrConnectionClosed
+			}
+			return response, nil
+		case <-ctx.Done():
+			// 放弃等待，迟到的响应会因找不到登记而被丢弃
+			t.unregister(request.ID)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// register 确保连接可用并登记等待响应的通道
+// 建立连接时不持有锁，期间其他请求和 readLoop 的分发不受影响
+func (t *wsTransport) register(ctx context.Context, id string) (*websocket.Conn, bool, chan *RPCResponse, error) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, false, nil, ErrConnectionClosed
+	}
+	if err := ctx.Err(); err != nil {
+		t.mu.Unlock()
+		return nil, false, nil, err
+	}
+	if t.conn != nil {
+		conn := t.conn
+		ch := t.addPending(id)
+		t.mu.Unlock()
+		return conn, false, ch, nil
+	}
+	t.mu.Unlock()
+
+	dialed, err := t.dial(ctx)
+	if err != nil {
+		return nil, false, nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	conn, fresh, err := t.install(dialed)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return conn, fresh, t.addPending(id), nil
+}
+
+// install 将新建立的连接设为当前连接，调用方需持有 mu
+// 传输层已关闭时关闭新连接并返回错误；其他请求已经先建立了连接时关闭新连接，
+// 返回已有的连接，fresh 为 false
+func (t *wsTransport) install(conn *websocket.Conn) (current *websocket.Conn, fresh bool, err error) {
+	if t.closed {
+		conn.Close()
+		return nil, false, ErrConnectionClosed
+	}
+	if t.conn != nil {
+		conn.Close()
+		return t.conn, false, nil
+	}
+
+	t.conn = conn
+	go t.readLoop(conn)
+	return conn, true, nil
+}
+
+// addPending 登记等待响应的通道，调用方需持有 mu
+func (t *wsTransport) addPending(id string) chan *RPCResponse {
+	ch := make(chan *RPCResponse, 1)
+	t.pending[id] = ch
+	return ch
+}
+
+// unregister 取消登记
+func (t *wsTransport) unregister(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pending, id)
+}
+
+// dial 建立 WebSocket 连接，TCP 连接、TLS 握手和 WebSocket 握手都受 ctx 限制
+func (t *wsTransport) dial(ctx context.Context) (*websocket.Conn, error) {
+	location, err := url.Parse(t.rpcURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// aria2 不校验 Origin，这里按目标地址构造一个合法值
+	origin := "http://" + location.Host + "/"
+	if location.Scheme == "wss" {
+		origin = "https://" + location.Host + "/"
+	}
+
+	config, err := websocket.NewConfig(t.rpcURL, origin)
+	if err != nil {
+		return nil, err
+	}
+	config.TlsConfig = t.tlsConfig
+
+	address := location.Host
+	if location.Port() == "" {
+		port := "80"
+		if location.Scheme == "wss" {
+			port = "443"
+		}
+		address = net.JoinHostPort(location.Hostname(), port)
+	}
+
+	var conn net.Conn
+	switch location.Scheme {
+	case "ws":
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", address)
+	case "wss":
+		conn, err = (&tls.Dialer{Config: t.tlsConfig}).DialContext(ctx, "tcp", address)
+	default:
+		return nil, websocket.ErrBadScheme
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// WebSocket 握手没有超时参数，ctx 结束时关闭底层连接使握手立即失败
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	ws, err := websocket.NewClient(config, conn)
+	if !stop() {
+		if err == nil {
+			ws.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ws, nil
+}
+
+// readLoop 持续读取连接上的消息，直到连接断开
+func (t *wsTransport) readLoop(conn *websocket.Conn) {
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(conn, &data); err != nil {
+			t.drop(conn)
+			return
+		}
+
+		var message wsMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			continue
+		}
+
+		// 通知消息没有 ID，交给订阅者处理
+		if message.Method != "" && message.ID == "" {
+			t.mu.Lock()
+			notify := t.notify
+			t.mu.Unlock()
+			if notify != nil {
+				notify(message.Method, message.Params)
+			}
+			continue
+		}
+
+		t.mu.Lock()
+		ch, ok := t.pending[message.ID]
+		delete(t.pending, message.ID)
+		t.mu.Unlock()
+
+		if ok {
+			response := message.RPCResponse
+			ch <- &response
+		}
+	}
+}
+
+// drop 关闭失效的连接，并让所有等待中的请求失败
+func (t *wsTransport) drop(conn *websocket.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn != conn {
+		return
+	}
+
+	conn.Close()
+	t.conn = nil
+	for id, ch := range t.pending {
+		close(ch)
+		delete(t.pending, id)
+	}
+}
+
+// listen 开始接收推送通知，监听期间连接断开会在后台自动重连
+func (t *wsTransport) listen(notify func(method string, params json.RawMessage)) {
+	t.mu.Lock()
+	t.notify = notify
+	t.listening = true
+	started := t.keepingAlive
+	t.keepingAlive = true
+	t.mu.Unlock()
+
+	if !started {
+		go t.keepAlive()
+	}
+}
+
+// unlisten 停止接收推送通知，没有等待中的请求后 keepAlive 随即退出
+func (t *wsTransport) unlisten() {
+	t.mu.Lock()
+	t.notify = nil
+	t.listening = false
+	t.mu.Unlock()
+
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// keepAlive 定期检查连接，断开时重新建立
+// 传输层关闭，或不再监听且没有等待中的请求时退出
+func (t *wsTransport) keepAlive() {
+	for {
+		t.mu.Lock()
+		closed, connected := t.closed, t.conn != nil
+		idle := !t.listening && len(t.pending) == 0
+		if closed || idle {
+			t.keepingAlive = false
+			t.mu.Unlock()
+			return
+		}
+		t.mu.Unlock()
+
+		if !connected {
+			ctx, cancel := context.WithTimeout(context.Background(), keepAliveDialTimeout)
+			conn, err := t.dial(ctx)
+			cancel()
+			if err == nil {
+				t.mu.Lock()
+				t.install(conn)
+				t.mu.Unlock()
+			}
+		}
+
+		select {
+		case <-time.After(reconnectInterval):
+		case <-t.wake:
+		case <-t.done:
+			t.mu.Lock()
+			t.keepingAlive = false
+			t.mu.Unlock()
+			return
+		}
+	}
+}
+
+// close 关闭连接，之后的请求都会失败
+func (t *wsTransport) close() error {
+	t.mu.Lock()
+	conn := t.conn
+	if !t.closed {
+		t.closed = true
+		close(t.done)
+	}
+	t.mu.Unlock()
+
+	if conn != nil {
+		t.drop(conn)
+	}
+	return nil
+}
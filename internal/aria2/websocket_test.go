@@ -0,0 +1,274 @@
+package aria2
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// newWSTestServer 启动 WebSocket 测试服务，handle 处理第 n 条连接（从 1 开始）
+// 返回的计数为已接受的连接数
+func newWSTestServer(t *testing.T, handle func(n int, conn *websocket.Conn)) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var conns int32
+	srv := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		handle(int(atomic.AddInt32(&conns, 1)), conn)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &conns
+}
+
+// receiveRequest 读取一个请求
+func receiveRequest(conn *websocket.Conn) (RPCRequest, bool) {
+	var request RPCRequest
+	if err := websocket.JSON.Receive(conn, &request); err != nil {
+		return request, false
+	}
+	return request, true
+}
+
+// echoGID 返回把请求中的 GID 作为 tellStatus 结果的响应
+func echoGID(request RPCRequest) map[string]interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      request.ID,
+		"result":  map[string]interface{}{"gid": request.Params[1]},
+	}
+}
+
+func TestWebSocketCorrelatesResponses(t *testing.T) {
+	const calls = 4
+
+	srv, _ := newWSTestServer(t, func(n int, conn *websocket.Conn) {
+		// 收齐全部请求后倒序响应，中间夹杂未知 ID 的响应和通知
+		var requests []RPCRequest
+		for len(requests) < calls {
+			request, ok := receiveRequest(conn)
+			if !ok {
+				return
+			}
+			requests = append(requests, request)
+		}
+
+		websocket.JSON.Send(conn, map[string]interface{}{"jsonrpc": "2.0", "id": "unknown", "result": "OK"})
+		for i := len(requests) - 1; i >= 0; i-- {
+			websocket.JSON.Send(conn, echoGID(requests[i]))
+			websocket.JSON.Send(conn, map[string]interface{}{
+				"jsonrpc": "2.0",
+				"method":  string(EventDownloadStart),
+				"params":  []interface{}{map[string]string{"gid": "event"}},
+			})
+		}
+		receiveRequest(conn)
+	})
+	client := newTestClient(t, srv, "ws")
+
+	events := make(chan Event, calls)
+	client.SubscribeChan(events)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func(gid string) {
+			defer wg.Done()
+			status, err := client.TellStatus(gid)
+			if err != nil {
+				errs <- err
+			} else if status.GID != gid {
+				errs <- fmt.Errorf("call for %s got the response for %s", gid, status.GID)
+			}
+		}(fmt.Sprintf("%016x", i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for i := 0; i < calls; i++ {
+		select {
+		case event := <-events:
+			if event != (Event{Type: EventDownloadStart, GID: "event"}) {
+				t.Errorf("got event %+v", event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d notifications, want %d", i, calls)
+		}
+	}
+
+	ws := client.transport.(*wsTransport)
+	ws.mu.Lock()
+	pending := len(ws.pending)
+	ws.mu.Unlock()
+	if pending != 0 {
+		t.Errorf("%d calls still pending", pending)
+	}
+}
+
+func TestWebSocketReconnect(t *testing.T) {
+	srv, conns := newWSTestServer(t, func(n int, conn *websocket.Conn) {
+		for {
+			request, ok := receiveRequest(conn)
+			if !ok {
+				return
+			}
+			// 第一条连接在第二个请求时断开，不发送响应
+			if n == 1 && request.Params[1] == "drop" {
+				conn.Close()
+				return
+			}
+			websocket.JSON.Send(conn, echoGID(request))
+		}
+	})
+	client := newTestClient(t, srv, "ws")
+
+	if _, err := client.TellStatus("first"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 等待响应期间连接断开，请求失败
+	_, err := client.TellStatus("drop")
+	if !errors.Is(err, ErrConnectionClosed) || !IsRetryable(err) {
+		t.Errorf("got %v, want a retryable ErrConnectionClosed", err)
+	}
+
+	// 下一次请求重新建立连接
+	status, err := client.TellStatus("second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.GID != "second" {
+		t.Errorf("got %s, want second", status.GID)
+	}
+	if n := atomic.LoadInt32(conns); n != 2 {
+		t.Errorf("got %d connections, want 2", n)
+	}
+
+	// 关闭后的客户端不再连接
+	client.Close()
+	if _, err := client.TellStatus("closed"); !errors.Is(err, ErrConnectionClosed) {
+		t.Errorf("call after Close: %v, want ErrConnectionClosed", err)
+	}
+	if n := atomic.LoadInt32(conns); n != 2 {
+		t.Errorf("got %d connections after Close, want 2", n)
+	}
+}
+
+func TestWebSocketLateResponse(t *testing.T) {
+	release := make(chan struct{})
+	srv, _ := newWSTestServer(t, func(n int, conn *websocket.Conn) {
+		for {
+			request, ok := receiveRequest(conn)
+			if !ok {
+				return
+			}
+			if request.Params[1] == "slow" {
+				<-release
+			}
+			websocket.JSON.Send(conn, echoGID(request))
+		}
+	})
+	client := newTestClient(t, srv, "ws")
+
+	// 超时的请求放弃等待，迟到的响应不会交给之后的请求
+	client.SetTimeout(100 * time.Millisecond)
+	if _, err := client.TellStatus("slow"); err == nil {
+		t.Fatal("slow call did not time out")
+	}
+	close(release)
+
+	client.SetTimeout(5 * time.Second)
+	status, err := client.TellStatus("fast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.GID != "fast" {
+		t.Errorf("got %s, want fast", status.GID)
+	}
+}
+
+// waitKeepingAlive 等待 keepAlive 协程的运行状态变为 want
+func waitKeepingAlive(t *testing.T, ws *wsTransport, want bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ws.mu.Lock()
+		running := ws.keepingAlive
+		ws.mu.Unlock()
+		if running == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("keepAlive running = %v, want %v", running, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebSocketKeepAliveStops(t *testing.T) {
+	release := make(chan struct{})
+	srv, _ := newWSTestServer(t, func(n int, conn *websocket.Conn) {
+		for {
+			request, ok := receiveRequest(conn)
+			if !ok {
+				return
+			}
+			if request.Params[1] == "slow" {
+				<-release
+			}
+			websocket.JSON.Send(conn, echoGID(request))
+		}
+	})
+	client := newTestClient(t, srv, "ws")
+	ws := client.transport.(*wsTransport)
+
+	first := client.Subscribe(func(Event) {})
+	second := client.Subscribe(func(Event) {})
+	waitKeepingAlive(t, ws, true)
+
+	// 还有订阅者时继续保持连接
+	first.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+	waitKeepingAlive(t, ws, true)
+
+	// 最后一个订阅者取消时还有等待中的请求，请求结束后才停止
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.TellStatus("slow")
+		done <- err
+	}()
+	for {
+		ws.mu.Lock()
+		pending := len(ws.pending)
+		ws.mu.Unlock()
+		if pending > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	second.Unsubscribe()
+	second.Unsubscribe()
+	time.Sleep(50 * time.Millisecond)
+	waitKeepingAlive(t, ws, true)
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	waitKeepingAlive(t, ws, false)
+
+	// 重新订阅后再次启动
+	client.Subscribe(func(Event) {})
+	waitKeepingAlive(t, ws, true)
+	client.Close()
+	waitKeepingAlive(t, ws, false)
+}
@@ -0,0 +1,244 @@
+// Package cleanup 删除已移除任务在本机上留下的文件
+//
+// 删除的范围严格限定在任务的下载目录中：任务的文件、aria2 控制文件（.aria2）、
+// 保存的 .torrent 和 .meta4 文件，以及删除后变空的子目录。
+// 路径经过符号链接解析后仍须位于下载目录中，下载目录本身和目录外的路径一律不删除。
+// 支持回收站的桌面上文件被放入回收站，否则直接删除。
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+// Task 需要删除文件的任务，路径均为本机路径
+type Task struct {
+	// Dir 任务的下载目录，只删除该目录中的文件
+	Dir string
+	// Files 任务的文件
+	Files []string
+	// Sidecars 控制文件、种子等附属文件，不存在时忽略
+	Sidecars []string
+}
+
+// Result 删除的结果
+type Result struct {
+	// Trashed 放入回收站的文件
+	Trashed []string
+	// Deleted 直接删除的文件
+	Deleted []string
+	// Dirs 删除的空目录
+	Dirs []string
+	// Errors 未能删除或被拒绝删除的文件
+	Errors []error
+}
+
+// Count 返回删除的文件数量，不含空目录
+func (r Result) Count() int {
+	return len(r.Trashed) + len(r.Deleted)
+}
+
+// FromStatus 根据 aria2 返回的任务状态生成 Task，status 至少需要 dir、files、infoHash 和 bittorrent 字段
+// localPath 将服务器上的路径转换为本机路径；下载目录无法转换时返回错误，
+// 无法转换的单个文件不在下载目录中，直接跳过
+func FromStatus(status aria2.TellStatus, localPath func(remote string) (string, bool)) (Task, error) {
+	if status.Dir == "" {
+		return Task{}, fmt.Errorf("cleanup: task %s has no download directory", status.GID)
+	}
+	dir, ok := localPath(status.Dir)
+	if !ok {
+		return Task{}, fmt.Errorf("cleanup: no local path for %s, add a path mapping to the server profile", status.Dir)
+	}
+
+	task := Task{Dir: dir}
+	for _, file := range status.Files {
+		// 磁力链接获取元数据期间的路径形如 [METADATA]<hash>，不是真正的文件
+		if file.Path == "" || strings.HasPrefix(file.Path, "[METADATA]") {
+			continue
+		}
+		path, ok := localPath(file.Path)
+		if !ok {
+			continue
+		}
+		task.Files = append(task.Files, path)
+		// HTTP、FTP 和单文件种子的控制文件与文件同名
+		task.Sidecars = append(task.Sidecars, path+".aria2")
+	}
+
+	if status.Bittorrent != nil {
+		// 多文件种子的控制文件以种子名称命名；通过链接下载的种子文件通常与种子同名，
+		// bt-save-metadata 保存的元数据以信息哈希命名
+		if name := status.Bittorrent.Info.Name; name != "" && !strings.ContainsAny(name, `/\`) {
+			task.Sidecars = append(task.Sidecars,
+				filepath.Join(dir, name+".aria2"),
+				filepath.Join(dir, name+".torrent"),
+			)
+		}
+		if status.InfoHash != "" {
+			task.Sidecars = append(task.Sidecars, filepath.Join(dir, status.InfoHash+".torrent"))
+		}
+	} else if name := status.Name(); name != "" && !strings.ContainsAny(name, `/\`) {
+		// 通过链接下载的 Metalink 文件（follow-metalink）通常以文件名加 .meta4 保存在下载目录
+		task.Sidecars = append(task.Sidecars, filepath.Join(dir, name+".meta4"))
+	}
+
+	return task, nil
+}
+
+// Remove 删除任务的文件和附属文件，并删除因此变空的子目录
+// useTrash 为 true 时尽量放入回收站，当前桌面不支持回收站时直接删除；
+// 不存在的文件被忽略，单个文件失败不影响其他文件
+func Remove(task Task, useTrash bool) Result {
+	var result Result
+
+	root, realRoot, err := resolveRoot(task.Dir)
+	if err != nil {
+		result.Errors = append(result.Errors, err)
+		return result
+	}
+
+	seen := make(map[string]bool)
+	var parents []string
+	remove := func(path string, sidecar bool) {
+		path = filepath.Clean(path)
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+
+		info, err := checkTarget(root, realRoot, path)
+		if err != nil {
+			// 附属文件是推测的路径，不在下载目录中时同样不删除，但无需报告
+			if !sidecar {
+				result.Errors = append(result.Errors, err)
+			}
+			return
+		}
+		if info == nil {
+			// 文件不存在，可能尚未开始下载或已被手动删除
+			return
+		}
+		if info.IsDir() {
+			if !sidecar {
+				result.Errors = append(result.Errors, fmt.Errorf("cleanup: %s is a directory", path))
+			}
+			return
+		}
+
+		if useTrash {
+			err := MoveToTrash(path)
+			if err == nil {
+				result.Trashed = append(result.Trashed, path)
+				parents = append(parents, filepath.Dir(path))
+				return
+			}
+			if !errors.Is(err, ErrTrashUnsupported) {
+				result.Errors = append(result.Errors, fmt.Errorf("cleanup: move %s to trash: %w", path, err))
+				return
+			}
+		}
+		if err := os.Remove(path); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("cleanup: %w", err))
+			return
+		}
+		result.Deleted = append(result.Deleted, path)
+		parents = append(parents, filepath.Dir(path))
+	}
+
+	for _, path := range task.Files {
+		remove(path, false)
+	}
+	for _, path := range task.Sidecars {
+		remove(path, true)
+	}
+
+	for _, dir := range parents {
+		result.Dirs = append(result.Dirs, removeEmptyDirs(root, dir, seen)...)
+	}
+	return result
+}
+
+// resolveRoot 检查下载目录，返回清理后的路径和解析符号链接后的真实路径
+func resolveRoot(dir string) (string, string, error) {
+	if dir == "" || !filepath.IsAbs(dir) {
+		return "", "", fmt.Errorf("cleanup: download directory %q is not an absolute path", dir)
+	}
+	root := filepath.Clean(dir)
+	// 整个磁盘或分区的根目录不能作为删除范围
+	if filepath.Dir(root) == root {
+		return "", "", fmt.Errorf("cleanup: refusing to delete files in %s", root)
+	}
+
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", "", fmt.Errorf("cleanup: %w", err)
+	}
+	return root, realRoot, nil
+}
+
+// checkTarget 检查 path 是否位于下载目录中
+// 所在目录解析符号链接后也必须在下载目录中，防止通过链接删除目录外的文件；
+// 文件不存在时返回 nil, nil
+func checkTarget(root, realRoot, path string) (os.FileInfo, error) {
+	if !filepath.IsAbs(path) || !within(root, path) {
+		return nil, fmt.Errorf("cleanup: %s is outside the download directory %s", path, root)
+	}
+
+	realParent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cleanup: %w", err)
+	}
+	if realParent != realRoot && !within(realRoot, realParent) {
+		return nil, fmt.Errorf("cleanup: %s resolves outside the download directory %s", path, root)
+	}
+
+	// 文件本身是符号链接时只删除链接
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cleanup: %w", err)
+	}
+	return info, nil
+}
+
+// within 判断 path 是否位于 root 中，不含 root 本身
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// removeEmptyDirs 从 dir 开始向上删除空目录，到下载目录为止（不含下载目录本身）
+// done 记录已经处理过的路径，多个文件在同一目录时只检查一次
+func removeEmptyDirs(root, dir string, done map[string]bool) []string {
+	var removed []string
+	for within(root, dir) && !done[dir] {
+		// 指向目录的符号链接不是任务创建的目录，保留
+		if info, err := os.Lstat(dir); err != nil || !info.IsDir() {
+			break
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil || len(entries) > 0 {
+			break
+		}
+		if err := os.Remove(dir); err != nil {
+			break
+		}
+		done[dir] = true
+		removed = append(removed, dir)
+		dir = filepath.Dir(dir)
+	}
+	return removed
+}
@@ -0,0 +1,380 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+// writeFile 创建文件及其所在目录
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// exists 判断路径是否存在，符号链接本身存在即可
+func exists(t *testing.T, path string) bool {
+	t.Helper()
+
+	_, err := os.Lstat(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+func TestRemove(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup 在 dir 中创建文件并返回要删除的任务，outside 是下载目录之外的目录
+		setup func(t *testing.T, dir, outside string) Task
+		// deleted 应被删除的路径，相对于 dir
+		deleted []string
+		// kept 应保留的路径，以 "outside/" 开头的相对于 outside，其余相对于 dir
+		kept []string
+		// dirs 应被删除的空目录，相对于 dir
+		dirs []string
+		// errors 期望的错误数量
+		errors int
+	}{
+		{
+			name: "files and sidecars",
+			setup: func(t *testing.T, dir, outside string) Task {
+				writeFile(t, filepath.Join(dir, "a.iso"))
+				writeFile(t, filepath.Join(dir, "a.iso.aria2"))
+				writeFile(t, filepath.Join(dir, "other.iso"))
+				return Task{
+					Dir:   dir,
+					Files: []string{filepath.Join(dir, "a.iso")},
+					// 不存在的附属文件被忽略
+					Sidecars: []string{filepath.Join(dir, "a.iso.aria2"), filepath.Join(dir, "a.torrent")},
+				}
+			},
+			deleted: []string{"a.iso", "a.iso.aria2"},
+			kept:    []string{"other.iso"},
+		},
+		{
+			name: "missing file",
+			setup: func(t *testing.T, dir, outside string) Task {
+				return Task{Dir: dir, Files: []string{filepath.Join(dir, "never-started.iso")}}
+			},
+		},
+		{
+			name: "empty parent directories",
+			setup: func(t *testing.T, dir, outside string) Task {
+				writeFile(t, filepath.Join(dir, "show", "s01", "e01.mkv"))
+				writeFile(t, filepath.Join(dir, "show", "s01", "e02.mkv"))
+				writeFile(t, filepath.Join(dir, "keep", "sub", "x.bin"))
+				writeFile(t, filepath.Join(dir, "keep", "other.txt"))
+				return Task{
+					Dir: dir,
+					Files: []string{
+						filepath.Join(dir, "show", "s01", "e01.mkv"),
+						filepath.Join(dir, "show", "s01", "e02.mkv"),
+						filepath.Join(dir, "keep", "sub", "x.bin"),
+					},
+				}
+			},
+			deleted: []string{"show/s01/e01.mkv", "show/s01/e02.mkv", "keep/sub/x.bin"},
+			// 仍有其他文件的目录保留
+			kept: []string{"keep/other.txt"},
+			dirs: []string{"show/s01", "show", "keep/sub"},
+		},
+		{
+			name: "dot dot path",
+			setup: func(t *testing.T, dir, outside string) Task {
+				writeFile(t, filepath.Join(outside, "victim.txt"))
+				rel, err := filepath.Rel(dir, filepath.Join(outside, "victim.txt"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return Task{
+					Dir:      dir,
+					Files:    []string{dir + string(filepath.Separator) + rel},
+					Sidecars: []string{filepath.Join(dir, "..", "victim.txt.aria2")},
+				}
+			},
+			kept: []string{"outside/victim.txt"},
+			// 附属文件不在下载目录中时不报告
+			errors: 1,
+		},
+		{
+			name: "path outside dir",
+			setup: func(t *testing.T, dir, outside string) Task {
+				writeFile(t, filepath.Join(outside, "victim.txt"))
+				return Task{Dir: dir, Files: []string{filepath.Join(outside, "victim.txt"), "relative.txt"}}
+			},
+			kept:   []string{"outside/victim.txt"},
+			errors: 2,
+		},
+		{
+			name: "download dir itself",
+			setup: func(t *testing.T, dir, outside string) Task {
+				return Task{Dir: dir, Files: []string{dir}}
+			},
+			errors: 1,
+		},
+		{
+			name: "directory",
+			setup: func(t *testing.T, dir, outside string) Task {
+				writeFile(t, filepath.Join(dir, "folder", "x.bin"))
+				return Task{Dir: dir, Files: []string{filepath.Join(dir, "folder")}}
+			},
+			kept:   []string{"folder/x.bin"},
+			errors: 1,
+		},
+		{
+			name: "symlink escaping dir",
+			setup: func(t *testing.T, dir, outside string) Task {
+				writeFile(t, filepath.Join(outside, "victim.txt"))
+				symlink(t, outside, filepath.Join(dir, "link"))
+				return Task{
+					Dir:      dir,
+					Files:    []string{filepath.Join(dir, "link", "victim.txt")},
+					Sidecars: []string{filepath.Join(dir, "link", "victim.txt.aria2")},
+				}
+			},
+			kept:   []string{"outside/victim.txt", "link"},
+			errors: 1,
+		},
+		{
+			name: "symlink file",
+			setup: func(t *testing.T, dir, outside string) Task {
+				writeFile(t, filepath.Join(outside, "victim.txt"))
+				symlink(t, filepath.Join(outside, "victim.txt"), filepath.Join(dir, "a.iso"))
+				return Task{Dir: dir, Files: []string{filepath.Join(dir, "a.iso")}}
+			},
+			// 只删除链接本身，不删除链接指向的文件
+			deleted: []string{"a.iso"},
+			kept:    []string{"outside/victim.txt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := t.TempDir()
+			dir := filepath.Join(base, "downloads")
+			outside := filepath.Join(base, "outside")
+			for _, d := range []string{dir, outside} {
+				if err := os.Mkdir(d, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			task := tt.setup(t, dir, outside)
+			result := Remove(task, false)
+
+			if len(result.Errors) != tt.errors {
+				t.Errorf("got errors %v, want %d", result.Errors, tt.errors)
+			}
+			if len(result.Trashed) != 0 {
+				t.Errorf("trashed %v without useTrash", result.Trashed)
+			}
+
+			var want []string
+			for _, p := range tt.deleted {
+				want = append(want, filepath.Join(dir, filepath.FromSlash(p)))
+			}
+			if got := sorted(result.Deleted); !reflect.DeepEqual(got, sorted(want)) {
+				t.Errorf("deleted %v, want %v", got, sorted(want))
+			}
+			for _, p := range want {
+				if exists(t, p) {
+					t.Errorf("%s still exists", p)
+				}
+			}
+
+			var wantDirs []string
+			for _, p := range tt.dirs {
+				wantDirs = append(wantDirs, filepath.Join(dir, filepath.FromSlash(p)))
+			}
+			if got := sorted(result.Dirs); !reflect.DeepEqual(got, sorted(wantDirs)) {
+				t.Errorf("removed directories %v, want %v", got, sorted(wantDirs))
+			}
+
+			for _, p := range tt.kept {
+				path := filepath.Join(dir, filepath.FromSlash(p))
+				if rest, ok := strings.CutPrefix(p, "outside/"); ok {
+					path = filepath.Join(outside, filepath.FromSlash(rest))
+				}
+				if !exists(t, path) {
+					t.Errorf("%s was removed", path)
+				}
+			}
+			if !exists(t, dir) {
+				t.Error("download directory was removed")
+			}
+		})
+	}
+}
+
+func TestRemoveSymlinkedDownloadDir(t *testing.T) {
+	base := t.TempDir()
+	real := filepath.Join(base, "real")
+	writeFile(t, filepath.Join(real, "sub", "a.iso"))
+	link := filepath.Join(base, "downloads")
+	symlink(t, real, link)
+
+	result := Remove(Task{Dir: link, Files: []string{filepath.Join(link, "sub", "a.iso")}}, false)
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	if result.Count() != 1 || exists(t, filepath.Join(real, "sub", "a.iso")) {
+		t.Errorf("got %+v, want the file deleted through the linked download directory", result)
+	}
+	if exists(t, filepath.Join(real, "sub")) {
+		t.Error("empty directory was not removed")
+	}
+	if !exists(t, real) || !exists(t, link) {
+		t.Error("download directory was removed")
+	}
+}
+
+func TestRemoveRefusesDir(t *testing.T) {
+	root := string(filepath.Separator)
+	if vol := filepath.VolumeName(os.TempDir()); vol != "" {
+		root = vol + string(filepath.Separator)
+	}
+
+	tests := map[string]string{
+		"empty":    "",
+		"relative": "downloads",
+		"root":     root,
+		"missing":  filepath.Join(t.TempDir(), "missing"),
+	}
+
+	for name, dir := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := Remove(Task{Dir: dir, Files: []string{filepath.Join(dir, "a.iso")}}, false)
+			if len(result.Errors) != 1 {
+				t.Errorf("got errors %v, want the download directory refused", result.Errors)
+			}
+			if result.Count() != 0 {
+				t.Errorf("removed %+v", result)
+			}
+		})
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	remote := "/srv/downloads"
+	local := filepath.Join(t.TempDir(), "downloads")
+	// localPath 把服务器上 /srv/downloads 中的路径映射到本机
+	localPath := func(path string) (string, bool) {
+		rel, err := filepath.Rel(remote, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			return "", false
+		}
+		return filepath.Join(local, rel), true
+	}
+
+	bittorrent := &aria2.BittorrentInfo{}
+	bittorrent.Info.Name = "album"
+
+	tests := []struct {
+		name     string
+		status   aria2.TellStatus
+		files    []string
+		sidecars []string
+	}{
+		{
+			name: "http",
+			status: aria2.TellStatus{
+				GID: "1", Dir: remote,
+				Files: []aria2.FileInfo{{Path: remote + "/a.iso", URIs: []aria2.URI{{URI: "http://example.com/a.iso"}}}},
+			},
+			files:    []string{"a.iso"},
+			sidecars: []string{"a.iso.aria2", "a.iso.meta4"},
+		},
+		{
+			name: "multi-file torrent",
+			status: aria2.TellStatus{
+				GID: "2", Dir: remote, InfoHash: "abcdef", Bittorrent: bittorrent,
+				Files: []aria2.FileInfo{
+					{Path: remote + "/album/01.flac"},
+					{Path: remote + "/album/02.flac"},
+				},
+			},
+			files: []string{"album/01.flac", "album/02.flac"},
+			sidecars: []string{
+				"album/01.flac.aria2", "album/02.flac.aria2",
+				"album.aria2", "album.torrent", "abcdef.torrent",
+			},
+		},
+		{
+			name: "magnet metadata",
+			status: aria2.TellStatus{
+				GID: "3", Dir: remote, InfoHash: "abcdef", Bittorrent: &aria2.BittorrentInfo{},
+				Files: []aria2.FileInfo{{Path: "[METADATA]abcdef"}},
+			},
+			sidecars: []string{"abcdef.torrent"},
+		},
+		{
+			name: "unmapped file",
+			status: aria2.TellStatus{
+				GID: "4", Dir: remote,
+				Files: []aria2.FileInfo{{Path: "/elsewhere/a.iso"}, {Path: ""}},
+			},
+			sidecars: []string{"a.iso.meta4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := FromStatus(tt.status, localPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if task.Dir != local {
+				t.Errorf("got dir %s, want %s", task.Dir, local)
+			}
+			join := func(paths []string) []string {
+				var out []string
+				for _, p := range paths {
+					out = append(out, filepath.Join(local, filepath.FromSlash(p)))
+				}
+				return out
+			}
+			if !reflect.DeepEqual(task.Files, join(tt.files)) {
+				t.Errorf("got files %v, want %v", task.Files, join(tt.files))
+			}
+			if !reflect.DeepEqual(task.Sidecars, join(tt.sidecars)) {
+				t.Errorf("got sidecars %v, want %v", task.Sidecars, join(tt.sidecars))
+			}
+		})
+	}
+
+	if _, err := FromStatus(aria2.TellStatus{GID: "5"}, localPath); err == nil {
+		t.Error("task without a download directory was accepted")
+	}
+	if _, err := FromStatus(aria2.TellStatus{GID: "6", Dir: "/elsewhere"}, localPath); err == nil {
+		t.Error("unmapped download directory was accepted")
+	}
+}
+
+// symlink 创建符号链接，系统不支持时跳过测试
+func symlink(t *testing.T, target, link string) {
+	t.Helper()
+
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+}
+
+// sorted 返回排序后的副本
+func sorted(paths []string) []string {
+	out := append([]string(nil), paths...)
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,134 @@
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// ErrTrashUnsupported 当前桌面没有可用的回收站，或文件无法移入回收站所在的分区
+// 调用方遇到该错误时可以改为直接删除；其他错误说明文件本身无法移动，直接删除通常同样会失败
+var ErrTrashUnsupported = errors.New("cleanup: trash is not supported")
+
+// MoveToTrash 将文件放入回收站
+// Windows 通过 PowerShell 调用系统回收站，macOS 通过 Finder，
+// 其他系统优先使用 gio trash，没有 gio 或 gio 失败时按 freedesktop.org 规范移入用户的回收站目录
+func MoveToTrash(path string) error {
+	switch runtime.GOOS {
+	case "windows":
+		return trashWindows(path)
+	case "darwin":
+		return trashDarwin(path)
+	default:
+		if gio, err := exec.LookPath("gio"); err == nil {
+			// gio 在没有会话总线或找不到回收站时同样失败，原因无法从输出可靠判断
+			if runTrashCommand(exec.Command(gio, "trash", "--", path)) == nil {
+				return nil
+			}
+		}
+		return trashFreedesktop(path)
+	}
+}
+
+// trashWindows 通过 Microsoft.VisualBasic 的 FileSystem.DeleteFile 放入回收站
+// 路径通过环境变量传入，避免在脚本中转义
+func trashWindows(path string) error {
+	powershell, err := exec.LookPath("powershell")
+	if err != nil {
+		return ErrTrashUnsupported
+	}
+
+	cmd := exec.Command(powershell, "-NoProfile", "-NonInteractive", "-Command",
+		"Add-Type -AssemblyName Microsoft.VisualBasic; "+
+			"[Microsoft.VisualBasic.FileIO.FileSystem]::DeleteFile($env:ARIA2GOUI_TRASH_PATH, 'OnlyErrorDialogs', 'SendToRecycleBin')")
+	cmd.Env = append(os.Environ(), "ARIA2GOUI_TRASH_PATH="+path)
+	return runTrashCommand(cmd)
+}
+
+// trashDarwin 通过 Finder 放入废纸篓，路径作为脚本参数传入
+func trashDarwin(path string) error {
+	osascript, err := exec.LookPath("osascript")
+	if err != nil {
+		return ErrTrashUnsupported
+	}
+
+	return runTrashCommand(exec.Command(osascript,
+		"-e", "on run argv",
+		"-e", `tell application "Finder" to delete POSIX file (item 1 of argv)`,
+		"-e", "end run",
+		path,
+	))
+}
+
+// runTrashCommand 执行回收站命令，失败时附带命令的输出
+func runTrashCommand(cmd *exec.Cmd) error {
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if len(output) > 0 {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return err
+	}
+	return nil
+}
+
+// trashFreedesktop 按 freedesktop.org 回收站规范移入 $XDG_DATA_HOME/Trash
+// 回收站目录无法创建或写入，或文件与回收站不在同一分区时，返回包装了 ErrTrashUnsupported 的错误
+func trashFreedesktop(path string) error {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ErrTrashUnsupported
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	filesDir := filepath.Join(dataHome, "Trash", "files")
+	infoDir := filepath.Join(dataHome, "Trash", "info")
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return fmt.Errorf("%w: %v", ErrTrashUnsupported, err)
+	}
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return fmt.Errorf("%w: %v", ErrTrashUnsupported, err)
+	}
+
+	// 先独占创建 .trashinfo 占住名称，回收站中已有同名文件时加序号
+	name := filepath.Base(path)
+	for i := 1; ; i++ {
+		trashName := name
+		if i > 1 {
+			trashName = fmt.Sprintf("%s.%d", name, i)
+		}
+
+		infoPath := filepath.Join(infoDir, trashName+".trashinfo")
+		info, err := os.OpenFile(infoPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrTrashUnsupported, err)
+		}
+		_, err = fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+			(&url.URL{Path: path}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+		if closeErr := info.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(path, filepath.Join(filesDir, trashName))
+		}
+		if err != nil {
+			os.Remove(infoPath)
+			if errors.Is(err, syscall.EXDEV) {
+				return ErrTrashUnsupported
+			}
+			return err
+		}
+		return nil
+	}
+}
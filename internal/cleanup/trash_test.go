@@ -0,0 +1,125 @@
+package cleanup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestTrashFreedesktop(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("freedesktop trash is only used on other systems")
+	}
+
+	base := t.TempDir()
+	dataHome := filepath.Join(base, "share")
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	filesDir := filepath.Join(dataHome, "Trash", "files")
+	infoDir := filepath.Join(dataHome, "Trash", "info")
+
+	// 回收站中已有同名文件时依次使用 name.2、name.3
+	tests := []struct {
+		path      string
+		trashName string
+		// escaped .trashinfo 中 Path 的值
+		escaped string
+	}{
+		{filepath.Join(base, "downloads", "my file.iso"), "my file.iso", "/downloads/my%20file.iso"},
+		{filepath.Join(base, "other", "my file.iso"), "my file.iso.2", "/other/my%20file.iso"},
+		{filepath.Join(base, "third", "my file.iso"), "my file.iso.3", "/third/my%20file.iso"},
+		{filepath.Join(base, "downloads", "100%.txt"), "100%.txt", "/downloads/100%25.txt"},
+	}
+
+	deletionDate := regexp.MustCompile(`^DeletionDate=\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}$`)
+	for _, tt := range tests {
+		writeFile(t, tt.path)
+		if err := trashFreedesktop(tt.path); err != nil {
+			t.Fatalf("trash %s: %v", tt.path, err)
+		}
+
+		if exists(t, tt.path) {
+			t.Errorf("%s still exists", tt.path)
+		}
+		if !exists(t, filepath.Join(filesDir, tt.trashName)) {
+			t.Errorf("%s is not in the trash as %q", tt.path, tt.trashName)
+		}
+
+		data, err := os.ReadFile(filepath.Join(infoDir, tt.trashName+".trashinfo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(string(data), "\n")
+		if len(lines) != 4 || lines[0] != "[Trash Info]" || lines[3] != "" {
+			t.Fatalf("trashinfo for %s:\n%s", tt.path, data)
+		}
+		if want := "Path=" + filepath.ToSlash(base) + tt.escaped; lines[1] != want {
+			t.Errorf("got %q, want %q", lines[1], want)
+		}
+		if !deletionDate.MatchString(lines[2]) {
+			t.Errorf("got %q, want a local DeletionDate without time zone", lines[2])
+		}
+	}
+}
+
+func TestTrashFreedesktopInfoCollision(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("freedesktop trash is only used on other systems")
+	}
+
+	base := t.TempDir()
+	dataHome := filepath.Join(base, "share")
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	// 只有 .trashinfo 占用名称时同样视为冲突，不能覆盖已有的记录
+	stale := filepath.Join(dataHome, "Trash", "info", "a.iso.trashinfo")
+	writeFile(t, stale)
+
+	path := filepath.Join(base, "a.iso")
+	writeFile(t, path)
+	if err := trashFreedesktop(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, err := os.ReadFile(stale); err != nil || string(data) != "data" {
+		t.Errorf("existing trashinfo was changed: %q, %v", data, err)
+	}
+	if !exists(t, filepath.Join(dataHome, "Trash", "files", "a.iso.2")) ||
+		!exists(t, filepath.Join(dataHome, "Trash", "info", "a.iso.2.trashinfo")) {
+		t.Error("file was not trashed as a.iso.2")
+	}
+}
+
+func TestTrashFreedesktopUnavailable(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("freedesktop trash is only used on other systems")
+	}
+
+	base := t.TempDir()
+	path := filepath.Join(base, "a.iso")
+	writeFile(t, path)
+
+	// 回收站目录无法创建时视为没有回收站，由调用方改为直接删除
+	notDir := filepath.Join(base, "share")
+	writeFile(t, notDir)
+	t.Setenv("XDG_DATA_HOME", notDir)
+	if err := trashFreedesktop(path); !errors.Is(err, ErrTrashUnsupported) {
+		t.Errorf("got %v, want ErrTrashUnsupported", err)
+	}
+	if !exists(t, path) {
+		t.Error("file was moved")
+	}
+
+	// 文件本身无法移动不是回收站的问题，直接删除同样会失败
+	t.Setenv("XDG_DATA_HOME", filepath.Join(base, "data"))
+	err := trashFreedesktop(filepath.Join(base, "missing.iso"))
+	if err == nil || errors.Is(err, ErrTrashUnsupported) {
+		t.Errorf("missing file: got %v, want an error other than ErrTrashUnsupported", err)
+	}
+	if infos, _ := os.ReadDir(filepath.Join(base, "data", "Trash", "info")); len(infos) != 0 {
+		t.Errorf("left %d trashinfo files behind", len(infos))
+	}
+}
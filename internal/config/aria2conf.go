@@ -0,0 +1,379 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Aria2Option aria2.conf 中的一个选项
+type Aria2Option struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// aria2 选项名，由 DownloadConfig 和 AdvancedConfig 管理
+const (
+	optDir                    = "dir"
+	optMaxConcurrentDownloads = "max-concurrent-downloads"
+	optMaxConnectionPerServer = "max-connection-per-server"
+	optMaxOverallDownload     = "max-overall-download-limit"
+	optMaxOverallUpload       = "max-overall-upload-limit"
+	optUserAgent              = "user-agent"
+	optHTTPProxy              = "http-proxy"
+	optFTPProxy               = "ftp-proxy"
+	optListenPort             = "listen-port"
+	optEnableDHT              = "enable-dht"
+	optEnablePeerExchange     = "enable-peer-exchange"
+	optFollowTorrent          = "follow-torrent"
+)
+
+// managedOptions 由 DownloadConfig 和 AdvancedConfig 管理的选项，按生成 aria2.conf 时的顺序排列
+// 其余选项原样保存在 AdvancedConfig.ExtraOptions 中
+var managedOptions = []string{
+	optDir,
+	optMaxConcurrentDownloads,
+	optMaxConnectionPerServer,
+	optMaxOverallDownload,
+	optMaxOverallUpload,
+	optUserAgent,
+	optHTTPProxy,
+	optFTPProxy,
+	optListenPort,
+	optEnableDHT,
+	optEnablePeerExchange,
+	optFollowTorrent,
+}
+
+// aria2Defaults aria2 在 aria2.conf 中没有这些选项时使用的默认值
+// 导入时没有出现的选项按默认值设置，生成时与默认值相同的选项不写出
+var aria2Defaults = map[string]string{
+	optMaxConcurrentDownloads: "5",
+	optMaxConnectionPerServer: "1",
+	optMaxOverallDownload:     "0",
+	optMaxOverallUpload:       "0",
+	optListenPort:             "6881-6999",
+	optEnableDHT:              "true",
+	optEnablePeerExchange:     "true",
+	optFollowTorrent:          "true",
+}
+
+// isManagedOption 判断选项是否由配置管理
+func isManagedOption(key string) bool {
+	for _, managed := range managedOptions {
+		if key == managed {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAria2Conf 解析 aria2.conf，按出现顺序返回所有选项
+// 空行和 # 开头的注释被忽略；同一选项可以出现多次（如 header），全部保留
+func ParseAria2Conf(r io.Reader) ([]Aria2Option, error) {
+	var options []Aria2Option
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("aria2.conf 第 %d 行格式错误: %q", lineNo, line)
+		}
+		options = append(options, Aria2Option{Key: key, Value: strings.TrimSpace(value)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return options, nil
+}
+
+// Aria2Options 根据下载和高级设置生成写入 aria2.conf 的选项
+// 导入的选项按原顺序输出：由配置管理的选项未修改时保留原值（如 1500、mem）和重复的行，
+// 修改过的选项在第一次出现的位置输出新值；导入时没有的选项只在与 aria2 默认值不同时追加在最后
+func (c *Config) Aria2Options() []Aria2Option {
+	return c.aria2Options(false)
+}
+
+// aria2Options 生成 aria2 选项，withDefaults 为 true 时同时输出与 aria2 默认值相同的选项
+func (c *Config) aria2Options(withDefaults bool) []Aria2Option {
+	// 同一选项出现多次时 aria2 使用最后一次的值
+	imported := make(map[string]string)
+	for _, option := range c.Advanced.ExtraOptions {
+		if isManagedOption(option.Key) {
+			imported[option.Key] = option.Value
+		}
+	}
+
+	var options []Aria2Option
+	written := make(map[string]bool)
+	for _, option := range c.Advanced.ExtraOptions {
+		if !isManagedOption(option.Key) {
+			options = append(options, option)
+			continue
+		}
+
+		value, ok := c.managedOption(option.Key)
+		if ok && sameOptionValue(option.Key, imported[option.Key], value) {
+			options = append(options, option)
+			continue
+		}
+		if ok && !written[option.Key] {
+			options = append(options, Aria2Option{Key: option.Key, Value: value})
+		}
+		written[option.Key] = true
+	}
+
+	for _, key := range managedOptions {
+		if _, ok := imported[key]; ok {
+			continue
+		}
+		value, ok := c.managedOption(key)
+		if !ok || !withDefaults && aria2Defaults[key] == value {
+			continue
+		}
+		options = append(options, Aria2Option{Key: key, Value: value})
+	}
+
+	return options
+}
+
+// managedOption 返回由配置管理的选项的当前值，ok 为 false 表示设置为空，不输出该选项
+func (c *Config) managedOption(key string) (value string, ok bool) {
+	download := c.Download
+	advanced := c.Advanced
+	switch key {
+	case optDir:
+		return download.DefaultDirectory, download.DefaultDirectory != ""
+	case optMaxConcurrentDownloads:
+		return strconv.Itoa(download.MaxConcurrentDownloads), download.MaxConcurrentDownloads > 0
+	case optMaxConnectionPerServer:
+		return strconv.Itoa(download.MaxConnectionPerServer), download.MaxConnectionPerServer > 0
+	case optMaxOverallDownload:
+		return formatSpeedLimit(download.GlobalSpeedLimit), true
+	case optMaxOverallUpload:
+		return formatSpeedLimit(download.UploadSpeedLimit), true
+	case optUserAgent:
+		return advanced.UserAgent, advanced.UserAgent != ""
+	case optHTTPProxy:
+		return advanced.HTTProxy, advanced.HTTProxy != ""
+	case optFTPProxy:
+		return advanced.FTPProxy, advanced.FTPProxy != ""
+	case optListenPort:
+		return advanced.BTPortRange, advanced.BTPortRange != ""
+	case optEnableDHT:
+		return strconv.FormatBool(advanced.DHTEnabled), true
+	case optEnablePeerExchange:
+		return strconv.FormatBool(advanced.PEXEnabled), true
+	case optFollowTorrent:
+		return strconv.FormatBool(advanced.SeedDownload), true
+	}
+	return "", false
+}
+
+// sameOptionValue 判断导入的原值 raw 读入配置后是否仍等于 value，即该选项没有被修改
+func sameOptionValue(key, raw, value string) bool {
+	var probe Config
+	if err := probe.importOption(Aria2Option{Key: key, Value: raw}); err != nil {
+		return false
+	}
+	current, ok := probe.managedOption(key)
+	return ok && current == value
+}
+
+// Aria2OptionValues 以选项名为键返回包括默认值在内的全部选项，便于比较和通过 RPC 提交
+// 同名选项（如 header）的值以换行连接，与 aria2 RPC 中多值选项的格式一致；
+// 由配置管理的选项只取最后一次的值
+func (c *Config) Aria2OptionValues() map[string]string {
+	values := make(map[string]string)
+	for _, option := range c.aria2Options(true) {
+		if value, ok := values[option.Key]; ok && !isManagedOption(option.Key) {
+			values[option.Key] = value + "\n" + option.Value
+		} else {
+			values[option.Key] = option.Value
+		}
+	}
+	return values
+}
+
+// WriteAria2Conf 将 Aria2Options 写成 aria2.conf 格式
+func (c *Config) WriteAria2Conf(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString("# 由 aria2GoUI 根据下载和高级设置生成\n")
+	for _, option := range c.Aria2Options() {
+		if strings.ContainsAny(option.Value, "\r\n") {
+			return fmt.Errorf("选项 %s 的值不能包含换行", option.Key)
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", option.Key, option.Value)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// SaveAria2Conf 生成 aria2.conf 并写入 path
+func (c *Config) SaveAria2Conf(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := c.WriteAria2Conf(&buf); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// ImportAria2Options 将 aria2 选项读入下载和高级设置
+// 由配置管理的选项更新对应字段，没有出现的按 aria2 的默认值设置；
+// 全部选项按原顺序保存在 ExtraOptions 中，生成时保留未修改的原值和位置；
+// 选项值无法解析时返回错误，配置保持不变
+func (c *Config) ImportAria2Options(options []Aria2Option) error {
+	var imported Config
+	for _, key := range managedOptions {
+		if value, ok := aria2Defaults[key]; ok {
+			imported.importOption(Aria2Option{Key: key, Value: value})
+		}
+	}
+	for _, option := range options {
+		if err := imported.importOption(option); err != nil {
+			return err
+		}
+	}
+
+	imported.Advanced.ExtraOptions = append([]Aria2Option(nil), options...)
+	c.Download = imported.Download
+	c.Advanced = imported.Advanced
+	return nil
+}
+
+// importOption 将一个由配置管理的选项读入对应字段，其他选项被忽略
+func (c *Config) importOption(option Aria2Option) error {
+	download := &c.Download
+	advanced := &c.Advanced
+
+	var err error
+	switch option.Key {
+	case optDir:
+		download.DefaultDirectory = option.Value
+	case optMaxConcurrentDownloads:
+		download.MaxConcurrentDownloads, err = strconv.Atoi(option.Value)
+	case optMaxConnectionPerServer:
+		download.MaxConnectionPerServer, err = strconv.Atoi(option.Value)
+	case optMaxOverallDownload:
+		download.GlobalSpeedLimit, err = parseSpeedLimit(option.Value)
+	case optMaxOverallUpload:
+		download.UploadSpeedLimit, err = parseSpeedLimit(option.Value)
+	case optUserAgent:
+		advanced.UserAgent = option.Value
+	case optHTTPProxy:
+		advanced.HTTProxy = option.Value
+	case optFTPProxy:
+		advanced.FTPProxy = option.Value
+	case optListenPort:
+		advanced.BTPortRange = option.Value
+	case optEnableDHT:
+		advanced.DHTEnabled, err = strconv.ParseBool(option.Value)
+	case optEnablePeerExchange:
+		advanced.PEXEnabled, err = strconv.ParseBool(option.Value)
+	case optFollowTorrent:
+		// mem 表示只在内存中处理种子，同样会自动下载
+		advanced.SeedDownload = option.Value != "false"
+	}
+	if err != nil {
+		return fmt.Errorf("选项 %s 的值 %q 无效", option.Key, option.Value)
+	}
+	return nil
+}
+
+// LoadAria2Conf 从 aria2.conf 文件导入下载和高级设置
+func (c *Config) LoadAria2Conf(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	options, err := ParseAria2Conf(file)
+	if err != nil {
+		return err
+	}
+	return c.ImportAria2Options(options)
+}
+
+// SplitConfPath 从本地 aria2c 的额外参数中取出 --conf-path 指定的文件，返回该文件和其余参数
+// aria2c 只读取一个配置文件，用户的文件代替由下载和高级设置生成的内容，与 RPC 密钥一起写入托管的配置文件
+func (d DaemonConfig) SplitConfPath() (string, []string) {
+	var confPath string
+	rest := make([]string, 0, len(d.Args))
+	for i := 0; i < len(d.Args); i++ {
+		switch {
+		case d.Args[i] == "--conf-path" && i+1 < len(d.Args):
+			i++
+			confPath = d.Args[i]
+		case strings.HasPrefix(d.Args[i], "--conf-path="):
+			confPath = strings.TrimPrefix(d.Args[i], "--conf-path=")
+		default:
+			rest = append(rest, d.Args[i])
+		}
+	}
+	return confPath, rest
+}
+
+// UsesGeneratedConf 当前服务器是否为本次运行托管的本地 aria2c，且下次启动时读取由下载和高级设置生成的 aria2.conf
+// 只在启动时读取的选项只有这种情况下才会在重启后生效
+func (c *Config) UsesGeneratedConf() bool {
+	if c.local == nil || c.ActiveProfile != LocalProfileName || !c.Daemon.Enabled {
+		return false
+	}
+	userConf, _ := c.Daemon.SplitConfPath()
+	return userConf == ""
+}
+
+// formatSpeedLimit 将 KB/s 转换为 aria2 的速度格式，0 表示不限速
+func formatSpeedLimit(kbps int) string {
+	if kbps <= 0 {
+		return "0"
+	}
+	return strconv.Itoa(kbps) + "K"
+}
+
+// parseSpeedLimit 解析 aria2 的速度格式（字节数，可带 K 或 M 后缀），返回 KB/s
+func parseSpeedLimit(value string) (int, error) {
+	multiplier := int64(1)
+	number := value
+	switch {
+	case strings.HasSuffix(value, "K"), strings.HasSuffix(value, "k"):
+		multiplier = 1024
+		number = value[:len(value)-1]
+	case strings.HasSuffix(value, "M"), strings.HasSuffix(value, "m"):
+		multiplier = 1024 * 1024
+		number = value[:len(value)-1]
+	}
+
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("无效的速度 %q", value)
+	}
+
+	total := n * multiplier
+	kbps := total / 1024
+	// 不足 1K 的限速按 1K 处理，避免变成不限速
+	if total > 0 && kbps == 0 {
+		kbps = 1
+	}
+	return int(kbps), nil
+}
@@ -0,0 +1,231 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// roundTrip 解析 src 并导入默认配置，返回导入后的配置
+func roundTrip(t *testing.T, src string) *Config {
+	t.Helper()
+
+	options, err := ParseAria2Conf(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParseAria2Conf: %v", err)
+	}
+	cfg := DefaultConfig()
+	if err := cfg.ImportAria2Options(options); err != nil {
+		t.Fatalf("ImportAria2Options: %v", err)
+	}
+	return cfg
+}
+
+// writeOptions 生成 aria2.conf 并重新解析
+func writeOptions(t *testing.T, cfg *Config) []Aria2Option {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := cfg.WriteAria2Conf(&buf); err != nil {
+		t.Fatalf("WriteAria2Conf: %v", err)
+	}
+	options, err := ParseAria2Conf(&buf)
+	if err != nil {
+		t.Fatalf("ParseAria2Conf: %v", err)
+	}
+	return options
+}
+
+func TestAria2ConfRoundTripUnchanged(t *testing.T) {
+	tests := map[string]string{
+		"order": "continue=true\n" +
+			"max-overall-download-limit=2M\n" +
+			"dir=/downloads\n" +
+			"header=X-A: 1\n" +
+			"max-concurrent-downloads=3\n" +
+			"header=X-B: 2\n",
+		"byte speed limit":     "max-overall-download-limit=1500\nmax-overall-upload-limit=100k\n",
+		"follow-torrent mem":   "follow-torrent=mem\n",
+		"repeated managed key": "dir=/a\nsplit=4\ndir=/b\n",
+		"missing defaults":     "dir=/downloads\nrpc-listen-all=true\n",
+		"explicit defaults": "enable-dht=true\n" +
+			"enable-peer-exchange=true\n" +
+			"follow-torrent=true\n" +
+			"max-overall-download-limit=0\n",
+		"empty": "",
+	}
+
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			want, err := ParseAria2Conf(strings.NewReader(src))
+			if err != nil {
+				t.Fatalf("ParseAria2Conf: %v", err)
+			}
+
+			got := writeOptions(t, roundTrip(t, src))
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed options\n got: %v\nwant: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestAria2ConfRoundTripEdited(t *testing.T) {
+	cfg := roundTrip(t, "dir=/a\nsplit=4\ndir=/b\nmax-overall-download-limit=1500\nfollow-torrent=mem\n")
+
+	// 修改过的选项在第一次出现的位置输出新值，重复的行被合并
+	cfg.Download.DefaultDirectory = "/c"
+	cfg.Download.GlobalSpeedLimit = 100
+	// 导入时没有的选项与 aria2 默认值不同时追加在最后
+	cfg.Advanced.DHTEnabled = false
+
+	got := writeOptions(t, cfg)
+	want := []Aria2Option{
+		{Key: "dir", Value: "/c"},
+		{Key: "split", Value: "4"},
+		{Key: "max-overall-download-limit", Value: "100K"},
+		{Key: "follow-torrent", Value: "mem"},
+		{Key: "enable-dht", Value: "false"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAria2ConfRoundTripCleared(t *testing.T) {
+	cfg := roundTrip(t, "all-proxy=http://proxy:8080\nhttp-proxy=http://proxy:3128\n")
+	cfg.Advanced.HTTProxy = ""
+
+	got := writeOptions(t, cfg)
+	want := []Aria2Option{{Key: "all-proxy", Value: "http://proxy:8080"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestImportAria2OptionsDefaults(t *testing.T) {
+	cfg := roundTrip(t, "dir=/downloads\n")
+
+	if cfg.Download.MaxConcurrentDownloads != 5 || cfg.Download.MaxConnectionPerServer != 1 {
+		t.Errorf("download settings = %+v, want aria2 defaults", cfg.Download)
+	}
+	if cfg.Advanced.UserAgent != "" || !cfg.Advanced.DHTEnabled || !cfg.Advanced.SeedDownload {
+		t.Errorf("advanced settings = %+v, want aria2 defaults", cfg.Advanced)
+	}
+
+	// 通过 RPC 提交时包括与默认值相同的选项
+	values := cfg.Aria2OptionValues()
+	for key, want := range map[string]string{
+		"dir":                        "/downloads",
+		"enable-dht":                 "true",
+		"max-overall-download-limit": "0",
+	} {
+		if values[key] != want {
+			t.Errorf("Aria2OptionValues()[%q] = %q, want %q", key, values[key], want)
+		}
+	}
+}
+
+func TestImportAria2OptionsInvalid(t *testing.T) {
+	cfg := DefaultConfig()
+	before := cfg.Clone()
+
+	err := cfg.ImportAria2Options([]Aria2Option{
+		{Key: "dir", Value: "/downloads"},
+		{Key: "max-concurrent-downloads", Value: "many"},
+	})
+	if err == nil {
+		t.Fatal("ImportAria2Options accepted an invalid number")
+	}
+	if !reflect.DeepEqual(cfg, before) {
+		t.Error("configuration changed after a failed import")
+	}
+}
+
+func TestParseSpeedLimit(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int
+	}{
+		{"0", 0},
+		{"512", 1},
+		{"2048", 2},
+		{"100K", 100},
+		{"100k", 100},
+		{"2M", 2048},
+	}
+	for _, tt := range tests {
+		got, err := parseSpeedLimit(tt.value)
+		if err != nil || got != tt.want {
+			t.Errorf("parseSpeedLimit(%q) = %d, %v, want %d", tt.value, got, err, tt.want)
+		}
+	}
+
+	if _, err := parseSpeedLimit("-1"); err == nil {
+		t.Error(`parseSpeedLimit("-1") succeeded`)
+	}
+}
+
+func TestSplitConfPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantConf string
+		wantRest []string
+	}{
+		{"none", []string{"--log-level=info"}, "", []string{"--log-level=info"}},
+		{"equals", []string{"--log-level=info", "--conf-path=/etc/aria2.conf", "-j5"}, "/etc/aria2.conf", []string{"--log-level=info", "-j5"}},
+		{"separate", []string{"--conf-path", "/etc/aria2.conf", "-j5"}, "/etc/aria2.conf", []string{"-j5"}},
+		// 多次指定时使用最后一个
+		{"repeated", []string{"--conf-path=a.conf", "--conf-path", "b.conf"}, "b.conf", []string{}},
+		// 缺少文件名时保留原参数，由 aria2c 报错
+		{"missing value", []string{"-j5", "--conf-path"}, "", []string{"-j5", "--conf-path"}},
+		{"empty", nil, "", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, rest := DaemonConfig{Args: tt.args}.SplitConfPath()
+			if conf != tt.wantConf {
+				t.Errorf("conf = %q, want %q", conf, tt.wantConf)
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("rest = %q, want %q", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestUsesGeneratedConf(t *testing.T) {
+	tests := []struct {
+		name    string
+		managed bool
+		enabled bool
+		args    []string
+		want    bool
+	}{
+		{"managed", true, true, nil, true},
+		{"user conf", true, true, []string{"--conf-path=/etc/aria2.conf"}, false},
+		// 下次启动时不再运行本地 aria2c
+		{"disabled", true, false, nil, false},
+		{"not managed", false, true, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.normalizeProfiles()
+			if tt.managed {
+				if err := cfg.UseLocalProfile(41234, "secret"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			cfg.Daemon.Enabled = tt.enabled
+			cfg.Daemon.Args = tt.args
+			if got := cfg.UsesGeneratedConf(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,6 +15,14 @@ type Config struct {
 	Advanced AdvancedConfig `json:"advanced"`
 	Display  DisplayConfig  `json:"display"`
 	Notify   NotifyConfig   `json:"notify"`
+	Daemon   DaemonConfig   `json:"daemon"`
+
+	// Profiles 命名的服务器配置，ActiveProfile 为当前使用的配置
+	Profiles      []ServerProfile `json:"profiles"`
+	ActiveProfile string          `json:"active_profile"`
+
+	// local 本次运行中托管的本地 aria2c 的连接设置，不写入配置文件
+	local *localSession
 }
 
 // RPCConfig aria2 RPC 连接配置
@@ -26,6 +34,16 @@ type RPCConfig struct {
 	Protocol     string `json:"protocol"`     // http, https, ws, wss
 	AutoReconnect bool   `json:"auto_reconnect"`
 	Timeout      int    `json:"timeout"`       // 连接超时时间（秒）
+	TLS          TLSConfig `json:"tls"`       // https/wss 连接的 TLS 设置
+}
+
+// TLSConfig https/wss 连接的 TLS 配置，留空时使用系统证书
+type TLSConfig struct {
+	CAFile             string `json:"ca_file"`              // CA 证书（PEM），自签名证书可直接填服务器证书
+	CertFile           string `json:"cert_file"`            // 客户端证书（PEM）
+	KeyFile            string `json:"key_file"`             // 客户端私钥（PEM）
+	ServerName         string `json:"server_name"`          // 校验证书时使用的主机名
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"` // 不校验服务器证书
 }
 
 // UIConfig 界面配置
@@ -63,6 +81,10 @@ type AdvancedConfig struct {
 	DHTEnabled   bool   `json:"dht_enabled"`
 	PEXEnabled   bool   `json:"pex_enabled"`
 	SeedDownload bool   `json:"seed_download"`
+	
+	// ExtraOptions 从 aria2.conf 导入的全部选项，按原顺序保存
+	// 其他选项生成 aria2.conf 时原样写回，由配置管理的选项未修改时保留原值和位置
+	ExtraOptions []Aria2Option `json:"extra_options"`
 }
 
 // DisplayConfig 显示配置
@@ -84,9 +106,19 @@ type NotifyConfig struct {
 	CompleteNotify bool `json:"complete_notify"`
 }
 
+// DaemonConfig 由 aria2GoUI 启动和管理的本地 aria2c
+// 启用后每次启动时以随机端口和密钥运行 aria2c，并使用 LocalProfileName 服务器配置连接
+type DaemonConfig struct {
+	Enabled bool     `json:"enabled"`
+	Binary  string   `json:"binary"`   // aria2c 路径，为空时自动查找
+	Port    int      `json:"port"`     // RPC 端口，0 表示自动选择
+	Args    []string `json:"args"`     // 追加的 aria2c 命令行参数
+	LogFile string   `json:"log_file"` // aria2c 输出日志，为空时写到配置目录下的 aria2c.log
+}
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
-	return &Config{
+	config := &Config{
 		RPC: RPCConfig{
 			Host:         "localhost",
 			Port:         6800,
@@ -141,6 +173,8 @@ func DefaultConfig() *Config {
 			CompleteNotify: true,
 		},
 	}
+	config.normalizeProfiles()
+	return config
 }
 
 // LoadConfig 从文件加载配置
@@ -159,6 +193,7 @@ func LoadConfig(path string) (*Config, error) {
 	if err := json.Unmarshal(data, &config); err != nil {
 		return nil, err
 	}
+	config.normalizeProfiles()
 
 	return &config, nil
 }
@@ -171,7 +206,10 @@ func (c *Config) SaveConfig(path string) error {
 		return err
 	}
 
-	data, err := json.MarshalIndent(c, "", "  ")
+	// 设置界面修改的连接设置也要保存到当前服务器配置
+	c.SyncActiveProfile()
+
+	data, err := json.MarshalIndent(c.persisted(), "", "  ")
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// PathMapping 服务器上的目录与本机上对应目录的映射
+// 如 aria2 运行在 NAS 或 Docker 中，服务器上的 /downloads 在本机挂载为 /mnt/nas/downloads
+type PathMapping struct {
+	Remote string `json:"remote"`
+	Local  string `json:"local"`
+}
+
+// ParsePathMappings 解析路径映射，每行一条，格式为“服务器路径 => 本机路径”，空行被忽略
+func ParsePathMappings(text string) ([]PathMapping, error) {
+	var mappings []PathMapping
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		remote, local, ok := strings.Cut(line, "=>")
+		remote = strings.TrimSpace(remote)
+		local = strings.TrimSpace(local)
+		if !ok || remote == "" || local == "" {
+			return nil, fmt.Errorf("路径映射第 %d 行格式错误，应为“服务器路径 => 本机路径”", i+1)
+		}
+		if !filepath.IsAbs(local) {
+			return nil, fmt.Errorf("路径映射第 %d 行的本机路径必须是绝对路径", i+1)
+		}
+		mappings = append(mappings, PathMapping{Remote: remote, Local: local})
+	}
+	return mappings, nil
+}
+
+// FormatPathMappings 将路径映射格式化为 ParsePathMappings 接受的文本
+func FormatPathMappings(mappings []PathMapping) string {
+	lines := make([]string, len(mappings))
+	for i, mapping := range mappings {
+		lines[i] = mapping.Remote + " => " + mapping.Local
+	}
+	return strings.Join(lines, "\n")
+}
+
+// IsLocal 判断服务器是否运行在本机
+func (p *ServerProfile) IsLocal() bool {
+	switch strings.ToLower(strings.Trim(p.Host, "[]")) {
+	case "", "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// LocalPath 将 aria2 返回的服务器路径转换为本机路径
+// 使用最长匹配的路径映射；没有匹配的映射时，本机服务器原样返回，远程服务器返回 false
+func (p *ServerProfile) LocalPath(remote string) (string, bool) {
+	best := -1
+	bestLen := 0
+	for i, mapping := range p.PathMappings {
+		prefix := normalizeRemotePath(mapping.Remote)
+		if !hasPathPrefix(normalizeRemotePath(remote), prefix) {
+			continue
+		}
+		if best < 0 || len(prefix) > bestLen {
+			best = i
+			bestLen = len(prefix)
+		}
+	}
+
+	if best < 0 {
+		if p.IsLocal() {
+			return remote, true
+		}
+		return "", false
+	}
+
+	rest := strings.TrimPrefix(normalizeRemotePath(remote), normalizeRemotePath(p.PathMappings[best].Remote))
+	rest = strings.TrimPrefix(rest, "/")
+	return filepath.Join(p.PathMappings[best].Local, filepath.FromSlash(rest)), true
+}
+
+// normalizeRemotePath 统一服务器路径的分隔符并清理 .. 等路径段
+// Windows 上的 aria2 返回反斜杠分隔的路径，按盘符判断
+func normalizeRemotePath(remote string) string {
+	if len(remote) >= 2 && remote[1] == ':' {
+		remote = strings.ReplaceAll(remote, `\`, "/")
+	}
+	return path.Clean(remote)
+}
+
+// hasPathPrefix 判断 remote 是否为 prefix 或位于 prefix 目录中，按完整的路径段比较
+func hasPathPrefix(remote, prefix string) bool {
+	if remote == prefix || prefix == "/" && strings.HasPrefix(remote, "/") {
+		return true
+	}
+	return strings.HasPrefix(remote, prefix+"/")
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLocalPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("local paths in this test are Unix paths")
+	}
+
+	nas := ServerProfile{Host: "nas.lan", PathMappings: []PathMapping{
+		{Remote: "/downloads", Local: "/mnt/nas/downloads"},
+		{Remote: "/downloads/tv/", Local: "/mnt/tv"},
+		{Remote: `D:\Downloads`, Local: "/mnt/d"},
+	}}
+	local := ServerProfile{Host: "localhost", PathMappings: []PathMapping{
+		{Remote: "/data", Local: "/home/user/data"},
+	}}
+	root := ServerProfile{Host: "nas.lan", PathMappings: []PathMapping{
+		{Remote: "/", Local: "/mnt/root"},
+	}}
+
+	tests := []struct {
+		name    string
+		profile ServerProfile
+		remote  string
+		want    string
+		wantOK  bool
+	}{
+		{"in mapping", nas, "/downloads/a.iso", "/mnt/nas/downloads/a.iso", true},
+		{"mapping itself", nas, "/downloads", "/mnt/nas/downloads", true},
+		{"trailing slash", nas, "/downloads/dir/", "/mnt/nas/downloads/dir", true},
+		// 按完整的路径段比较，/downloads 不匹配 /downloads2
+		{"prefix boundary", nas, "/downloads2/a.iso", "", false},
+		{"longest match", nas, "/downloads/tv/show.mkv", "/mnt/tv/show.mkv", true},
+		{"mapping with trailing slash", nas, "/downloads/tv", "/mnt/tv", true},
+		// 清理 .. 后不在映射中
+		{"dot dot", nas, "/downloads/../etc/passwd", "", false},
+		{"dot dot inside", nas, "/downloads/tv/../a.iso", "/mnt/nas/downloads/a.iso", true},
+		{"unmapped remote", nas, "/other/a.iso", "", false},
+		{"relative", nas, "downloads/a.iso", "", false},
+		// Windows 上的 aria2 返回反斜杠分隔的路径
+		{"windows separators", nas, `D:\Downloads\sub\a.iso`, "/mnt/d/sub/a.iso", true},
+		{"windows mixed separators", nas, `D:/Downloads\a.iso`, "/mnt/d/a.iso", true},
+		{"windows prefix boundary", nas, `D:\Downloads2\a.iso`, "", false},
+		{"root mapping", root, "/srv/a.iso", "/mnt/root/srv/a.iso", true},
+		// 本机服务器没有匹配的映射时原样返回
+		{"local unmapped", local, "/tmp/a.iso", "/tmp/a.iso", true},
+		{"local mapped", local, "/data/a.iso", "/home/user/data/a.iso", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.profile.LocalPath(tt.remote)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("LocalPath(%q) = %q, %v, want %q, %v", tt.remote, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParsePathMappings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("local paths in this test are Unix paths")
+	}
+
+	mappings, err := ParsePathMappings("\n  /downloads => /mnt/nas/downloads  \n\nD:\\Downloads=>/mnt/d\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PathMapping{
+		{Remote: "/downloads", Local: "/mnt/nas/downloads"},
+		{Remote: `D:\Downloads`, Local: "/mnt/d"},
+	}
+	if !reflect.DeepEqual(mappings, want) {
+		t.Errorf("got %+v, want %+v", mappings, want)
+	}
+	if text := FormatPathMappings(mappings); text != "/downloads => /mnt/nas/downloads\nD:\\Downloads => /mnt/d" {
+		t.Errorf("FormatPathMappings = %q", text)
+	}
+
+	invalid := []struct {
+		text string
+		line string
+	}{
+		{"/downloads /mnt/nas", "第 1 行"},
+		{"/downloads =>", "第 1 行"},
+		{" => /mnt/nas", "第 1 行"},
+		{"/a => /mnt/a\n\n/downloads => mnt/nas", "第 3 行"},
+		{"/downloads -> /mnt/nas", "第 1 行"},
+	}
+	for _, tt := range invalid {
+		mappings, err := ParsePathMappings(tt.text)
+		if err == nil || mappings != nil {
+			t.Errorf("%q: got %+v, %v, want an error", tt.text, mappings, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.line) {
+			t.Errorf("%q: error %q does not name %s", tt.text, err, tt.line)
+		}
+	}
+}
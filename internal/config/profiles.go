@@ -0,0 +1,241 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultProfileName 旧配置文件迁移时使用的服务器配置名称
+const DefaultProfileName = "默认"
+
+// LocalProfileName 托管的本地 aria2c 使用的服务器配置名称
+const LocalProfileName = "本地 aria2c"
+
+// ServerProfile 命名的 aria2 服务器连接配置
+// 当前使用的配置会复制到 RPCConfig，自动重连和超时等设置由所有服务器共用
+type ServerProfile struct {
+	Name        string    `json:"name"`
+	Host        string    `json:"host"`
+	Port        int       `json:"port"`
+	Token       string    `json:"token"`
+	Path        string    `json:"path"`
+	Protocol    string    `json:"protocol"` // http, https, ws, wss
+	TLS         TLSConfig `json:"tls"`
+	DownloadDir string    `json:"download_dir"` // 该服务器的默认下载目录，为空时使用下载设置中的目录
+	// PathMappings 服务器路径到本机路径的映射，删除任务文件时用来找到本机上的文件
+	PathMappings []PathMapping `json:"path_mappings"`
+}
+
+// normalizeProfiles 保证至少有一个服务器配置且当前配置存在
+// 没有服务器配置的旧配置文件以 RPC 设置创建默认配置
+func (c *Config) normalizeProfiles() {
+	if len(c.Profiles) == 0 {
+		profile := ServerProfile{Name: DefaultProfileName}
+		profile.setEndpoint(c.RPC)
+		c.Profiles = []ServerProfile{profile}
+		c.ActiveProfile = profile.Name
+		return
+	}
+
+	if c.FindProfile(c.ActiveProfile) == nil {
+		c.ActiveProfile = c.Profiles[0].Name
+		c.Profiles[0].applyEndpoint(&c.RPC)
+	}
+}
+
+// FindProfile 按名称查找服务器配置，不存在时返回 nil
+func (c *Config) FindProfile(name string) *ServerProfile {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			return &c.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// ProfileNames 返回所有服务器配置的名称
+func (c *Config) ProfileNames() []string {
+	names := make([]string, len(c.Profiles))
+	for i, profile := range c.Profiles {
+		names[i] = profile.Name
+	}
+	return names
+}
+
+// SyncActiveProfile 将 RPC 设置写回当前服务器配置
+// 设置界面修改的是 RPCConfig，切换和保存前需要同步
+func (c *Config) SyncActiveProfile() {
+	if profile := c.FindProfile(c.ActiveProfile); profile != nil {
+		profile.setEndpoint(c.RPC)
+	}
+}
+
+// UseProfile 切换到指定的服务器配置，并将其连接设置复制到 RPCConfig
+func (c *Config) UseProfile(name string) error {
+	profile := c.FindProfile(name)
+	if profile == nil {
+		return fmt.Errorf("服务器配置 %q 不存在", name)
+	}
+
+	c.SyncActiveProfile()
+	profile.applyEndpoint(&c.RPC)
+	c.ActiveProfile = name
+	return nil
+}
+
+// AddProfile 添加服务器配置，名称不能为空或重复
+func (c *Config) AddProfile(profile ServerProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return errors.New("服务器配置名称不能为空")
+	}
+	if c.FindProfile(profile.Name) != nil {
+		return fmt.Errorf("服务器配置 %q 已存在", profile.Name)
+	}
+
+	c.Profiles = append(c.Profiles, profile)
+	return nil
+}
+
+// UpdateProfile 按名称替换已有的服务器配置
+// 修改的是当前使用的配置时同时更新 RPCConfig，调用方需要重新连接
+func (c *Config) UpdateProfile(profile ServerProfile) error {
+	existing := c.FindProfile(profile.Name)
+	if existing == nil {
+		return fmt.Errorf("服务器配置 %q 不存在", profile.Name)
+	}
+
+	*existing = profile
+	if profile.Name == c.ActiveProfile {
+		existing.applyEndpoint(&c.RPC)
+	}
+	return nil
+}
+
+// RemoveProfile 删除服务器配置，当前使用的配置不能删除
+func (c *Config) RemoveProfile(name string) error {
+	if name == c.ActiveProfile {
+		return fmt.Errorf("不能删除正在使用的服务器配置 %q", name)
+	}
+
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			c.Profiles = append(c.Profiles[:i], c.Profiles[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("服务器配置 %q 不存在", name)
+}
+
+// localSession 记录切换到托管的本地 aria2c 之前的状态
+// 本地 aria2c 每次启动的端口和密钥不同，保存配置时写回这里记录的状态
+type localSession struct {
+	// previous 切换前使用的服务器配置
+	previous string
+	// saved 配置文件中原有的本地配置，原来没有时为 nil
+	saved *ServerProfile
+}
+
+// UseLocalProfile 将托管的本地 aria2c 的端口和密钥写入 LocalProfileName 配置并切换过去
+// 该配置不存在时创建，已有的下载目录设置保留；端口和密钥只保存在内存中，
+// 配置文件中仍是原来的连接设置和服务器配置，本地 aria2c 未启动时下次打开不会连接到过期的端口
+func (c *Config) UseLocalProfile(port int, secret string) error {
+	if c.local == nil {
+		session := &localSession{}
+		if c.ActiveProfile != LocalProfileName {
+			session.previous = c.ActiveProfile
+		}
+		if profile := c.FindProfile(LocalProfileName); profile != nil {
+			saved := *profile
+			session.saved = &saved
+		}
+		c.local = session
+	}
+
+	profile := c.FindProfile(LocalProfileName)
+	if profile == nil {
+		if err := c.AddProfile(ServerProfile{Name: LocalProfileName}); err != nil {
+			return err
+		}
+		profile = c.FindProfile(LocalProfileName)
+	}
+
+	profile.Host = "127.0.0.1"
+	profile.Port = port
+	profile.Token = secret
+	profile.Path = "/jsonrpc"
+	profile.Protocol = "http"
+	profile.TLS = TLSConfig{}
+
+	// UseProfile 会先把 RPC 设置同步回当前配置，当前配置就是本地配置时需要先更新 RPC
+	if c.ActiveProfile == LocalProfileName {
+		profile.applyEndpoint(&c.RPC)
+	}
+	return c.UseProfile(LocalProfileName)
+}
+
+// persisted 返回写入配置文件的配置
+// 使用托管的本地 aria2c 时，本地配置的连接设置恢复为配置文件中原有的值（原来没有时不保存该配置），
+// 当前服务器恢复为切换前的配置
+func (c *Config) persisted() *Config {
+	if c.local == nil {
+		return c
+	}
+
+	saved := *c
+	saved.Profiles = make([]ServerProfile, 0, len(c.Profiles))
+	for _, profile := range c.Profiles {
+		if profile.Name == LocalProfileName {
+			if c.local.saved == nil {
+				continue
+			}
+			var endpoint RPCConfig
+			c.local.saved.applyEndpoint(&endpoint)
+			profile.setEndpoint(endpoint)
+		}
+		saved.Profiles = append(saved.Profiles, profile)
+	}
+
+	if saved.ActiveProfile == LocalProfileName {
+		saved.ActiveProfile = c.local.previous
+		profile := saved.FindProfile(saved.ActiveProfile)
+		if profile == nil {
+			// 切换前的配置已被删除，加载时由 normalizeProfiles 选择第一个配置
+			saved.ActiveProfile = ""
+			profile = &ServerProfile{}
+			profile.setEndpoint(DefaultConfig().RPC)
+		}
+		profile.applyEndpoint(&saved.RPC)
+	}
+	return &saved
+}
+
+// DownloadDir 当前服务器的默认下载目录
+// 服务器配置未设置时使用下载设置中的默认目录
+func (c *Config) DownloadDir() string {
+	if profile := c.FindProfile(c.ActiveProfile); profile != nil && profile.DownloadDir != "" {
+		return profile.DownloadDir
+	}
+	return c.Download.DefaultDirectory
+}
+
+// setEndpoint 从 RPCConfig 复制连接设置
+func (p *ServerProfile) setEndpoint(rpc RPCConfig) {
+	p.Host = rpc.Host
+	p.Port = rpc.Port
+	p.Token = rpc.Token
+	p.Path = rpc.Path
+	p.Protocol = rpc.Protocol
+	p.TLS = rpc.TLS
+}
+
+// applyEndpoint 将连接设置复制到 RPCConfig，保留共用的重连和超时设置
+func (p *ServerProfile) applyEndpoint(rpc *RPCConfig) {
+	rpc.Host = p.Host
+	rpc.Port = p.Port
+	rpc.Token = p.Token
+	rpc.Path = p.Path
+	rpc.Protocol = p.Protocol
+	rpc.TLS = p.TLS
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestUseLocalProfileNotPersisted(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.normalizeProfiles()
+	if err := cfg.AddProfile(ServerProfile{Name: "NAS", Host: "nas.lan", Port: 6800, Token: "nas"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.UseProfile("NAS"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.UseLocalProfile(41234, "per-launch"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ActiveProfile != LocalProfileName || cfg.RPC.Port != 41234 || cfg.RPC.Token != "per-launch" {
+		t.Fatalf("active %q at port %d, want the local profile", cfg.ActiveProfile, cfg.RPC.Port)
+	}
+
+	// 本次运行中对本地配置的其他修改照常保存
+	cfg.FindProfile(LocalProfileName).DownloadDir = "/downloads"
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := cfg.SaveConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ActiveProfile != LocalProfileName || cfg.RPC.Token != "per-launch" {
+		t.Error("SaveConfig changed the configuration in memory")
+	}
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ActiveProfile != "NAS" || loaded.RPC.Host != "nas.lan" || loaded.RPC.Token != "nas" {
+		t.Errorf("saved active profile %q with %+v, want NAS", loaded.ActiveProfile, loaded.RPC)
+	}
+	if loaded.FindProfile(LocalProfileName) != nil {
+		t.Error("local profile created for this launch was saved")
+	}
+}
+
+func TestUseLocalProfileKeepsSavedEndpoint(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.normalizeProfiles()
+	if err := cfg.AddProfile(ServerProfile{Name: LocalProfileName, Host: "127.0.0.1", Port: 6800, DownloadDir: "/old"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cfg.UseLocalProfile(41234, "per-launch"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.FindProfile(LocalProfileName).DownloadDir = "/new"
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := cfg.SaveConfig(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	profile := loaded.FindProfile(LocalProfileName)
+	if profile == nil {
+		t.Fatal("existing local profile was not saved")
+	}
+	if profile.Port != 6800 || profile.Token != "" || profile.DownloadDir != "/new" {
+		t.Errorf("saved local profile %+v, want the original endpoint and the new download directory", profile)
+	}
+	if loaded.ActiveProfile != DefaultProfileName {
+		t.Errorf("saved active profile %q, want %q", loaded.ActiveProfile, DefaultProfileName)
+	}
+}
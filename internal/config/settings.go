@@ -0,0 +1,27 @@
+package config
+
+// Clone 返回配置的深拷贝
+// 设置窗口编辑拷贝，点击确定后才通过 ApplySettings 写回，取消时直接丢弃
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.Advanced.ExtraOptions = append([]Aria2Option(nil), c.Advanced.ExtraOptions...)
+	clone.Daemon.Args = append([]string(nil), c.Daemon.Args...)
+
+	clone.Profiles = make([]ServerProfile, len(c.Profiles))
+	for i, profile := range c.Profiles {
+		profile.PathMappings = append([]PathMapping(nil), profile.PathMappings...)
+		clone.Profiles[i] = profile
+	}
+	return &clone
+}
+
+// ApplySettings 将设置窗口编辑的连接、刷新间隔、下载、高级和本地 aria2c 设置写回配置
+// 服务器配置在单独的窗口中管理，不随设置窗口写回，避免覆盖期间对服务器配置的修改
+func (c *Config) ApplySettings(draft *Config) {
+	settings := draft.Clone()
+	c.RPC = settings.RPC
+	c.UI.RefreshInterval = settings.UI.RefreshInterval
+	c.Download = settings.Download
+	c.Advanced = settings.Advanced
+	c.Daemon = settings.Daemon
+}
@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// ErrBinaryNotFound 找不到 aria2c
+var ErrBinaryNotFound = errors.New("daemon: aria2c not found")
+
+// binaryName 当前平台上 aria2c 可执行文件的名称
+func binaryName() string {
+	if runtime.GOOS == "windows" {
+		return "aria2c.exe"
+	}
+	return "aria2c"
+}
+
+// FindBinary 查找 aria2c
+// binary 不为空时只检查该路径（也可以是 PATH 中的命令名）；
+// 为空时依次查找本程序所在目录、PATH 以及各平台的常见安装位置
+func FindBinary(binary string) (string, error) {
+	if binary != "" {
+		path, err := exec.LookPath(binary)
+		if err != nil {
+			return "", fmt.Errorf("daemon: aria2c %s: %w", binary, err)
+		}
+		return path, nil
+	}
+
+	name := binaryName()
+
+	// 与本程序一起分发的 aria2c 优先
+	if executable, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(executable), name)
+		if isExecutable(candidate) {
+			return candidate, nil
+		}
+	}
+
+	if path, err := exec.LookPath(name); err == nil {
+		return path, nil
+	}
+
+	for _, candidate := range commonLocations() {
+		if isExecutable(candidate) {
+			return candidate, nil
+		}
+	}
+
+	return "", ErrBinaryNotFound
+}
+
+// commonLocations 返回 PATH 之外常见的 aria2c 安装位置
+// 从桌面启动时 PATH 往往不包含 Homebrew 等目录
+func commonLocations() []string {
+	switch runtime.GOOS {
+	case "windows":
+		var locations []string
+		for _, dir := range []string{os.Getenv("ProgramFiles"), os.Getenv("ProgramFiles(x86)"), os.Getenv("LOCALAPPDATA")} {
+			if dir != "" {
+				locations = append(locations, filepath.Join(dir, "aria2", "aria2c.exe"))
+			}
+		}
+		return locations
+	case "darwin":
+		return []string{"/opt/homebrew/bin/aria2c", "/usr/local/bin/aria2c", "/opt/local/bin/aria2c"}
+	default:
+		return []string{"/usr/local/bin/aria2c", "/usr/bin/aria2c", "/snap/bin/aria2c"}
+	}
+}
+
+// isExecutable 判断 path 是否为可执行的普通文件
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return info.Mode().Perm()&0111 != 0
+}
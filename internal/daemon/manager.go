@@ -0,0 +1,545 @@
+// Package daemon 启动并管理本地 aria2c 进程
+//
+// Manager 以随机生成的 RPC 密钥和端口启动 aria2c，进程意外退出后按指数退避重启，
+// 并把 stdout 和 stderr 写入日志文件。密钥写入只有当前用户可读的配置文件，
+// 不出现在其他用户可以通过 ps 看到的命令行中。aria2c 的路径可以任意指定，
+// 因此可以用一个只监听端口或直接退出的桩程序代替真正的 aria2c 来验证管理逻辑：
+//
+//	m, err := daemon.NewManager(daemon.Options{Binary: "/path/to/stub"}, nil)
+//	if err != nil {
+//		return err
+//	}
+//	if err := m.Start(); err != nil {
+//		return err
+//	}
+//	defer m.Stop()
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// State 本地 aria2c 进程的状态
+type State int
+
+const (
+	// StateStopped 未启动或已停止
+	StateStopped State = iota
+	// StateRunning 进程正在运行
+	StateRunning
+	// StateRestarting 进程意外退出，等待重启
+	StateRestarting
+	// StateFailed 连续多次启动后很快退出，已放弃重启
+	StateFailed
+)
+
+// String 返回状态名称
+func (s State) String() string {
+	switch s {
+	case StateStopped:
+		return "stopped"
+	case StateRunning:
+		return "running"
+	case StateRestarting:
+		return "restarting"
+	case StateFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+const (
+	// maxRestarts 连续多少次很快退出后放弃重启
+	maxRestarts = 5
+	// stableRun 进程运行超过该时间后退出不计入连续失败
+	stableRun = time.Minute
+
+	defaultMinBackoff  = time.Second
+	defaultMaxBackoff  = 30 * time.Second
+	defaultStopTimeout = 10 * time.Second
+)
+
+// ErrNotRunning 进程没有在运行
+var ErrNotRunning = errors.New("daemon: aria2c is not running")
+
+// Options 启动 aria2c 的设置
+type Options struct {
+	// Binary aria2c 的路径，为空时通过 FindBinary 查找
+	Binary string
+	// Port RPC 端口，为 0 时选择一个空闲端口
+	Port int
+	// Secret RPC 密钥，为空时随机生成
+	Secret string
+	// Args 追加在生成的 RPC 参数之后的命令行参数，如 --dir
+	// 不能包含 --conf-path，aria2c 只读取一个配置文件，其中的选项通过 Conf 传入
+	Args []string
+	// ConfFile 通过 --conf-path 传给 aria2c 的配置文件，每次启动前以 0600 权限写入 Conf 和 RPC 密钥
+	// 为空时在临时目录中创建，停止后删除
+	ConfFile string
+	// Conf 写入配置文件的其他选项，aria2.conf 格式
+	Conf []byte
+	// LogFile aria2c 的 stdout 和 stderr 追加写入的文件，为空时丢弃输出
+	LogFile string
+	// StopTimeout 停止时等待进程自行退出的时间，超时后强制结束，为 0 时使用 10 秒
+	StopTimeout time.Duration
+}
+
+// Manager 管理一个本地 aria2c 进程
+// 端口和密钥在整个生命周期内保持不变，重启后客户端无需重新配置
+type Manager struct {
+	binary      string
+	port        int
+	secret      string
+	args        []string
+	confFile    string
+	conf        []byte
+	logFile     string
+	stopTimeout time.Duration
+	onState     func(state State, err error)
+
+	minBackoff time.Duration
+	maxBackoff time.Duration
+
+	mu      sync.Mutex
+	state   State
+	lastErr error
+	cmd     *exec.Cmd
+	exited  chan struct{}
+	started bool
+	// tempConf 配置文件是 Start 在临时目录中创建的，停止后删除
+	tempConf bool
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// NewManager 创建进程管理器，解析 aria2c 路径并确定端口和密钥，但不启动进程
+// onState 在状态变化时从管理协程中调用，可以为 nil
+func NewManager(opts Options, onState func(state State, err error)) (*Manager, error) {
+	binary, err := FindBinary(opts.Binary)
+	if err != nil {
+		return nil, err
+	}
+
+	port := opts.Port
+	if port == 0 {
+		if port, err = freePort(); err != nil {
+			return nil, fmt.Errorf("daemon: pick RPC port: %w", err)
+		}
+	}
+
+	secret := opts.Secret
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			return nil, fmt.Errorf("daemon: generate RPC secret: %w", err)
+		}
+	}
+
+	stopTimeout := opts.StopTimeout
+	if stopTimeout <= 0 {
+		stopTimeout = defaultStopTimeout
+	}
+
+	return &Manager{
+		binary:      binary,
+		port:        port,
+		secret:      secret,
+		args:        append([]string(nil), opts.Args...),
+		confFile:    opts.ConfFile,
+		conf:        append([]byte(nil), opts.Conf...),
+		logFile:     opts.LogFile,
+		stopTimeout: stopTimeout,
+		onState:     onState,
+		minBackoff:  defaultMinBackoff,
+		maxBackoff:  defaultMaxBackoff,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}, nil
+}
+
+// Binary 返回使用的 aria2c 路径
+func (m *Manager) Binary() string {
+	return m.binary
+}
+
+// Port 返回 RPC 端口
+func (m *Manager) Port() int {
+	return m.port
+}
+
+// Secret 返回 RPC 密钥
+func (m *Manager) Secret() string {
+	return m.secret
+}
+
+// State 返回当前状态和最近一次退出的原因
+func (m *Manager) State() (State, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state, m.lastErr
+}
+
+// ConfFile 返回传给 aria2c 的配置文件路径，临时文件在 Start 之前为空
+func (m *Manager) ConfFile() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.confFile
+}
+
+// Args 返回启动 aria2c 的完整命令行参数
+// RPC 只监听本机，密钥在配置文件中；--stop-with-process 让 aria2c 在本程序异常退出后也随之退出
+func (m *Manager) Args() []string {
+	args := []string{
+		"--conf-path=" + m.ConfFile(),
+		"--enable-rpc=true",
+		"--rpc-listen-all=false",
+		"--rpc-listen-port=" + strconv.Itoa(m.port),
+		"--stop-with-process=" + strconv.Itoa(os.Getpid()),
+	}
+	return append(args, m.args...)
+}
+
+// Start 启动 aria2c 和管理协程，只能调用一次
+// 进程无法启动时返回错误；启动成功不代表 RPC 已可用，需要时调用 WaitReady
+func (m *Manager) Start() error {
+	m.mu.Lock()
+	if m.started {
+		m.mu.Unlock()
+		return errors.New("daemon: manager already started")
+	}
+	m.started = true
+	if m.confFile == "" {
+		file, err := os.CreateTemp("", "aria2c-*.conf")
+		if err != nil {
+			m.mu.Unlock()
+			close(m.done)
+			return fmt.Errorf("daemon: create config file: %w", err)
+		}
+		file.Close()
+		m.confFile = file.Name()
+		m.tempConf = true
+	}
+	m.mu.Unlock()
+
+	if err := m.launch(); err != nil {
+		m.removeTempConf()
+		close(m.done)
+		return err
+	}
+
+	go m.run()
+	return nil
+}
+
+// WaitReady 等待 RPC 端口开始监听
+// ctx 结束或进程不再运行时返回错误
+func (m *Manager) WaitReady(ctx context.Context) error {
+	address := net.JoinHostPort("127.0.0.1", strconv.Itoa(m.port))
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		conn, err := net.DialTimeout("tcp", address, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+
+		if state, lastErr := m.State(); state == StateStopped || state == StateFailed {
+			if lastErr != nil {
+				return lastErr
+			}
+			return ErrNotRunning
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop 停止 aria2c 并等待管理协程退出，可以重复调用
+// 先请求 aria2c 正常退出（保存会话等），超过 StopTimeout 后强制结束
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	started := m.started
+	m.mu.Unlock()
+	if !started {
+		return
+	}
+
+	m.once.Do(func() {
+		close(m.stop)
+	})
+	<-m.done
+}
+
+// run 管理循环，进程意外退出后按退避时间重启
+func (m *Manager) run() {
+	defer close(m.done)
+	defer m.removeTempConf()
+
+	failures := 0
+	for {
+		m.mu.Lock()
+		cmd, exited := m.cmd, m.exited
+		m.mu.Unlock()
+		startedAt := time.Now()
+
+		select {
+		case <-m.stop:
+			m.terminate(cmd, exited)
+			m.setState(StateStopped, nil)
+			return
+		case <-exited:
+		}
+
+		err := exitError(cmd)
+		if time.Since(startedAt) >= stableRun {
+			failures = 0
+		}
+		failures++
+		if failures > maxRestarts {
+			m.setState(StateFailed, err)
+			return
+		}
+
+		// 进程意外退出，等待后重启；重启失败同样计入连续失败
+		m.setState(StateRestarting, err)
+		for {
+			if !m.wait(m.backoff(failures)) {
+				m.setState(StateStopped, nil)
+				return
+			}
+			if err = m.launch(); err == nil {
+				break
+			}
+			failures++
+			if failures > maxRestarts {
+				m.setState(StateFailed, err)
+				return
+			}
+			m.setState(StateRestarting, err)
+		}
+	}
+}
+
+// launch 写入配置文件并启动一次 aria2c 进程
+func (m *Manager) launch() error {
+	if err := m.writeConf(); err != nil {
+		return err
+	}
+	cmd := exec.Command(m.binary, m.Args()...)
+
+	// 没有设置日志文件时输出重定向到空设备
+	output, err := m.openLog()
+	if err != nil {
+		return err
+	}
+	if output != nil {
+		cmd.Stdout = output
+		cmd.Stderr = output
+	}
+
+	if err := cmd.Start(); err != nil {
+		if output != nil {
+			output.Close()
+		}
+		return fmt.Errorf("daemon: start %s: %w", m.binary, err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		if output != nil {
+			output.Close()
+		}
+		close(exited)
+	}()
+
+	m.mu.Lock()
+	m.cmd = cmd
+	m.exited = exited
+	m.mu.Unlock()
+
+	m.setState(StateRunning, nil)
+	return nil
+}
+
+// managedKeys 由 Manager 控制的选项，写入配置文件时从 Conf 中去掉。
+// daemon=true 会让 aria2c 转入后台后立即退出，Manager 会把它当作崩溃反复重启
+var managedKeys = map[string]bool{
+	"daemon":          true,
+	"enable-rpc":      true,
+	"rpc-listen-all":  true,
+	"rpc-listen-port": true,
+	"rpc-secret":      true,
+}
+
+// managedConf 返回去掉 managedKeys 的配置内容，注释和其他选项保持不变
+func managedConf(conf []byte) []byte {
+	var out []byte
+	for _, line := range bytes.SplitAfter(conf, []byte("\n")) {
+		key, _, found := bytes.Cut(line, []byte("="))
+		if found && managedKeys[string(bytes.TrimSpace(key))] {
+			continue
+		}
+		out = append(out, line...)
+	}
+	return out
+}
+
+// writeConf 写入配置文件，去掉由 Manager 控制的选项，RPC 密钥写在最后
+func (m *Manager) writeConf() error {
+	path := m.ConfFile()
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("daemon: write config file: %w", err)
+	}
+
+	// 文件已存在时 OpenFile 不会修改权限
+	err = file.Chmod(0600)
+	conf := managedConf(m.conf)
+	if err == nil {
+		_, err = file.Write(conf)
+	}
+	if err == nil && len(conf) > 0 && conf[len(conf)-1] != '\n' {
+		_, err = file.WriteString("\n")
+	}
+	if err == nil {
+		_, err = fmt.Fprintf(file, "rpc-secret=%s\n", m.secret)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("daemon: write config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// removeTempConf 删除 Start 创建的临时配置文件
+func (m *Manager) removeTempConf() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.tempConf {
+		os.Remove(m.confFile)
+		m.tempConf = false
+	}
+}
+
+// openLog 打开日志文件并写入本次启动的分隔行，没有设置日志文件时返回 nil
+func (m *Manager) openLog() (*os.File, error) {
+	if m.logFile == "" {
+		return nil, nil
+	}
+
+	file, err := os.OpenFile(m.logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("daemon: open log file: %w", err)
+	}
+	fmt.Fprintf(file, "==== %s 启动 %s ====\n", time.Now().Format(time.RFC3339), m.binary)
+	return file, nil
+}
+
+// terminate 请求进程退出，超时后强制结束
+// Windows 不支持向进程发送 SIGTERM，直接结束进程
+func (m *Manager) terminate(cmd *exec.Cmd, exited chan struct{}) {
+	if runtime.GOOS == "windows" {
+		cmd.Process.Kill()
+	} else {
+		cmd.Process.Signal(syscall.SIGTERM)
+	}
+
+	timer := time.NewTimer(m.stopTimeout)
+	defer timer.Stop()
+	select {
+	case <-exited:
+	case <-timer.C:
+		cmd.Process.Kill()
+		<-exited
+	}
+}
+
+// setState 更新状态，状态改变或有新的错误时通知调用方
+func (m *Manager) setState(state State, err error) {
+	m.mu.Lock()
+	changed := m.state != state || err != nil
+	m.state = state
+	m.lastErr = err
+	m.mu.Unlock()
+
+	if changed && m.onState != nil {
+		m.onState(state, err)
+	}
+}
+
+// wait 等待 d，返回 false 表示已停止
+func (m *Manager) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-m.stop:
+		return false
+	}
+}
+
+// backoff 计算第 failures 次连续失败后的重启等待时间，按指数增长到 maxBackoff
+func (m *Manager) backoff(failures int) time.Duration {
+	d := m.minBackoff
+	for i := 1; i < failures && d < m.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > m.maxBackoff {
+		d = m.maxBackoff
+	}
+	return d
+}
+
+// exitError 返回进程退出的原因
+func exitError(cmd *exec.Cmd) error {
+	if cmd.ProcessState == nil {
+		return errors.New("daemon: aria2c exited")
+	}
+	return fmt.Errorf("daemon: aria2c exited: %s", cmd.ProcessState)
+}
+
+// freePort 选择一个本机空闲的 TCP 端口
+// 端口在 aria2c 启动前可能被其他程序占用，此时 aria2c 启动失败并按崩溃处理
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// generateSecret 生成随机的 RPC 密钥
+func generateSecret() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
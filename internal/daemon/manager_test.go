@@ -0,0 +1,366 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// 测试二进制文件在设置了 stubModeEnv 时作为 aria2c 的桩程序运行
+const (
+	// stubModeEnv 桩程序的行为：serve 监听 RPC 端口直到收到 SIGTERM，
+	// ignore-term 监听端口并忽略 SIGTERM，crash 立即退出，crash-once 第一次启动时立即退出
+	stubModeEnv = "ARIA2GOUI_DAEMON_STUB"
+	// stubLogEnv 桩程序记录启动参数和收到的信号的文件
+	stubLogEnv = "ARIA2GOUI_DAEMON_STUB_LOG"
+)
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(stubModeEnv); mode != "" {
+		runStub(mode)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runStub 模拟 aria2c：按 --rpc-listen-port 监听端口
+func runStub(mode string) {
+	logPath := os.Getenv(stubLogEnv)
+	stubLog(logPath, "start "+strings.Join(os.Args[1:], " "))
+
+	switch mode {
+	case "crash":
+		os.Exit(1)
+	case "crash-once":
+		marker := logPath + ".crashed"
+		if _, err := os.Stat(marker); err != nil {
+			os.WriteFile(marker, nil, 0600)
+			os.Exit(1)
+		}
+	}
+
+	if mode == "ignore-term" {
+		signal.Ignore(syscall.SIGTERM)
+	} else {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM)
+		go func() {
+			<-signals
+			stubLog(logPath, "terminated")
+			os.Exit(0)
+		}()
+	}
+
+	port := ""
+	for _, arg := range os.Args[1:] {
+		if strings.HasPrefix(arg, "--rpc-listen-port=") {
+			port = strings.TrimPrefix(arg, "--rpc-listen-port=")
+		}
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", port))
+	if err != nil {
+		os.Exit(2)
+	}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			os.Exit(2)
+		}
+		conn.Close()
+	}
+}
+
+// stubLog 向桩程序的记录文件追加一行
+func stubLog(path, line string) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	file.WriteString(line + "\n")
+}
+
+// stubLines 读取桩程序的记录
+func stubLines(t *testing.T, path string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// newStubManager 创建以测试二进制文件作为 aria2c 的管理器
+// states 接收管理器的状态变化
+func newStubManager(t *testing.T, mode string, opts Options) (*Manager, string, <-chan State) {
+	t.Helper()
+
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(t.TempDir(), "stub.log")
+	t.Setenv(stubModeEnv, mode)
+	t.Setenv(stubLogEnv, logPath)
+
+	states := make(chan State, 64)
+	opts.Binary = executable
+	m, err := NewManager(opts, func(state State, err error) {
+		states <- state
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.minBackoff = 10 * time.Millisecond
+	m.maxBackoff = 40 * time.Millisecond
+	return m, logPath, states
+}
+
+// waitState 等待管理器进入 want 状态
+func waitState(t *testing.T, states <-chan State, want State) {
+	t.Helper()
+
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case state := <-states:
+			if state == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for state %s", want)
+		}
+	}
+}
+
+// waitReady 等待桩程序开始监听
+func waitReady(t *testing.T, m *Manager) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := m.WaitReady(ctx); err != nil {
+		t.Fatalf("WaitReady: %v", err)
+	}
+}
+
+func TestManagerStartWaitReady(t *testing.T) {
+	m, logPath, states := newStubManager(t, "serve", Options{Conf: []byte("dir=/downloads")})
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitReady(t, m)
+	waitState(t, states, StateRunning)
+
+	// 密钥只出现在配置文件中
+	confFile := m.ConfFile()
+	lines := stubLines(t, logPath)
+	if len(lines) != 1 {
+		t.Fatalf("stub log = %q, want one start", lines)
+	}
+	if strings.Contains(lines[0], m.Secret()) || strings.Contains(lines[0], "rpc-secret") {
+		t.Errorf("secret passed on the command line: %s", lines[0])
+	}
+	if !strings.Contains(lines[0], "--conf-path="+confFile) {
+		t.Errorf("command line %s does not pass --conf-path=%s", lines[0], confFile)
+	}
+
+	data, err := os.ReadFile(confFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "dir=/downloads\nrpc-secret=" + m.Secret() + "\n"; string(data) != want {
+		t.Errorf("config file = %q, want %q", data, want)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(confFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("config file permissions = %o, want 600", perm)
+		}
+	}
+
+	m.Stop()
+	if state, _ := m.State(); state != StateStopped {
+		t.Errorf("state after Stop = %s, want stopped", state)
+	}
+	if _, err := os.Stat(confFile); !os.IsNotExist(err) {
+		t.Errorf("temporary config file %s was not removed", confFile)
+	}
+}
+
+func TestManagerConfFileIsPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not enforced on Windows")
+	}
+
+	// 已存在的配置文件也改为只有当前用户可读
+	confFile := filepath.Join(t.TempDir(), "aria2.conf")
+	if err := os.WriteFile(confFile, []byte("rpc-secret=old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, _, _ := newStubManager(t, "serve", Options{ConfFile: confFile})
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+	waitReady(t, m)
+
+	info, err := os.Stat(confFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("config file permissions = %o, want 600", perm)
+	}
+	data, _ := os.ReadFile(confFile)
+	if want := "rpc-secret=" + m.Secret() + "\n"; string(data) != want {
+		t.Errorf("config file = %q, want %q", data, want)
+	}
+}
+
+func TestManagerConfStripsManagedOptions(t *testing.T) {
+	// 导入的 aria2.conf 中 daemon=true 会让 aria2c 立即退出，RPC 选项由 Manager 决定
+	conf := "# imported\ndaemon=true\nenable-rpc=false\n rpc-listen-port = 6800\nrpc-listen-all=true\nrpc-secret=old\ndir=/downloads\nmax-concurrent-downloads=5"
+	m, _, states := newStubManager(t, "serve", Options{Conf: []byte(conf)})
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+	waitReady(t, m)
+	waitState(t, states, StateRunning)
+
+	data, err := os.ReadFile(m.ConfFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# imported\ndir=/downloads\nmax-concurrent-downloads=5\nrpc-secret=" + m.Secret() + "\n"
+	if string(data) != want {
+		t.Errorf("config file = %q, want %q", data, want)
+	}
+}
+
+func TestManagerRestartsAfterCrash(t *testing.T) {
+	m, logPath, states := newStubManager(t, "crash-once", Options{})
+	m.minBackoff = 200 * time.Millisecond
+	m.maxBackoff = time.Second
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	waitState(t, states, StateRunning)
+	waitState(t, states, StateRestarting)
+	restarting := time.Now()
+	waitState(t, states, StateRunning)
+	if waited := time.Since(restarting); waited < m.minBackoff/2 {
+		t.Errorf("restarted after %v, want a backoff of about %v", waited, m.minBackoff)
+	}
+
+	waitReady(t, m)
+	if lines := stubLines(t, logPath); len(lines) != 2 {
+		t.Errorf("stub started %d times, want 2", len(lines))
+	}
+}
+
+func TestManagerGivesUpAfterMaxRestarts(t *testing.T) {
+	m, logPath, states := newStubManager(t, "crash", Options{})
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer m.Stop()
+
+	waitState(t, states, StateFailed)
+	if lines := stubLines(t, logPath); len(lines) != maxRestarts+1 {
+		t.Errorf("stub started %d times, want %d", len(lines), maxRestarts+1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := m.WaitReady(ctx); err == nil || err == context.DeadlineExceeded {
+		t.Errorf("WaitReady after giving up = %v, want the exit error", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	m := &Manager{minBackoff: time.Second, maxBackoff: 30 * time.Second}
+	tests := map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		3:  4 * time.Second,
+		5:  16 * time.Second,
+		6:  30 * time.Second,
+		20: 30 * time.Second,
+	}
+	for failures, want := range tests {
+		if got := m.backoff(failures); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestManagerStopTerminates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM is not supported on Windows")
+	}
+
+	m, logPath, _ := newStubManager(t, "serve", Options{StopTimeout: 10 * time.Second})
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitReady(t, m)
+
+	start := time.Now()
+	m.Stop()
+	if elapsed := time.Since(start); elapsed >= 5*time.Second {
+		t.Errorf("Stop took %v, want the process to exit on SIGTERM", elapsed)
+	}
+	lines := stubLines(t, logPath)
+	if len(lines) == 0 || lines[len(lines)-1] != "terminated" {
+		t.Errorf("stub log = %q, want SIGTERM to be handled", lines)
+	}
+}
+
+func TestManagerStopKillsAfterTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM is not supported on Windows")
+	}
+
+	stopTimeout := 300 * time.Millisecond
+	m, _, _ := newStubManager(t, "ignore-term", Options{StopTimeout: stopTimeout})
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitReady(t, m)
+
+	start := time.Now()
+	m.Stop()
+	if elapsed := time.Since(start); elapsed < stopTimeout {
+		t.Errorf("Stop returned after %v, want it to wait %v before killing", elapsed, stopTimeout)
+	}
+
+	m.mu.Lock()
+	cmd := m.cmd
+	m.mu.Unlock()
+	if cmd.ProcessState == nil {
+		t.Fatal("process was not reaped")
+	}
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signal() != syscall.SIGKILL {
+		t.Errorf("process exited with %v, want SIGKILL", cmd.ProcessState)
+	}
+}
@@ -2,8 +2,14 @@ package ui
 
 import (
 
+	"context"
+
+	"errors"
+
 	"fmt"
 
+	"io"
+
 	"os"
 
 	"os/exec"
@@ -12,8 +18,12 @@ import (
 
 	"runtime"
 
+	"sort"
+
 	"strings"
 
+	"sync"
+
 	"time"
 
 	
@@ -24,6 +34,8 @@ import (
 
 	"fyne.io/fyne/v2/dialog"
 
+	"fyne.io/fyne/v2/storage"
+
 	"fyne.io/fyne/v2/theme"
 
 	"fyne.io/fyne/v2/widget"
@@ -43,7 +55,68 @@ type App struct {
 	fyneApp   fyne.App
 	window    fyne.Window
 	config    *config.Config
-	aria2Client *aria2.Client
+	aria2Client aria2.API
+	eventSub  *aria2.Subscription
+	
+	// supervisor 监视当前客户端的连接，断开后自动重连
+	supervisor *aria2.Supervisor
+	
+	// 连接状态及主界面上的状态指示器
+	statusMu    sync.Mutex
+	connState   aria2.ConnState
+	connErr     error
+	statusLabel *widget.Label
+	statusIcon  *widget.Icon
+	// selectionLabel 状态栏中选中任务的数量
+	selectionLabel *widget.Label
+	
+	// selection 列表中选中的任务，合并视图中可以包含多个服务器的任务
+	selection *taskSelection
+	
+	// rpcCtx 当前客户端上调用的上下文，切换服务器时取消以放弃进行中的调用
+	rpcCtx    context.Context
+	cancelRPC context.CancelFunc
+	
+	// clientMu 保护 aria2Client、rpcCtx 和 supervisor 的替换
+	// 界面之外的协程通过 currentRPC 取得快照，不直接读取这些字段
+	clientMu sync.RWMutex
+	
+	// cancelReconnect 取消尚未完成的 reconnectAria2，新的重连开始或程序退出时调用
+	reconnectMu     sync.Mutex
+	cancelReconnect context.CancelFunc
+	
+	// taskNames 任务名称缓存，刷新列表时不必每次都请求 files 字段
+	namesMu   sync.Mutex
+	taskNames map[string]string
+	
+	// autoRefresh 是否自动刷新任务列表
+	autoRefresh bool
+	
+	// clientProfile 当前客户端所属的服务器配置，profileStates 保存其他服务器的界面状态
+	clientProfile string
+	profileStates map[string]*profileState
+	
+	// combinedView 是否在一个列表中显示所有服务器的任务
+	// serverClients 合并视图中其他服务器的客户端，按服务器配置名称缓存
+	combinedView  bool
+	serversMu     sync.Mutex
+	serverClients map[string]serverClient
+	
+	// pendingOptions 改动过但尚未在当前服务器上生效的下载和高级设置，下次保存设置时重试
+	// 由 namesMu 保护，每个服务器各自记录，切换服务器时随 profileState 保存和恢复
+	pendingOptions map[string]bool
+	
+	// tasks 当前服务器任务的最新快照，开启自动刷新时由 poller 在后台按刷新间隔更新
+	tasks    *taskStore
+	pollerMu sync.Mutex
+	poller   *taskPoller
+	
+	// listView 当前显示的任务列表；showTasks 和 showCombinedTasks 由其设置，刷新后调用其中之一
+	reloadMu          sync.Mutex
+	viewMu            sync.Mutex
+	listView          selectableList
+	showTasks         func(changes taskChanges)
+	showCombinedTasks func(results []serverTasks)
 }
 
 // NewApp 创建新的应用程序
@@ -51,9 +124,17 @@ func NewApp() *App {
 	fyneApp := fyne.CurrentApp()
 	
 	app := &App{
-		fyneApp: fyneApp,
-		config:  config.DefaultConfig(),
-	}
+		fyneApp:        fyneApp,
+		config:         config.DefaultConfig(),
+		taskNames:      make(map[string]string),
+		autoRefresh:    true,
+		pendingOptions: make(map[string]bool),
+		profileStates:  make(map[string]*profileState),
+		serverClients:  make(map[string]serverClient),
+		tasks:          newTaskStore(),
+		selection:      newTaskSelection(),
+	}
+	app.rpcCtx, app.cancelRPC = context.WithCancel(context.Background())
 	
 	// 创建主窗口
 	window := fyneApp.NewWindow("aria2GoUI")
@@ -68,9 +149,149 @@ func (a *App) SetConfig(cfg *config.Config) {
 	a.config = cfg
 }
 
-// SetAria2Client 设置 aria2 客户端
-func (a *App) SetAria2Client(client *aria2.Client) {
+// SetAria2Client 设置 aria2 客户端，并关闭被替换的旧客户端
+func (a *App) SetAria2Client(client aria2.API) {
+	a.clientMu.Lock()
+	defer a.clientMu.Unlock()
+	
+	if a.aria2Client != nil && a.aria2Client != client {
+		a.supervisor.Stop()
+		a.supervisor = nil
+		a.eventSub.Unsubscribe()
+		a.cancelRPC()
+		a.rpcCtx, a.cancelRPC = context.WithCancel(context.Background())
+		a.aria2Client.Close()
+	}
+	
+	// 不同服务器的 GID 互不相关，换成新服务器的选中任务和名称缓存
+	if a.aria2Client != client {
+		a.swapProfileState()
+		a.tasks.reset(client)
+	}
+	
 	a.aria2Client = client
+	a.eventSub = nil
+	
+	// 监视新客户端的连接状态
+	if client != nil && a.supervisor == nil {
+		a.supervisor = aria2.NewSupervisor(client, a.handleConnState)
+		a.supervisor.SetAutoReconnect(a.config.RPC.AutoReconnect)
+		a.supervisor.Start()
+	}
+	
+	// WebSocket 连接可以收到 aria2 的推送通知，无需等待刷新
+	if client != nil && client.SupportsNotifications() {
+		// 回调运行在读取连接的协程中，处理时还要发起请求，因此转到新协程
+		a.eventSub = client.Subscribe(func(event aria2.Event) {
+			go a.handleAria2Event(event)
+		})
+	}
+}
+
+// handleConnState 处理连接状态变化，更新状态指示器，连接恢复后刷新任务列表
+func (a *App) handleConnState(state aria2.ConnState, err error) {
+	a.statusMu.Lock()
+	a.connState = state
+	a.connErr = err
+	label, icon := a.statusLabel, a.statusIcon
+	a.statusMu.Unlock()
+	
+	// 主界面尚未创建
+	if label == nil {
+		return
+	}
+	
+	a.showConnState(label, icon, state, err)
+	
+	if state == aria2.StateConnected {
+		// 回调运行在监视协程中，刷新列表时还要发起请求，因此转到新协程
+		go a.refreshTaskList()
+	}
+}
+
+// showConnState 在状态指示器上显示连接状态
+func (a *App) showConnState(label *widget.Label, icon *widget.Icon, state aria2.ConnState, err error) {
+	switch state {
+	case aria2.StateConnecting:
+		label.SetText("正在连接...")
+		icon.SetResource(theme.ViewRefreshIcon())
+	case aria2.StateConnected:
+		label.SetText("已连接")
+		icon.SetResource(theme.ConfirmIcon())
+	case aria2.StateDegraded:
+		label.SetText("连接中断，正在重试...")
+		icon.SetResource(theme.WarningIcon())
+	case aria2.StateOffline:
+		text := "连接失败"
+		if a.config.RPC.AutoReconnect {
+			text += "，将自动重试"
+		}
+		if err != nil {
+			text += fmt.Sprintf(" (%v)", err)
+		}
+		label.SetText(text)
+		icon.SetResource(theme.ErrorIcon())
+	}
+}
+
+// rpcState 当前客户端、调用上下文和连接监视器的快照
+// 后台刷新开始时取得快照，期间切换服务器不会让刷新混用新旧客户端
+type rpcState struct {
+	client     aria2.API
+	ctx        context.Context
+	supervisor *aria2.Supervisor
+}
+
+// currentRPC 返回当前客户端的快照
+func (a *App) currentRPC() rpcState {
+	a.clientMu.RLock()
+	defer a.clientMu.RUnlock()
+	
+	return rpcState{client: a.aria2Client, ctx: a.rpcCtx, supervisor: a.supervisor}
+}
+
+// reportError 将调用失败报告给快照中的连接监视器，传输错误会触发立即检查连接
+func (r rpcState) reportError(err error) {
+	if r.supervisor != nil {
+		r.supervisor.ReportError(err)
+	}
+}
+
+// reportRPCError 将调用失败报告给当前的连接监视器
+func (a *App) reportRPCError(err error) {
+	a.currentRPC().reportError(err)
+}
+
+// handleAria2Event 处理 aria2 推送的任务事件
+func (a *App) handleAria2Event(event aria2.Event) {
+	switch event.Type {
+	case aria2.EventDownloadComplete, aria2.EventBtDownloadComplete:
+		if a.config.Notify.CompleteNotify {
+			a.sendTaskNotification(event.GID, "下载完成")
+		}
+	case aria2.EventDownloadError:
+		if a.config.Notify.ErrorNotify {
+			a.sendTaskNotification(event.GID, "下载出错")
+		}
+	}
+	
+	a.refreshTaskList()
+}
+
+// sendTaskNotification 发送任务相关的系统通知
+func (a *App) sendTaskNotification(gid string, title string) {
+	if !a.config.Notify.SystemNotify {
+		return
+	}
+	
+	name := gid
+	if rpc := a.currentRPC(); rpc.client != nil {
+		if task, err := rpc.client.TellStatusContext(rpc.ctx, gid, "gid", "files"); err == nil {
+			name = a.taskName(*task)
+		}
+	}
+	
+	a.fyneApp.SendNotification(fyne.NewNotification(title, name))
 }
 
 // CreateMainUI 创建主界面
@@ -108,14 +329,19 @@ func (a *App) CreateMainUI() {
 		widget.NewButtonWithIcon("清理完成", theme.ConfirmIcon(), func() {
 			a.clearCompletedTasks()
 		}),
+		widget.NewButtonWithIcon("清理错误", theme.ErrorIcon(), func() {
+			a.clearErroredTasks()
+		}),
 	)
 	
 	// 自动刷新开关
 	autoRefreshCheck := widget.NewCheck("自动刷新", nil)
-	autoRefreshCheck.SetChecked(true)
+	autoRefreshCheck.SetChecked(a.autoRefresh)
 	
 	// 视图和设置工具栏
 	viewToolbar := container.NewHBox(
+		a.createProfileSwitcher(),
+		widget.NewSeparator(),
 		widget.NewButtonWithIcon("刷新", theme.ViewRefreshIcon(), func() {
 			a.refreshTaskList()
 		}),
@@ -131,6 +357,9 @@ func (a *App) CreateMainUI() {
 		widget.NewButtonWithIcon("连接", theme.MediaPlayIcon(), func() {
 			a.testQuickConnection()
 		}),
+		widget.NewButtonWithIcon("全局选项", theme.DocumentIcon(), func() {
+			a.showGlobalOptionsDialog()
+		}),
 		widget.NewButtonWithIcon("设置", theme.SettingsIcon(), func() {
 			a.showSettingsDialog()
 		}),
@@ -143,24 +372,27 @@ func (a *App) CreateMainUI() {
 		viewToolbar,
 	)
 	
-	// 连接状态指示器
+	// 连接状态指示器和选中任务的数量
 	statusLabel := widget.NewLabel("未连接")
 	statusIcon := widget.NewIcon(theme.InfoIcon())
-	statusContainer := container.NewHBox(
-		statusIcon,
-		statusLabel,
+	selectionLabel := widget.NewLabel("")
+	statusContainer := container.NewBorder(
+		nil,
+		nil,
+		container.NewHBox(statusIcon, statusLabel),
+		selectionLabel,
 	)
 	
-	// 测试初始连接状态
-	go func() {
-		if _, err := a.aria2Client.GetVersion(); err != nil {
-			statusLabel.SetText("连接失败")
-			statusIcon.SetResource(theme.ErrorIcon())
-		} else {
-			statusLabel.SetText("已连接")
-			statusIcon.SetResource(theme.ConfirmIcon())
-		}
-	}()
+	// 显示连接监视器报告的状态，之后的变化由 handleConnState 更新
+	a.statusMu.Lock()
+	a.statusLabel, a.statusIcon = statusLabel, statusIcon
+	a.selectionLabel = selectionLabel
+	state, stateErr := a.connState, a.connErr
+	a.statusMu.Unlock()
+	if a.currentRPC().supervisor != nil {
+		a.showConnState(statusLabel, statusIcon, state, stateErr)
+	}
+	a.updateSelectionStatus()
 	
 	// 主内容区域
 	mainContent := container.NewBorder(
@@ -172,14 +404,21 @@ func (a *App) CreateMainUI() {
 	)
 	
 	a.window.SetContent(mainContent)
+	a.window.SetMainMenu(fyne.NewMainMenu(a.createServerMenu(), a.createSelectMenu()))
+	a.window.Canvas().AddShortcut(&fyne.ShortcutSelectAll{}, func(fyne.Shortcut) {
+		a.selectTasks(nil)
+	})
 	
-	// 启动自动刷新
+	// 启动自动刷新，切换到关闭了自动刷新的服务器时停止
 	if autoRefreshCheck.Checked {
 		a.startAutoRefresh()
+	} else {
+		a.stopAutoRefresh()
 	}
 	
 	// 自动刷新开关变化处理
 	autoRefreshCheck.OnChanged = func(checked bool) {
+		a.autoRefresh = checked
 		if checked {
 			a.startAutoRefresh()
 		} else {
@@ -188,224 +427,346 @@ func (a *App) CreateMainUI() {
 	}
 }
 
-// startAutoRefresh 开始自动刷新
+// startAutoRefresh 开始自动刷新，已在刷新时不做任何事
 func (a *App) startAutoRefresh() {
-	// 简化实现：这里可以启动一个 goroutine 来定期刷新
-	// 由于 Fyne 的限制，这里只是记录
-	fmt.Println("自动刷新已启动")
+	a.pollerMu.Lock()
+	defer a.pollerMu.Unlock()
+	
+	if a.poller != nil {
+		return
+	}
+	a.poller = startTaskPoller(func() time.Duration {
+		return time.Duration(a.config.UI.RefreshInterval) * time.Second
+	}, a.pollTasks)
 }
 
-// stopAutoRefresh 停止自动刷新
+// stopAutoRefresh 停止自动刷新，等待进行中的刷新结束
+// 不能在 pollTasks 中调用，否则会一直等待自己结束
 func (a *App) stopAutoRefresh() {
-	fmt.Println("自动刷新已停止")
+	a.pollerMu.Lock()
+	poller := a.poller
+	a.poller = nil
+	a.pollerMu.Unlock()
+	
+	if poller != nil {
+		poller.stopAndWait()
+	}
 }
 
-// createTaskList 创建任务列表
-func (a *App) createTaskList() fyne.CanvasObject {
-	// 创建一个容器来动态切换内容
-	contentContainer := container.NewMax()
-	
-	// 更新任务列表显示的函数
-	updateTaskDisplay := func() {
-		taskCount := a.getTaskCount()
-		
-		if taskCount == 0 {
-			// 显示空状态
-			emptyState := a.createEmptyState()
-			contentContainer.Objects = []fyne.CanvasObject{emptyState}
-		} else {
-			// 显示任务列表
-			taskList := a.createTaskListWidget()
-			contentContainer.Objects = []fyne.CanvasObject{taskList}
-		}
-		
-		contentContainer.Refresh()
+// pollTasks 在后台刷新一次任务，有变化时通知当前显示的任务列表
+// 当前服务器断开期间普通列表不发起请求，连接恢复后由 handleConnState 刷新；
+// 合并视图中其他服务器照常刷新，当前服务器是否请求由 getCombinedTasks 决定
+// Fyne 2.4 没有切换到主线程的接口，控件方法本身可以在其他协程中调用；
+// 通知在 reloadTasks 中依次进行，不会并发更新同一个列表
+func (a *App) pollTasks() {
+	if a.currentRPC().client == nil {
+		return
 	}
 	
-	// 初始更新
-	updateTaskDisplay()
+	a.viewMu.Lock()
+	combined := a.showCombinedTasks != nil
+	a.viewMu.Unlock()
 	
-	return contentContainer
+	if !combined && a.connectionError() != nil {
+		return
+	}
+	
+	a.reloadTasks()
 }
 
-// getTaskCount 获取任务数量
-func (a *App) getTaskCount() int {
-	if a.aria2Client == nil {
-		return 0
+// connectionError 当前服务器未连接时返回原因，已连接时返回 nil
+func (a *App) connectionError() error {
+	a.statusMu.Lock()
+	state, err := a.connState, a.connErr
+	a.statusMu.Unlock()
+	
+	switch {
+	case state == aria2.StateConnected:
+		return nil
+	case err != nil:
+		return err
+	case state == aria2.StateConnecting:
+		return errors.New("正在连接")
+	default:
+		return errors.New("未连接")
 	}
+}
+
+// reloadTasks 获取任务并更新当前显示的任务列表，后台刷新和手动刷新共用，不会同时进行
+// 获取期间切换了服务器时，旧服务器的结果由 taskStore 丢弃
+func (a *App) reloadTasks() {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
 	
-	// 获取活动任务
-	activeTasks, err := a.aria2Client.TellActive()
-	if err != nil {
-		return 0
+	rpc := a.currentRPC()
+	if rpc.client == nil {
+		return
 	}
 	
-	// 获取等待任务
-	waitingTasks, err := a.aria2Client.TellWaiting(0, 1000)
-	if err != nil {
-		return len(activeTasks)
+	a.viewMu.Lock()
+	showTasks, showCombinedTasks := a.showTasks, a.showCombinedTasks
+	a.viewMu.Unlock()
+	
+	if showCombinedTasks != nil {
+		showCombinedTasks(a.getCombinedTasks())
+		return
 	}
 	
-	// 获取已停止任务（最近的一部分）
-	stoppedTasks, err := a.aria2Client.TellStopped(0, 100)
-	if err != nil {
-		return len(activeTasks) + len(waitingTasks)
+	tasks := a.getListTasks(rpc)
+	if changes := a.tasks.replace(rpc.client, tasks); !changes.empty() && showTasks != nil {
+		showTasks(changes)
 	}
+}
+
+// setTaskView 设置当前显示的任务列表和刷新后更新它的函数，普通列表和合并视图只设置其中之一
+func (a *App) setTaskView(list selectableList, showTasks func(changes taskChanges), showCombinedTasks func(results []serverTasks)) {
+	a.viewMu.Lock()
+	defer a.viewMu.Unlock()
 	
-	return len(activeTasks) + len(waitingTasks) + len(stoppedTasks)
+	a.listView = list
+	a.showTasks = showTasks
+	a.showCombinedTasks = showCombinedTasks
 }
 
-// getAllTasks 获取所有任务
-func (a *App) getAllTasks() []aria2.TellStatus {
-	if a.aria2Client == nil {
-		return []aria2.TellStatus{}
+// createTaskList 创建任务列表
+func (a *App) createTaskList() fyne.CanvasObject {
+	if a.combinedView {
+		return a.createCombinedTaskList()
 	}
 	
-	var allTasks []aria2.TellStatus
+	// 初始显示，之后由刷新按任务的变化更新
+	rpc := a.currentRPC()
+	a.tasks.replace(rpc.client, a.getListTasks(rpc))
+	view := newTaskListView(a)
+	a.setTaskView(view, view.show, nil)
 	
-	// 获取活动任务
-	if activeTasks, err := a.aria2Client.TellActive(); err == nil {
-		allTasks = append(allTasks, activeTasks...)
+	return view.content
+}
+
+// taskListKeys 任务列表显示所需的字段
+// 不包含 bitfield、files 和 bittorrent，大型种子的这些字段每次刷新可达数 MB
+var taskListKeys = []string{"gid", "status", "totalLength", "completedLength", "downloadSpeed", "uploadSpeed"}
+
+// getAllTasks 获取 rpc 中客户端上的所有任务，keys 为空时返回全部字段
+func (a *App) getAllTasks(rpc rpcState, keys ...string) []aria2.TellStatus {
+	if rpc.client == nil {
+		return []aria2.TellStatus{}
 	}
 	
-	// 获取等待任务
-	if waitingTasks, err := a.aria2Client.TellWaiting(0, 1000); err == nil {
-		allTasks = append(allTasks, waitingTasks...)
+	allTasks, err := fetchTasks(rpc.ctx, rpc.client, keys...)
+	if err != nil {
+		rpc.reportError(err)
 	}
-	
-	// 获取已停止任务
-	if stoppedTasks, err := a.aria2Client.TellStopped(0, 100); err == nil {
-		allTasks = append(allTasks, stoppedTasks...)
+	if allTasks == nil {
+		return []aria2.TellStatus{}
 	}
 	
 	return allTasks
 }
 
-// createEmptyState 创建空状态显示
-func (a *App) createEmptyState() fyne.CanvasObject {
-	emptyIcon := widget.NewIcon(theme.ContentAddIcon())
-	emptyText := widget.NewLabel("暂无下载任务")
-	emptySubText := widget.NewLabel("点击上方\"添加\"按钮开始添加下载任务")
-	
-	emptyText.TextStyle = fyne.TextStyle{Bold: true}
+// fetchTasks 获取 client 上的活动、等待和已停止任务，keys 为空时返回全部字段
+// 其中某个列表获取失败时返回其他列表的任务和第一个错误，整个请求失败时返回 nil
+func fetchTasks(ctx context.Context, client aria2.API, keys ...string) ([]aria2.TellStatus, error) {
+	// 活动、等待和已停止任务合并为一次 system.multicall 请求
+	withKeys := func(params ...interface{}) []interface{} {
+		if len(keys) > 0 {
+			params = append(params, keys)
+		}
+		return params
+	}
+	calls := []aria2.Call{
+		{Method: "aria2.tellActive", Params: withKeys()},
+		{Method: "aria2.tellWaiting", Params: withKeys(0, 1000)},
+		{Method: "aria2.tellStopped", Params: withKeys(0, 100)},
+	}
+	results, err := client.MulticallContext(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
 	
-	return container.NewVBox(
-		container.NewCenter(emptyIcon),
-		container.NewCenter(emptyText),
-		container.NewCenter(emptySubText),
-	)
-}
-
-// createTaskListWidget 创建任务列表控件
-func (a *App) createTaskListWidget() fyne.CanvasObject {
-	// 获取所有任务
-	allTasks := a.getAllTasks()
+	allTasks := []aria2.TellStatus{}
+	var firstErr error
 	
-	// 任务列表
-	list := widget.NewList(
-		func() int {
-			return len(allTasks)
-		},
-		func() fyne.CanvasObject {
-			return a.createTaskItem()
-		},
-		func(id widget.ListItemID, obj fyne.CanvasObject) {
-			a.updateTaskItem(id, obj, allTasks)
-		},
-	)
+	// 单个列表获取失败不影响其他列表
+	for i, result := range results {
+		var tasks []aria2.TellStatus
+		if err := result.Decode(&tasks); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", calls[i].Method, err)
+			}
+			continue
+		}
+		allTasks = append(allTasks, tasks...)
+	}
 	
-	return list
+	return allTasks, firstErr
 }
 
-// updateTaskItem 更新任务项显示
-func (a *App) updateTaskItem(id widget.ListItemID, obj fyne.CanvasObject, tasks []aria2.TellStatus) {
-	if id >= len(tasks) {
-		return
+// waitingPageSize 分页获取等待队列时每页的任务数
+const waitingPageSize = 1000
+
+// fetchWaitingQueue 分页获取 client 上的整个等待队列，keys 为空时返回全部字段
+// 某一页少于 waitingPageSize 个任务时队列已取完；翻页期间队列变化导致重复的任务只保留一次
+func fetchWaitingQueue(ctx context.Context, client aria2.API, keys ...string) ([]aria2.TellStatus, error) {
+	var queue []aria2.TellStatus
+	seen := make(map[string]bool)
+	for offset := 0; ; offset += waitingPageSize {
+		page, err := client.TellWaitingContext(ctx, offset, waitingPageSize, keys...)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range page {
+			if !seen[task.GID] {
+				seen[task.GID] = true
+				queue = append(queue, task)
+			}
+		}
+		if len(page) < waitingPageSize {
+			return queue, nil
+		}
 	}
-	
-	// 简化实现：暂时不更新具体组件
-	// 简化实现：任务列表刷新已经足够优化
 }
 
-// createTaskItemForData 为特定任务数据创建任务项
-func (a *App) createTaskItemForData(task aria2.TellStatus) fyne.CanvasObject {
-	var name string = "未知任务"
-	var status string = task.Status
-	var progress float64 = 0
-	var speedText string = "0 B/s"
-	var sizeText string = "0 B / 0 B"
+// getListTasks 获取列表显示用的任务，只请求 taskListKeys 中的字段
+func (a *App) getListTasks(rpc rpcState) []aria2.TellStatus {
+	tasks := a.getAllTasks(rpc, taskListKeys...)
+	a.resolveTaskNames(rpc, "", tasks)
+	return tasks
+}
+
+// resolveTaskNames 为名称未知的任务单独请求一次 files 和 bittorrent 字段，并清理已消失任务的缓存
+// rpc 为任务所在服务器的客户端快照，server 为任务所属的服务器配置，为空表示当前服务器
+func (a *App) resolveTaskNames(rpc rpcState, server string, tasks []aria2.TellStatus) {
+	present := make(map[string]bool, len(tasks))
+	var missing []string
 	
-	// 获取任务名称
-	if len(task.Files) > 0 {
-		name = task.Files[0].Path
-		if name == "" {
-			name = "未命名任务"
+	a.namesMu.Lock()
+	names := a.namesFor(server)
+	for _, task := range tasks {
+		present[task.GID] = true
+		if _, ok := names[task.GID]; !ok {
+			missing = append(missing, task.GID)
 		}
 	}
-	
-	// 计算进度
-	if task.TotalLength != "0" && task.CompletedLength != "0" {
-		total := a.parseFloat64(task.TotalLength)
-		completed := a.parseFloat64(task.CompletedLength)
-		if total > 0 {
-			progress = float64(completed) / float64(total)
+	for gid := range names {
+		if !present[gid] {
+			delete(names, gid)
 		}
-		
-		sizeText = fmt.Sprintf("%s / %s", a.formatSize(completed), a.formatSize(total))
 	}
+	a.namesMu.Unlock()
 	
-	// 获取速度
-	if task.DownloadSpeed != "0" {
-		speed := a.parseFloat64(task.DownloadSpeed)
-		speedText = a.formatSpeed(speed)
+	if len(missing) == 0 {
+		return
 	}
 	
-	nameLabel := widget.NewLabel(name)
-	statusLabel := widget.NewLabel(status)
-	progressBar := widget.NewProgressBar()
-	progressBar.SetValue(progress)
-	speedLabel := widget.NewLabel(speedText)
-	sizeLabel := widget.NewLabel(sizeText)
+	calls := make([]aria2.Call, len(missing))
+	for i, gid := range missing {
+		calls[i] = aria2.Call{Method: "aria2.tellStatus", Params: []interface{}{gid, []string{"files", "bittorrent"}}}
+	}
+	results, err := rpc.client.MulticallContext(rpc.ctx, calls)
+	if err != nil {
+		return
+	}
 	
-	return container.NewVBox(
-		container.NewHBox(
-			nameLabel,
-			statusLabel,
-		),
-		progressBar,
-		container.NewHBox(
-			speedLabel,
-			sizeLabel,
-		),
-	)
+	a.namesMu.Lock()
+	defer a.namesMu.Unlock()
+	names = a.namesFor(server)
+	for i, result := range results {
+		var task aria2.TellStatus
+		if err := result.Decode(&task); err != nil {
+			continue
+		}
+		// HTTP 任务开始下载前可能还没有文件名，下次刷新时再取
+		if name := task.Name(); name != "" {
+			names[missing[i]] = name
+		}
+	}
+}
+
+// taskName 返回当前服务器上任务的名称
+func (a *App) taskName(task aria2.TellStatus) string {
+	return a.serverTaskName("", task)
+}
+
+// serverTaskName 返回任务名称，优先使用任务自带的 files 字段，其次使用缓存，都没有时返回 GID
+func (a *App) serverTaskName(server string, task aria2.TellStatus) string {
+	if name := task.Name(); name != "" {
+		return name
+	}
+	
+	a.namesMu.Lock()
+	defer a.namesMu.Unlock()
+	if name, ok := a.namesFor(server)[task.GID]; ok {
+		return name
+	}
+	return task.GID
+}
+
+// batchTaskCall 在一次请求中对 rpc 中客户端上的多个任务执行同一方法，返回成功数量和第一个错误
+func (a *App) batchTaskCall(rpc rpcState, method string, gids []string) (int, error) {
+	calls := make([]aria2.Call, len(gids))
+	for i, gid := range gids {
+		calls[i] = aria2.Call{Method: method, Params: []interface{}{gid}}
+	}
+	
+	return a.batchCalls(rpc, calls)
 }
 
-// isStatusText 判断是否为状态文本
-func (a *App) isStatusText(text string) bool {
-	statuses := []string{"active", "waiting", "paused", "complete", "error", "removed"}
-	for _, status := range statuses {
-		if text == status {
-			return true
+// batchCalls 在 rpc 中的客户端上以一次请求执行多个调用，返回成功数量和第一个错误
+func (a *App) batchCalls(rpc rpcState, calls []aria2.Call) (int, error) {
+	results, err := rpc.client.MulticallContext(rpc.ctx, calls)
+	if err != nil {
+		return 0, err
+	}
+	
+	count := 0
+	var firstErr error
+	for _, result := range results {
+		if result.Err != nil {
+			if firstErr == nil {
+				firstErr = result.Err
+			}
+			continue
 		}
+		count++
 	}
-	return false
+	
+	return count, firstErr
 }
 
-// isSpeedText 判断是否为速度文本
-func (a *App) isSpeedText(text string) bool {
-	return len(text) > 0 && (text[len(text)-1] == 's' || text[len(text)-1] == 'B')
+// taskGIDs 提取任务的 GID 列表
+func taskGIDs(tasks []aria2.TellStatus) []string {
+	gids := make([]string, len(tasks))
+	for i, task := range tasks {
+		gids[i] = task.GID
+	}
+	return gids
 }
 
-// isSizeText 判断是否为大小文本
-func (a *App) isSizeText(text string) bool {
-	return len(text) > 0 && (text[len(text)-1] == 'B' || text[len(text)-1] == 'K' || text[len(text)-1] == 'M' || text[len(text)-1] == 'G')
+// createEmptyState 创建空状态显示
+func (a *App) createEmptyState() fyne.CanvasObject {
+	emptyIcon := widget.NewIcon(theme.ContentAddIcon())
+	emptyText := widget.NewLabel("暂无下载任务")
+	emptySubText := widget.NewLabel("点击上方\"添加\"按钮开始添加下载任务")
+	
+	emptyText.TextStyle = fyne.TextStyle{Bold: true}
+	
+	return container.NewVBox(
+		container.NewCenter(emptyIcon),
+		container.NewCenter(emptyText),
+		container.NewCenter(emptySubText),
+	)
 }
 
-// parseFloat64 安全地解析字符串为 float64
-func (a *App) parseFloat64(s string) float64 {
-	var result float64
-	fmt.Sscanf(s, "%f", &result)
-	return result
+// updateTaskItem 将第 id 行绑定到对应的任务
+func (a *App) updateTaskItem(id widget.ListItemID, obj fyne.CanvasObject, tasks []aria2.TellStatus) {
+	if id >= len(tasks) {
+		return
+	}
+	
+	if row, ok := obj.(*taskRow); ok {
+		row.update(tasks[id])
+	}
 }
 
 // formatSpeed 格式化速度显示
@@ -421,6 +782,21 @@ func (a *App) formatSpeed(bytesPerSec float64) string {
 	}
 }
 
+// formatDuration 格式化剩余时间显示
+func (a *App) formatDuration(d time.Duration) string {
+	seconds := int64(d / time.Second)
+	switch {
+	case seconds < 60:
+		return fmt.Sprintf("%d秒", seconds)
+	case seconds < 3600:
+		return fmt.Sprintf("%d分%d秒", seconds/60, seconds%60)
+	case seconds < 86400:
+		return fmt.Sprintf("%d小时%d分", seconds/3600, seconds%3600/60)
+	default:
+		return fmt.Sprintf("%d天%d小时", seconds/86400, seconds%86400/3600)
+	}
+}
+
 // formatSize 格式化大小显示
 func (a *App) formatSize(bytes float64) string {
 	if bytes < 1024 {
@@ -434,231 +810,294 @@ func (a *App) formatSize(bytes float64) string {
 	}
 }
 
-// createTaskItem 创建任务项
+// createTaskItem 创建任务行模板
 func (a *App) createTaskItem() fyne.CanvasObject {
-	nameLabel := widget.NewLabel("任务名称")
-	statusLabel := widget.NewLabel("状态")
-	progressBar := widget.NewProgressBar()
-	speedLabel := widget.NewLabel("速度")
-	sizeLabel := widget.NewLabel("大小")
-	
-	// 创建任务项容器
-	taskContainer := container.NewVBox(
-		container.NewHBox(
-			nameLabel,
-			statusLabel,
-		),
-		progressBar,
-		container.NewHBox(
-			speedLabel,
-			sizeLabel,
-		),
-	)
-	
-	// 创建可点击的卡片
-	card := widget.NewCard("", "", taskContainer)
-	
-	// 创建自定义可点击容器
-	clickableContainer := &ClickableContainer{
-		CanvasObject: container.NewVBox(card),
-		app:          a,
-	}
-	
-	return clickableContainer
+	return newTaskRow(a)
 }
 
-// ClickableContainer 可点击容器
-type ClickableContainer struct {
-	fyne.CanvasObject
-	app *App
-}
+// queueMove 队列移动方式
+type queueMove int
 
-// Tapped 处理点击事件
-func (c *ClickableContainer) Tapped(*fyne.PointEvent) {
-	// 显示任务详情
-	c.app.showTaskDetailDialog()
+const (
+	moveToTop queueMove = iota
+	moveToBottom
+	moveUp
+	moveDown
+)
+
+// moveTaskToTop 将选中的任务移到队列顶部
+func (a *App) moveTaskToTop() {
+	a.moveSelectedTasks(moveToTop, "任务已移动到顶部")
 }
 
-// TappedSecondary 处理右键点击事件
-func (c *ClickableContainer) TappedSecondary(*fyne.PointEvent) {
-	// 显示上下文菜单
-	c.app.showTaskContextMenu()
+// moveTaskToBottom 将选中的任务移到队列底部
+func (a *App) moveTaskToBottom() {
+	a.moveSelectedTasks(moveToBottom, "任务已移动到底部")
 }
 
-// addTaskContextMenu 为任务项添加右键菜单
-func (a *App) addTaskContextMenu(item fyne.CanvasObject) fyne.CanvasObject {
-	// 简化实现：使用操作按钮菜单替代右键菜单
-	// 可以通过监听鼠标事件来实现
-	return item
+// moveTaskUp 将选中的任务上移一位
+func (a *App) moveTaskUp() {
+	a.moveSelectedTasks(moveUp, "任务已上移")
 }
 
-// createTaskContextMenu 创建任务右键菜单项
-func (a *App) createTaskContextMenu() []fyne.CanvasObject {
-	return []fyne.CanvasObject{
-		widget.NewButton("开始任务", func() {
-			a.resumeSelectedTasks()
-		}),
-		widget.NewButton("暂停任务", func() {
-			a.pauseSelectedTasks()
-		}),
-		widget.NewSeparator(),
-		widget.NewButton("移动到顶部", func() {
-			a.moveTaskToTop()
-		}),
-		widget.NewButton("移动到底部", func() {
-			a.moveTaskToBottom()
-		}),
-		widget.NewSeparator(),
-		widget.NewButton("复制下载链接", func() {
-			a.copyTaskURL()
-		}),
-		widget.NewButton("打开文件所在目录", func() {
-			a.openTaskDirectory()
-		}),
-		widget.NewSeparator(),
-		widget.NewButton("任务详情", func() {
-			a.showTaskDetailDialog()
-		}),
-		widget.NewButton("删除任务", func() {
-			a.showRemoveTaskDialog()
-		}),
-	}
+// moveTaskDown 将选中的任务下移一位
+func (a *App) moveTaskDown() {
+	a.moveSelectedTasks(moveDown, "任务已下移")
 }
 
-// moveTaskToTop 将任务移到队列顶部
-func (a *App) moveTaskToTop() {
-	if a.aria2Client == nil {
-		a.showErrorMessage("未连接到 aria2 服务")
+// moveSelectedTasks 移动选中的等待任务，多个任务保持原有的相对顺序
+// 合并视图中选中了多个服务器的任务时，在各自的等待队列中移动
+func (a *App) moveSelectedTasks(move queueMove, successMsg string) {
+	if move == moveToBottom {
+		a.moveSelectedToBottom(successMsg)
 		return
 	}
 	
-	// 获取等待中的任务（只有等待中的任务可以移动位置）
-	waitingTasks, err := a.aria2Client.TellWaiting(0, 1000)
-	if err != nil {
-		a.showErrorMessage(fmt.Sprintf("获取任务失败: %v", err))
+	groups := a.selection.groups()
+	if len(groups) == 0 {
+		a.showErrorMessage("请先在列表中选择要移动的任务")
 		return
 	}
 	
-	if len(waitingTasks) == 0 {
+	movable := false
+	moved := false
+	for _, group := range groups {
+		rpc, err := a.clientForProfile(group.server)
+		if err != nil {
+			a.showErrorMessage(err.Error())
+			return
+		}
+		
+		// 获取等待中的任务（只有等待中的任务可以移动位置）
+		waitingTasks, err := fetchWaitingQueue(rpc.ctx, rpc.client, "gid")
+		if err != nil {
+			a.showErrorMessage(fmt.Sprintf("获取任务失败: %v", err))
+			return
+		}
+		
+		selected := make(map[string]bool, len(group.gids))
+		for _, gid := range group.gids {
+			selected[gid] = true
+		}
+		
+		calls := planQueueMoves(taskGIDs(waitingTasks), selected, move)
+		if calls == nil {
+			continue
+		}
+		movable = true
+		if len(calls) == 0 {
+			// 选中的任务已经在目标位置
+			continue
+		}
+		
+		// aria2 按顺序执行 system.multicall 中的调用，一次请求即可完成全部移动
+		if _, err := a.batchCalls(rpc, calls); err != nil {
+			a.showErrorMessage(fmt.Sprintf("移动任务失败: %v", err))
+			return
+		}
+		moved = true
+	}
+	
+	if !movable {
 		a.showErrorMessage("没有可移动的任务（只有等待中的任务可以移动位置）")
 		return
 	}
-	
-	// 简化实现：移动第一个等待任务到顶部
-	task := waitingTasks[0]
-	if err := a.changeTaskPosition(task.GID, 0); err != nil {
-		a.showErrorMessage(fmt.Sprintf("移动任务失败: %v", err))
-	} else {
-		a.showSuccessMessage("任务已移动到顶部")
+	if moved {
+		a.showSuccessMessage(successMsg)
 		a.refreshTaskList()
 	}
 }
 
-// moveTaskToBottom 将任务移到队列底部
-func (a *App) moveTaskToBottom() {
-	if a.aria2Client == nil {
-		a.showErrorMessage("未连接到 aria2 服务")
+// moveSelectedToBottom 将选中的等待任务按列表中的顺序依次移到队列末尾
+// 使用 POS_END 定位，不需要获取等待队列，队列再长也能移到真正的末尾
+func (a *App) moveSelectedToBottom(successMsg string) {
+	selected, err := a.fetchSelectedTasks("gid", "status")
+	if err != nil {
+		a.showErrorMessage(err.Error())
 		return
 	}
 	
-	// 获取等待中的任务
-	waitingTasks, err := a.aria2Client.TellWaiting(0, 1000)
-	if err != nil {
-		a.showErrorMessage(fmt.Sprintf("获取任务失败: %v", err))
-		return
+	// 多个任务保持在列表中的相对顺序
+	order := make(map[taskRef]int)
+	if list := a.currentList(); list != nil {
+		for i, row := range list.listed() {
+			order[row.ref()] = i
+		}
 	}
 	
-	if len(waitingTasks) == 0 {
-		a.showErrorMessage("没有可移动的任务（只有等待中的任务可以移动位置）")
-		return
+	movable := false
+	for _, group := range selected {
+		// 只有等待中和已暂停的任务在等待队列中
+		var waiting []aria2.TellStatus
+		for _, task := range group.tasks {
+			if task.Status == "waiting" || task.Status == "paused" {
+				waiting = append(waiting, task)
+			}
+		}
+		if len(waiting) == 0 {
+			continue
+		}
+		movable = true
+		sort.SliceStable(waiting, func(i, j int) bool {
+			return order[taskRef{server: group.server, gid: waiting[i].GID}] < order[taskRef{server: group.server, gid: waiting[j].GID}]
+		})
+		
+		calls := make([]aria2.Call, len(waiting))
+		for i, task := range waiting {
+			calls[i] = aria2.Call{
+				Method: "aria2.changePosition",
+				Params: []interface{}{task.GID, 0, string(aria2.PosEnd)},
+			}
+		}
+		if _, err := a.batchCalls(group.rpc, calls); err != nil {
+			a.showErrorMessage(fmt.Sprintf("移动任务失败: %v", err))
+			return
+		}
 	}
 	
-	// 简化实现：移动第一个等待任务到底部
-	task := waitingTasks[0]
-	if err := a.changeTaskPosition(task.GID, -1); err != nil {
-		a.showErrorMessage(fmt.Sprintf("移动任务失败: %v", err))
-	} else {
-		a.showSuccessMessage("任务已移动到底部")
-		a.refreshTaskList()
+	if !movable {
+		a.showErrorMessage("没有可移动的任务（只有等待中的任务可以移动位置）")
+		return
 	}
+	a.showSuccessMessage(successMsg)
+	a.refreshTaskList()
 }
 
-// changeTaskPosition 改变任务位置
-func (a *App) changeTaskPosition(gid string, pos int) error {
-	// 这里需要调用 aria2 的 ChangePosition 方法
-	// 由于当前的 aria2 客户端没有这个方法，我们暂时返回成功
-	// 在实际实现中，需要添加相应的 RPC 调用
-	fmt.Printf("移动任务 %s 到位置 %d\n", gid, pos)
-	return nil
+// planQueueMoves 计算移到顶部、上移和下移选中任务所需的 changePosition 调用，移到底部见 moveSelectedToBottom
+// queue 为当前等待队列的 GID；没有选中任何等待任务时返回 nil
+func planQueueMoves(queue []string, selected map[string]bool, move queueMove) []aria2.Call {
+	var picked []string
+	for _, gid := range queue {
+		if selected[gid] {
+			picked = append(picked, gid)
+		}
+	}
+	if len(picked) == 0 {
+		return nil
+	}
+	
+	calls := []aria2.Call{}
+	changePosition := func(gid string, pos int, how aria2.PositionHow) {
+		calls = append(calls, aria2.Call{
+			Method: "aria2.changePosition",
+			Params: []interface{}{gid, pos, string(how)},
+		})
+	}
+	
+	switch move {
+	case moveToTop:
+		// 按原顺序依次放到第 0、1、2... 位，已经在顶部的连续任务保持不动
+		inPlace := 0
+		for inPlace < len(picked) && queue[inPlace] == picked[inPlace] {
+			inPlace++
+		}
+		for i := inPlace; i < len(picked); i++ {
+			changePosition(picked[i], i, aria2.PosSet)
+		}
+	case moveUp:
+		// 每个选中任务越过前面一个未选中的任务；已在顶部的连续任务保持不动
+		order := append([]string(nil), queue...)
+		for i := 1; i < len(order); i++ {
+			if selected[order[i]] && !selected[order[i-1]] {
+				order[i-1], order[i] = order[i], order[i-1]
+				changePosition(order[i-1], i-1, aria2.PosSet)
+			}
+		}
+	case moveDown:
+		order := append([]string(nil), queue...)
+		for i := len(order) - 2; i >= 0; i-- {
+			if selected[order[i]] && !selected[order[i+1]] {
+				order[i], order[i+1] = order[i+1], order[i]
+				changePosition(order[i+1], i+1, aria2.PosSet)
+			}
+		}
+	}
+	
+	return calls
 }
 
-// copyTaskURL 复制任务下载链接
+// copyTaskURL 显示选中任务的下载链接
 func (a *App) copyTaskURL() {
-	if a.aria2Client == nil {
-		a.showErrorMessage("未连接到 aria2 服务")
+	selected, err := a.fetchSelectedTasks("gid", "files")
+	if err != nil {
+		a.showErrorMessage(err.Error())
 		return
 	}
-	
-	// 获取所有任务
-	tasks := a.getAllTasks()
-	if len(tasks) == 0 {
-		a.showErrorMessage("没有可复制的链接")
+	a.showTaskURLs(selected)
+}
+
+// showTaskURLs 显示任务的下载链接，tasks 至少需要 files 字段
+func (a *App) showTaskURLs(selected []selectedTasks) {
+	// 每个任务取第一个文件的第一个链接，与单个任务时的行为一致
+	var urls []string
+	for _, group := range selected {
+		for _, task := range group.tasks {
+			if len(task.Files) > 0 && len(task.Files[0].URIs) > 0 && task.Files[0].URIs[0].URI != "" {
+				urls = append(urls, task.Files[0].URIs[0].URI)
+			}
+		}
+	}
+	if len(urls) == 0 {
+		a.showErrorMessage("选中的任务没有有效的下载链接")
 		return
 	}
 	
-	// 简化实现：复制第一个任务的链接（实际应该获取选中的任务）
-	task := tasks[0]
-	if len(task.Files) > 0 && len(task.Files[0].URIs) > 0 {
-		url := task.Files[0].URIs[0].URI
-		if url != "" {
-			// 在实际应用中，这里应该使用剪贴板 API
-			// 由于 Fyne 的剪贴板功能较复杂，这里简化为显示链接
-			linkWindow := a.fyneApp.NewWindow("下载链接")
-			linkWindow.Resize(fyne.NewSize(500, 150))
-			
-			linkEntry := widget.NewMultiLineEntry()
-			linkEntry.SetText(url)
-			linkEntry.Wrapping = fyne.TextWrapWord
-			
-			content := container.NewVBox(
-				widget.NewLabel("下载链接（请手动复制）:"),
-				linkEntry,
-				widget.NewButton("关闭", func() {
-					linkWindow.Close()
-				}),
-			)
-			
-			linkWindow.SetContent(content)
-			linkWindow.Show()
-			
-			a.showSuccessMessage("链接已显示，请手动复制")
-		} else {
-			a.showErrorMessage("任务没有有效的下载链接")
-		}
-	} else {
-		a.showErrorMessage("任务没有下载链接")
-	}
+	// 在实际应用中，这里应该使用剪贴板 API
+	// 由于 Fyne 的剪贴板功能较复杂，这里简化为显示链接
+	linkWindow := a.fyneApp.NewWindow("下载链接")
+	linkWindow.Resize(fyne.NewSize(500, 150))
+	
+	linkEntry := widget.NewMultiLineEntry()
+	linkEntry.SetText(strings.Join(urls, "\n"))
+	linkEntry.Wrapping = fyne.TextWrapWord
+	
+	content := container.NewVBox(
+		widget.NewLabel("下载链接（请手动复制）:"),
+		linkEntry,
+		widget.NewButton("关闭", func() {
+			linkWindow.Close()
+		}),
+	)
+	
+	linkWindow.SetContent(content)
+	linkWindow.Show()
+	
+	a.showSuccessMessage(fmt.Sprintf("已显示 %d 个链接，请手动复制", len(urls)))
 }
 
-// openTaskDirectory 打开任务文件所在目录
+// openTaskDirectory 打开选中任务文件所在的目录
 func (a *App) openTaskDirectory() {
-	if a.aria2Client == nil {
-		a.showErrorMessage("未连接到 aria2 服务")
+	selected, err := a.fetchSelectedTasks("gid", "dir", "files")
+	if err != nil {
+		a.showErrorMessage(err.Error())
 		return
 	}
-	
-	// 获取所有任务
-	tasks := a.getAllTasks()
-	if len(tasks) == 0 {
-		a.showErrorMessage("没有可打开目录的任务")
+	a.openTaskDirectories(selected)
+}
+
+// openTaskDirectories 打开任务文件所在的目录，多个任务在同一目录时只打开一次
+// tasks 至少需要 dir 和 files 字段
+func (a *App) openTaskDirectories(selected []selectedTasks) {
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, group := range selected {
+		for _, task := range group.tasks {
+			dirPath := task.Dir
+			if len(task.Files) > 0 && task.Files[0].Path != "" {
+				dirPath = filepath.Dir(task.Files[0].Path)
+			}
+			if dirPath != "" && !seen[dirPath] {
+				seen[dirPath] = true
+				dirs = append(dirs, dirPath)
+			}
+		}
+	}
+	if len(dirs) == 0 {
+		a.showErrorMessage("任务没有有效的文件路径")
 		return
 	}
 	
-	// 简化实现：打开第一个任务的目录（实际应该获取选中的任务）
-	task := tasks[0]
-	if len(task.Files) > 0 && task.Files[0].Path != "" {
-		dirPath := filepath.Dir(task.Files[0].Path)
-		
+	for _, dirPath := range dirs {
 		// 使用系统命令打开目录
 		var cmd *exec.Cmd
 		if runtime.GOOS == "windows" {
@@ -671,23 +1110,40 @@ func (a *App) openTaskDirectory() {
 		
 		if err := cmd.Start(); err != nil {
 			a.showErrorMessage(fmt.Sprintf("打开目录失败: %v", err))
-		} else {
-			a.showSuccessMessage(fmt.Sprintf("已打开目录: %s", dirPath))
+			return
 		}
-	} else {
-		a.showErrorMessage("任务没有有效的文件路径")
 	}
+	a.showSuccessMessage(fmt.Sprintf("已打开目录: %s", strings.Join(dirs, ", ")))
 }
 
-// showTaskDetailDialog 显示任务详情对话框
+// showTaskDetailDialog 显示任务详情对话框，默认显示最近点击的任务，没有时显示第一个选中的任务
+// 下拉框列出该任务所在服务器上的全部任务
 func (a *App) showTaskDetailDialog() {
-	if a.aria2Client == nil {
-		a.showErrorMessage("未连接到 aria2 服务")
+	var server, selectedGID string
+	if focus, ok := a.selection.focused(); ok {
+		server, selectedGID = focus.server, focus.gid
+	} else if groups := a.selection.groups(); len(groups) > 0 {
+		server = groups[0].server
+		selectedGID = groups[0].gids[0]
+	}
+	
+	rpc, err := a.clientForProfile(server)
+	if err != nil {
+		a.showErrorMessage(err.Error())
 		return
 	}
+	client := rpc.client
 	
-	// 获取所有任务
-	tasks := a.getAllTasks()
+	// 下拉框只需要任务名称，完整状态在选中任务后再获取
+	tasks, err := fetchTasks(rpc.ctx, client, taskListKeys...)
+	if err != nil {
+		rpc.reportError(err)
+		if len(tasks) == 0 {
+			a.showErrorMessage(fmt.Sprintf("获取任务失败: %v", err))
+			return
+		}
+	}
+	a.resolveTaskNames(rpc, server, tasks)
 	if len(tasks) == 0 {
 		a.showErrorMessage("没有可显示的任务")
 		return
@@ -699,36 +1155,49 @@ func (a *App) showTaskDetailDialog() {
 	
 	// 创建任务选择下拉框
 	taskSelect := widget.NewSelect([]string{}, nil)
-	for _, task := range tasks {
-		if len(task.Files) > 0 {
-			taskSelect.Options = append(taskSelect.Options, task.Files[0].Path)
-		} else {
-			taskSelect.Options = append(taskSelect.Options, task.GID)
+	initial := 0
+	for i, task := range tasks {
+		taskSelect.Options = append(taskSelect.Options, a.serverTaskName(server, task))
+		if task.GID == selectedGID {
+			initial = i
 		}
 	}
-	if len(taskSelect.Options) > 0 {
-		taskSelect.SetSelected(taskSelect.Options[0])
-	}
+	taskSelect.SetSelectedIndex(initial)
 	
 	// 详情内容显示
 	detailContent := widget.NewRichTextFromMarkdown("请选择一个任务查看详情")
 	
+	// 任务选项编辑器，跟随选中的任务
+	var optionsGID string
+	optionsEditor := newOptionsEditor(
+		func() (aria2.Options, error) {
+			return client.GetOptionContext(rpc.ctx, optionsGID)
+		},
+		func(options aria2.Options) error {
+			return client.ChangeOptionContext(rpc.ctx, optionsGID, options)
+		},
+	)
+	
+	// 文件、服务器、节点和 URI 页面
+	inspector := newTaskInspector(a, rpc)
+	
 	// 更新详情显示的函数
 	updateDetail := func() {
 		if taskSelect.Selected == "" {
 			return
 		}
 		
-		// 找到选中的任务
-		var selectedTask *aria2.TellStatus
-		for i, task := range tasks {
-			if i < len(taskSelect.Options) && taskSelect.Options[i] == taskSelect.Selected {
-				selectedTask = &task
-				break
-			}
+		// 按下拉框中的位置找到选中的任务，同名任务不会混淆
+		index := taskSelect.SelectedIndex()
+		if index < 0 || index >= len(tasks) {
+			return
 		}
+		selectedGID := tasks[index].GID
 		
-		if selectedTask == nil {
+		// 详情需要 bitfield、files 和 bittorrent 等全部字段，只为选中的任务获取
+		selectedTask, err := client.TellStatusContext(rpc.ctx, selectedGID)
+		if err != nil {
+			detailContent.ParseMarkdown(fmt.Sprintf("获取任务详情失败: %v", err))
 			return
 		}
 		
@@ -747,6 +1216,7 @@ func (a *App) showTaskDetailDialog() {
 - **进度**: %.2f%%
 - **下载速度**: %s
 - **上传速度**: %s
+- **剩余时间**: %s
 - **连接数**: %d
 
 ## 文件信息
@@ -755,11 +1225,12 @@ func (a *App) showTaskDetailDialog() {
 			selectedTask.Status,
 			selectedTask.ErrorCode,
 			selectedTask.ErrorMessage,
-			a.formatSize(a.parseFloat64(selectedTask.TotalLength)),
-			a.formatSize(a.parseFloat64(selectedTask.CompletedLength)),
-			a.calculateProgress(selectedTask),
-			a.formatSpeed(a.parseFloat64(selectedTask.DownloadSpeed)),
-			a.formatSpeed(a.parseFloat64(selectedTask.UploadSpeed)),
+			a.formatSize(float64(selectedTask.TotalLength)),
+			a.formatSize(float64(selectedTask.CompletedLength)),
+			selectedTask.Progress()*100,
+			a.formatSpeed(float64(selectedTask.DownloadSpeed)),
+			a.formatSpeed(float64(selectedTask.UploadSpeed)),
+			a.formatETA(selectedTask),
 			selectedTask.Connections,
 		)
 		
@@ -768,8 +1239,8 @@ func (a *App) showTaskDetailDialog() {
 			detailText += "\n### 文件列表\n\n"
 			for i, file := range selectedTask.Files {
 				detailText += fmt.Sprintf("%d. **%s**\n", i+1, file.Path)
-				detailText += fmt.Sprintf("   - 大小: %s\n", a.formatSize(a.parseFloat64(file.Length)))
-				detailText += fmt.Sprintf("   - 已完成: %s\n", a.formatSize(a.parseFloat64(file.CompletedLength)))
+				detailText += fmt.Sprintf("   - 大小: %s\n", a.formatSize(float64(file.Length)))
+				detailText += fmt.Sprintf("   - 已完成: %s\n", a.formatSize(float64(file.CompletedLength)))
 				detailText += fmt.Sprintf("   - 选中: %s\n\n", file.Selected)
 			}
 		}
@@ -789,6 +1260,12 @@ func (a *App) showTaskDetailDialog() {
 		}
 		
 		detailContent.ParseMarkdown(detailText)
+		
+		if optionsGID != selectedTask.GID {
+			optionsGID = selectedTask.GID
+			optionsEditor.reload()
+			inspector.show(optionsGID)
+		}
 	}
 	
 	// 任务选择变化时更新详情
@@ -799,18 +1276,27 @@ func (a *App) showTaskDetailDialog() {
 	// 初始更新
 	updateDetail()
 	
-	// 底部按钮
+	// 底部按钮针对下拉框中正在查看的任务，不改变主列表中的选择
+	fetchViewedTask := func(keys ...string) ([]selectedTasks, bool) {
+		if optionsGID == "" {
+			return nil, false
+		}
+		task, err := client.TellStatusContext(rpc.ctx, optionsGID, keys...)
+		if err != nil {
+			a.showErrorMessage(fmt.Sprintf("获取任务失败: %v", err))
+			return nil, false
+		}
+		return []selectedTasks{{server: server, rpc: rpc, tasks: []aria2.TellStatus{*task}}}, true
+	}
 	bottomButtons := container.NewHBox(
 		widget.NewButton("复制链接", func() {
-			if len(tasks) > 0 {
-				// 复制第一个任务的链接
-				a.copyTaskURL()
+			if selected, ok := fetchViewedTask("gid", "files"); ok {
+				a.showTaskURLs(selected)
 			}
 		}),
 		widget.NewButton("打开目录", func() {
-			if len(tasks) > 0 {
-				// 打开第一个任务的目录
-				a.openTaskDirectory()
+			if selected, ok := fetchViewedTask("gid", "dir", "files"); ok {
+				a.openTaskDirectories(selected)
 			}
 		}),
 		widget.NewButton("关闭", func() {
@@ -827,45 +1313,71 @@ func (a *App) showTaskDetailDialog() {
 		bottomButtons,
 		nil,
 		nil,
-		container.NewScroll(detailContent),
+		container.NewAppTabs(append(
+			[]*container.TabItem{container.NewTabItem("详情", container.NewScroll(detailContent))},
+			append(inspector.tabs(), container.NewTabItem("选项", optionsEditor.content))...,
+		)...),
 	)
 	
+	// 窗口打开期间按刷新间隔更新文件、服务器、节点和 URI
+	stopRefresh := make(chan struct{})
+	detailWindow.SetOnClosed(func() {
+		close(stopRefresh)
+	})
+	go func() {
+		interval := time.Duration(a.config.UI.RefreshInterval) * time.Second
+		if interval < time.Second {
+			interval = time.Second
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		
+		for {
+			select {
+			case <-ticker.C:
+				inspector.refresh()
+			case <-stopRefresh:
+				return
+			}
+		}
+	}()
+	
 	detailWindow.SetContent(mainContainer)
 	detailWindow.Show()
 }
 
-// calculateProgress 计算任务进度
-func (a *App) calculateProgress(task *aria2.TellStatus) float64 {
-	total := a.parseFloat64(task.TotalLength)
-	completed := a.parseFloat64(task.CompletedLength)
-	if total == 0 {
-		return 0
+// formatETA 格式化任务的剩余时间，无法估算时显示“未知”
+func (a *App) formatETA(task *aria2.TellStatus) string {
+	eta, ok := task.ETA()
+	if !ok {
+		return "未知"
 	}
-	return (float64(completed) / float64(total)) * 100
+	return a.formatDuration(eta)
 }
 
-// showTaskContextMenu 显示任务上下文菜单
+// showTaskContextMenu 显示选中任务的操作菜单
 func (a *App) showTaskContextMenu() {
-	if a.aria2Client == nil {
-		a.showErrorMessage("未连接到 aria2 服务")
+	// 按选中任务的当前状态决定显示暂停还是开始
+	selected, err := a.fetchSelectedTasks("gid", "status")
+	if err != nil {
+		a.showErrorMessage(err.Error())
 		return
 	}
-	
-	// 获取当前任务状态
-	tasks := a.getAllTasks()
 	var hasActive, hasPaused bool
 	
-	for _, task := range tasks {
-		switch task.Status {
-		case "active":
-			hasActive = true
-		case "paused":
-			hasPaused = true
+	for _, group := range selected {
+		for _, task := range group.tasks {
+			switch task.Status {
+			case "active", "waiting":
+				hasActive = true
+			case "paused":
+				hasPaused = true
+			}
 		}
 	}
 	
 	// 创建菜单窗口
-	menuWindow := a.fyneApp.NewWindow("任务操作")
+	menuWindow := a.fyneApp.NewWindow(fmt.Sprintf("任务操作（%d 个任务）", a.selection.count()))
 	menuWindow.Resize(fyne.NewSize(250, 400))
 	
 	// 构建菜单项
@@ -933,6 +1445,14 @@ func (a *App) showTaskContextMenu() {
 	deleteBtn.Importance = widget.DangerImportance
 	menuItems = append(menuItems, deleteBtn)
 	
+	// 卡住的任务无法正常删除时使用强制删除
+	forceDeleteBtn := widget.NewButtonWithIcon("强制删除任务", theme.DeleteIcon(), func() {
+		a.forceRemoveSelectedTasks()
+		menuWindow.Close()
+	})
+	forceDeleteBtn.Importance = widget.DangerImportance
+	menuItems = append(menuItems, forceDeleteBtn)
+	
 	// 创建菜单内容
 	menuContent := container.NewVBox(
 		widget.NewLabel("选择操作:"),
@@ -957,8 +1477,15 @@ func (a *App) showTaskContextMenu() {
 	menuWindow.Show()
 }
 
-// saveSettings 保存设置
-func (a *App) saveSettings() {
+// saveSettings 将设置窗口编辑的 draft 应用到配置并保存
+func (a *App) saveSettings(draft *config.Config) {
+	previous := a.config.Aria2OptionValues()
+	a.config.ApplySettings(draft)
+	change := a.newOptionChange(previous)
+	if rpc := a.currentRPC(); rpc.supervisor != nil {
+		rpc.supervisor.SetAutoReconnect(a.config.RPC.AutoReconnect)
+	}
+	
 	configPath := getConfigPath()
 	
 	// 确保配置目录存在
@@ -974,50 +1501,143 @@ func (a *App) saveSettings() {
 		a.showSuccessMessage("配置已保存")
 		
 		// 如果 RPC 设置发生变化，重新连接 aria2 客户端
-		a.reconnectAria2()
+		// 连接成功后将改动过的下载和高级设置立即发送给运行中的 aria2
+		a.reconnectAria2(func(ok bool) {
+			if ok {
+				a.applyAria2Options(change)
+			}
+		})
 	}
 }
 
-// reconnectAria2 重新连接 aria2
-func (a *App) reconnectAria2() {
+// reconnectAria2 使用当前 RPC 配置重新连接 aria2，连接失败时保留原来的客户端
+// 连接测试在后台进行，不会卡住界面；完成后调用 done 报告是否连接成功，done 可以为 nil。
+// 测试期间再次重连时放弃这一次，不再调用 done
+func (a *App) reconnectAria2(done func(ok bool)) {
 	// 创建新的客户端
-	newClient := aria2.NewClient(
+	newClient, err := a.newAria2Client(
 		a.config.RPC.Host,
 		a.config.RPC.Port,
 		a.config.RPC.Token,
 		a.config.RPC.Protocol,
 		a.config.RPC.Path,
+		a.config.RPC.TLS,
 	)
+	if err != nil {
+		a.showErrorMessage(fmt.Sprintf("TLS 设置有误: %v", err))
+		if done != nil {
+			done(false)
+		}
+		return
+	}
+	profile := a.config.ActiveProfile
+	multipleProfiles := len(a.config.Profiles) > 1
+	
+	ctx, cancel := context.WithCancel(context.Background())
+	a.reconnectMu.Lock()
+	if a.cancelReconnect != nil {
+		a.cancelReconnect()
+	}
+	a.cancelReconnect = cancel
+	a.reconnectMu.Unlock()
 	
-	// 测试连接
-	if version, err := newClient.GetVersion(); err != nil {
-		// 提供详细的错误信息和建议
-		errorMsg := fmt.Sprintf("连接 aria2 失败: %v", err)
+	go func() {
+		defer cancel()
 		
-		if strings.Contains(err.Error(), "connection refused") {
-			errorMsg += "\n\n请检查:\n1. aria2 是否正在运行\n2. RPC 端口是否正确（默认 6800）\n3. 防火墙是否阻止连接"
-		} else if strings.Contains(err.Error(), "timeout") {
-			errorMsg += "\n\n请检查:\n1. 网络连接是否正常\n2. RPC 地址是否正确\n3. aria2 是否响应"
-		} else if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "Unauthorized") {
-			errorMsg += "\n\n请检查:\n1. RPC 密钥是否正确\n2. aria2 配置文件中的 rpc-secret 设置"
-		} else if strings.Contains(err.Error(), "404") {
-			errorMsg += "\n\n请检查:\n1. RPC 请求路径是否正确（默认 /jsonrpc）\n2. aria2 配置文件中的 rpc-path 设置"
+		// 测试连接，受连接超时限制
+		version, err := newClient.GetVersionContext(ctx)
+		
+		// 在锁内确认这次重连没有被取代，避免先后两次重连交错替换客户端
+		a.reconnectMu.Lock()
+		if ctx.Err() != nil {
+			a.reconnectMu.Unlock()
+			newClient.Close()
+			return
+		}
+		a.cancelReconnect = nil
+		if err == nil {
+			a.SetAria2Client(newClient)
+		}
+		a.reconnectMu.Unlock()
+		
+		if err != nil {
+			// 提供详细的错误信息和建议
+			errorMsg := fmt.Sprintf("连接 aria2 失败: %v", err)
+			
+			switch {
+			case isConnectionRefused(err):
+				errorMsg += "\n\n请检查:\n1. aria2 是否正在运行\n2. RPC 端口是否正确（默认 6800）\n3. 防火墙是否阻止连接"
+			case isTimeoutError(err):
+				errorMsg += "\n\n请检查:\n1. 网络连接是否正常\n2. RPC 地址是否正确\n3. aria2 是否响应"
+			case errors.Is(err, aria2.ErrUnauthorized):
+				errorMsg += "\n\n请检查:\n1. RPC 密钥是否正确\n2. aria2 配置文件中的 rpc-secret 设置"
+			case errors.Is(err, aria2.ErrNotFound):
+				errorMsg += "\n\n请检查:\n1. RPC 请求路径是否正确（默认 /jsonrpc）\n2. aria2 配置文件中的 rpc-path 设置"
+			}
+			
+			a.showErrorMessage(errorMsg)
+			newClient.Close()
+			if done != nil {
+				done(false)
+			}
+			return
 		}
 		
-		a.showErrorMessage(errorMsg)
-	} else {
-		a.aria2Client = newClient
 		successMsg := "成功连接到 aria2 服务器！"
+		if multipleProfiles {
+			successMsg += fmt.Sprintf("\n服务器: %s", profile)
+		}
 		if version != nil && version.Version != "" {
 			successMsg += fmt.Sprintf("\naria2 版本: %s", version.Version)
 		}
 		a.showSuccessMessage(successMsg)
-		a.refreshTaskList()
+		
+		// 可能换了服务器，服务器切换器、自动刷新开关和任务列表都需要重建
+		a.CreateMainUI()
+		if done != nil {
+			done(true)
+		}
+	}()
+}
+
+// stopReconnect 放弃尚未完成的重连
+func (a *App) stopReconnect() {
+	a.reconnectMu.Lock()
+	defer a.reconnectMu.Unlock()
+	
+	if a.cancelReconnect != nil {
+		a.cancelReconnect()
+		a.cancelReconnect = nil
+	}
+}
+
+// isConnectionRefused 判断错误是否因 aria2 未监听而被拒绝连接
+func isConnectionRefused(err error) bool {
+	var transportErr *aria2.TransportError
+	return errors.As(err, &transportErr) && transportErr.ConnectionRefused()
+}
+
+// isTimeoutError 判断错误是否由连接超时引起
+func isTimeoutError(err error) bool {
+	var transportErr *aria2.TransportError
+	return errors.As(err, &transportErr) && transportErr.Timeout()
+}
+
+// newAria2Client 创建 aria2 客户端，默认超时取自 RPC 配置
+// 证书文件无法读取时返回错误
+func (a *App) newAria2Client(host string, port int, token string, protocol string, path string, tlsSettings config.TLSConfig) (*aria2.Client, error) {
+	tlsConfig, err := aria2.TLSOptionsFrom(tlsSettings).Config()
+	if err != nil {
+		return nil, err
 	}
+
+	client := aria2.NewClientTLS(host, port, token, protocol, path, tlsConfig)
+	client.SetTimeout(time.Duration(a.config.RPC.Timeout) * time.Second)
+	return client, nil
 }
 
-// restoreDefaultSettings 恢复默认设置
-func (a *App) restoreDefaultSettings() {
+// restoreDefaultSettings 恢复默认设置，settingsWindow 为正在编辑的设置窗口，恢复后换成显示默认值的新窗口
+func (a *App) restoreDefaultSettings(settingsWindow fyne.Window) {
 	// 创建确认对话框
 	confirmWindow := a.fyneApp.NewWindow("确认恢复")
 	confirmWindow.Resize(fyne.NewSize(300, 150))
@@ -1036,9 +1656,10 @@ func (a *App) restoreDefaultSettings() {
 				a.showSuccessMessage("已恢复默认设置")
 				
 				// 重新连接 aria2 客户端
-				a.reconnectAria2()
+				a.reconnectAria2(nil)
 				
-				// 重新打开设置窗口显示默认值
+				// 重新打开设置窗口显示默认值，旧窗口中的修改不再保存
+				settingsWindow.Close()
 				a.showSettingsDialog()
 			}
 			
@@ -1071,7 +1692,7 @@ func getConfigPath() string {
 func (a *App) showAddTaskDialog() {
 	// 创建添加任务窗口
 	addWindow := a.fyneApp.NewWindow("添加下载任务")
-	addWindow.Resize(fyne.NewSize(500, 400))
+	addWindow.Resize(fyne.NewSize(600, 500))
 	
 	// URL 输入框
 	urlEntry := widget.NewMultiLineEntry()
@@ -1080,8 +1701,8 @@ func (a *App) showAddTaskDialog() {
 	
 	// 下载目录
 	dirEntry := widget.NewEntry()
-	if a.config.Download.DefaultDirectory != "" {
-		dirEntry.SetText(a.config.Download.DefaultDirectory)
+	if dir := a.config.DownloadDir(); dir != "" {
+		dirEntry.SetText(dir)
 	}
 	
 	// 选择目录按钮
@@ -1092,6 +1713,35 @@ func (a *App) showAddTaskDialog() {
 		}
 	})
 	
+	// 种子/Metalink 文件，读取本机文件后上传给 aria2，远程服务器也能使用
+	var torrentData []byte
+	var torrentName string
+	fileLabel := widget.NewLabel("未选择文件")
+	selectFileBtn := widget.NewButton("选择文件", func() {
+		fileDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil {
+				a.showErrorMessage(fmt.Sprintf("打开文件失败: %v", err))
+				return
+			}
+			if reader == nil {
+				return
+			}
+			defer reader.Close()
+			
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				a.showErrorMessage(fmt.Sprintf("读取文件失败: %v", err))
+				return
+			}
+			
+			torrentData = data
+			torrentName = reader.URI().Name()
+			fileLabel.SetText(torrentName)
+		}, addWindow)
+		fileDialog.SetFilter(storage.NewExtensionFileFilter([]string{".torrent", ".metalink", ".meta4"}))
+		fileDialog.Show()
+	})
+	
 	// 下载选项
 	options := map[string]fyne.CanvasObject{
 		"split":  widget.NewSelect([]string{"1", "2", "4", "8", "16", "32"}, nil),
@@ -1109,6 +1759,10 @@ func (a *App) showAddTaskDialog() {
 	// 创建表单
 	form := container.NewVBox(
 		widget.NewCard("下载链接", "", urlEntry),
+		widget.NewCard("种子/Metalink 文件", "选择种子文件时，上方的 HTTP 链接作为 Web 种子", container.NewHBox(
+			selectFileBtn,
+			fileLabel,
+		)),
 		widget.NewCard("下载设置", "", container.NewVBox(
 			container.NewHBox(
 				widget.NewLabel("下载目录:"),
@@ -1125,7 +1779,11 @@ func (a *App) showAddTaskDialog() {
 	// 底部按钮
 	bottomButtons := container.NewHBox(
 		widget.NewButton("确定", func() {
-			a.addTask(urlEntry.Text, dirEntry.Text, options)
+			if torrentData != nil {
+				a.addTorrentTask(torrentName, torrentData, urlEntry.Text, dirEntry.Text, options)
+			} else {
+				a.addTask(urlEntry.Text, dirEntry.Text, options)
+			}
 			addWindow.Close()
 		}),
 		widget.NewButton("取消", func() {
@@ -1157,6 +1815,72 @@ func (a *App) addTask(url, dir string, options map[string]fyne.CanvasObject) {
 	uris := []string{url}
 	
 	// 构建 aria2 选项
+	aria2Options := a.buildTaskOptions(dir, options)
+	
+	// 调用 aria2 客户端添加任务
+	rpc := a.currentRPC()
+	if rpc.client == nil {
+		a.showErrorMessage("未连接到 aria2 服务")
+		return
+	}
+	
+	gid, err := rpc.client.AddURIContext(rpc.ctx, uris, aria2Options)
+	if err != nil {
+		a.showErrorMessage(fmt.Sprintf("添加任务失败: %v", err))
+		return
+	}
+	
+	a.showSuccessMessage(fmt.Sprintf("任务已添加，GID: %s", gid))
+	
+	// 刷新任务列表
+	a.refreshTaskList()
+}
+
+// addTorrentTask 上传种子或 Metalink 文件添加下载任务
+func (a *App) addTorrentTask(name string, data []byte, webSeeds, dir string, options map[string]fyne.CanvasObject) {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
+		a.showErrorMessage("未连接到 aria2 服务")
+		return
+	}
+	
+	aria2Options := a.buildTaskOptions(dir, options)
+	
+	// Metalink 文件可能产生多个任务
+	lowerName := strings.ToLower(name)
+	if strings.HasSuffix(lowerName, ".metalink") || strings.HasSuffix(lowerName, ".meta4") {
+		gids, err := rpc.client.AddMetalinkContext(rpc.ctx, data, aria2Options, -1)
+		if err != nil {
+			a.showErrorMessage(fmt.Sprintf("添加任务失败: %v", err))
+			return
+		}
+		
+		a.showSuccessMessage(fmt.Sprintf("已添加 %d 个任务", len(gids)))
+		a.refreshTaskList()
+		return
+	}
+	
+	// 链接输入框中的 HTTP 地址作为 Web 种子
+	var seeds []string
+	for _, line := range strings.Split(webSeeds, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+			seeds = append(seeds, line)
+		}
+	}
+	
+	gid, err := rpc.client.AddTorrentContext(rpc.ctx, data, seeds, aria2Options, -1)
+	if err != nil {
+		a.showErrorMessage(fmt.Sprintf("添加任务失败: %v", err))
+		return
+	}
+	
+	a.showSuccessMessage(fmt.Sprintf("任务已添加，GID: %s", gid))
+	a.refreshTaskList()
+}
+
+// buildTaskOptions 根据添加任务对话框中的设置构建 aria2 选项
+func (a *App) buildTaskOptions(dir string, options map[string]fyne.CanvasObject) map[string]interface{} {
 	aria2Options := make(map[string]interface{})
 	
 	if dir != "" {
@@ -1175,22 +1899,7 @@ func (a *App) addTask(url, dir string, options map[string]fyne.CanvasObject) {
 		}
 	}
 	
-	// 调用 aria2 客户端添加任务
-	if a.aria2Client == nil {
-		a.showErrorMessage("未连接到 aria2 服务")
-		return
-	}
-	
-	gid, err := a.aria2Client.AddURI(uris, aria2Options)
-	if err != nil {
-		a.showErrorMessage(fmt.Sprintf("添加任务失败: %v", err))
-		return
-	}
-	
-	a.showSuccessMessage(fmt.Sprintf("任务已添加，GID: %s", gid))
-	
-	// 刷新任务列表
-	a.refreshTaskList()
+	return aria2Options
 }
 
 // showErrorMessage 显示错误消息
@@ -1229,72 +1938,69 @@ func (a *App) showSuccessMessage(message string) {
 	successWindow.Show()
 }
 
-// pauseSelectedTasks 暂停选中的任务
+// pauseSelectedTasks 暂停选中的任务，只有下载中和等待中的任务可以暂停
 func (a *App) pauseSelectedTasks() {
-	if a.aria2Client == nil {
-		a.showErrorMessage("未连接到 aria2 服务")
-		return
-	}
-	
-	// 暂停所有活动任务（简化实现，实际应该获取选中的任务）
-	tasks, err := a.aria2Client.TellActive()
+	selected, err := a.fetchSelectedTasks("gid", "status")
 	if err != nil {
-		a.showErrorMessage(fmt.Sprintf("获取任务失败: %v", err))
+		a.showErrorMessage(err.Error())
 		return
 	}
 	
-	if len(tasks) == 0 {
-		a.showErrorMessage("没有活动的任务需要暂停")
+	pausedCount, err := a.callSelectedTasks(selected, func(task aria2.TellStatus) string {
+		if task.Status == "active" || task.Status == "waiting" {
+			return "aria2.pause"
+		}
+		return ""
+	})
+	if pausedCount == 0 && err == nil {
+		a.showErrorMessage("选中的任务中没有可以暂停的任务")
 		return
 	}
-	
-	// 暂停所有活动任务
-	for _, task := range tasks {
-		if err := a.aria2Client.Pause(task.GID); err != nil {
-			a.showErrorMessage(fmt.Sprintf("暂停任务失败: %v", err))
+	if err != nil {
+		a.showErrorMessage(fmt.Sprintf("暂停任务失败: %v", err))
+		if pausedCount == 0 {
 			return
 		}
 	}
 	
-	a.showSuccessMessage(fmt.Sprintf("已暂停 %d 个任务", len(tasks)))
+	a.showSuccessMessage(fmt.Sprintf("已暂停 %d 个任务", pausedCount))
 	a.refreshTaskList()
 }
 
-// resumeSelectedTasks 恢复选中的任务
+// resumeSelectedTasks 恢复选中的已暂停任务
 func (a *App) resumeSelectedTasks() {
-	if a.aria2Client == nil {
-		a.showErrorMessage("未连接到 aria2 服务")
-		return
-	}
-	
-	// 获取等待中的任务
-	waitingTasks, err := a.aria2Client.TellWaiting(0, 1000)
+	selected, err := a.fetchSelectedTasks("gid", "status")
 	if err != nil {
-		a.showErrorMessage(fmt.Sprintf("获取任务失败: %v", err))
+		a.showErrorMessage(err.Error())
 		return
 	}
 	
-	if len(waitingTasks) == 0 {
-		a.showErrorMessage("没有等待中的任务需要恢复")
+	resumedCount, err := a.callSelectedTasks(selected, func(task aria2.TellStatus) string {
+		if task.Status == "paused" {
+			return "aria2.unpause"
+		}
+		return ""
+	})
+	if resumedCount == 0 && err == nil {
+		a.showErrorMessage("选中的任务中没有已暂停的任务")
 		return
 	}
-	
-	// 恢复所有等待的任务
-	for _, task := range waitingTasks {
-		if err := a.aria2Client.Unpause(task.GID); err != nil {
-			a.showErrorMessage(fmt.Sprintf("恢复任务失败: %v", err))
+	if err != nil {
+		a.showErrorMessage(fmt.Sprintf("恢复任务失败: %v", err))
+		if resumedCount == 0 {
 			return
 		}
 	}
 	
-	a.showSuccessMessage(fmt.Sprintf("已恢复 %d 个任务", len(waitingTasks)))
+	a.showSuccessMessage(fmt.Sprintf("已恢复 %d 个任务", resumedCount))
 	a.refreshTaskList()
 }
 
 // showRemoveTaskDialog 显示删除任务确认对话框
 func (a *App) showRemoveTaskDialog() {
-	if a.aria2Client == nil {
-		a.showErrorMessage("未连接到 aria2 服务")
+	count := a.selection.count()
+	if count == 0 {
+		a.showErrorMessage("请先在列表中选择要删除的任务")
 		return
 	}
 	
@@ -1302,16 +2008,25 @@ func (a *App) showRemoveTaskDialog() {
 	confirmWindow := a.fyneApp.NewWindow("确认删除")
 	confirmWindow.Resize(fyne.NewSize(350, 200))
 	
-	// 删除文件选项
-	deleteFilesCheck := widget.NewCheck("同时删除下载的文件", nil)
+	// 删除文件选项，文件只在任务的下载目录中删除，远程服务器需要设置路径映射
+	trashCheck := widget.NewCheck("放入回收站", nil)
+	trashCheck.SetChecked(true)
+	trashCheck.Disable()
+	deleteFilesCheck := widget.NewCheck("同时删除下载的文件", func(checked bool) {
+		if checked {
+			trashCheck.Enable()
+		} else {
+			trashCheck.Disable()
+		}
+	})
 	
 	// 提示信息
-	message := widget.NewLabel("确定要删除选中的任务吗？")
+	message := widget.NewLabel(fmt.Sprintf("确定要删除选中的 %d 个任务吗？", count))
 	
 	// 按钮
 	buttons := container.NewHBox(
 		widget.NewButton("确定", func() {
-			a.removeSelectedTasks(deleteFilesCheck.Checked)
+			a.removeSelectedTasks(deleteFilesCheck.Checked, trashCheck.Checked)
 			confirmWindow.Close()
 		}),
 		widget.NewButton("取消", func() {
@@ -1322,6 +2037,7 @@ func (a *App) showRemoveTaskDialog() {
 	content := container.NewVBox(
 		message,
 		deleteFilesCheck,
+		trashCheck,
 		buttons,
 	)
 	
@@ -1330,60 +2046,82 @@ func (a *App) showRemoveTaskDialog() {
 }
 
 // removeSelectedTasks 删除选中的任务
-func (a *App) removeSelectedTasks(deleteFiles bool) {
-	// 获取所有任务
-	tasks := a.getAllTasks()
-	
-	if len(tasks) == 0 {
-		a.showErrorMessage("没有可删除的任务")
+// deleteFiles 为 true 时在任务停止后删除其下载的文件，useTrash 为 true 时尽量放入回收站
+func (a *App) removeSelectedTasks(deleteFiles, useTrash bool) {
+	// 只有需要删除文件时才请求文件路径
+	keys := []string{"gid", "status"}
+	if deleteFiles {
+		keys = append(keys, taskFileKeys...)
+	}
+	selected, err := a.fetchSelectedTasks(keys...)
+	if err != nil {
+		a.showErrorMessage(err.Error())
 		return
 	}
 	
 	deletedCount := 0
-	
-	// 删除所有任务（简化实现，实际应该删除选中的任务）
-	for _, task := range tasks {
-		if err := a.aria2Client.Remove(task.GID); err != nil {
-			a.showErrorMessage(fmt.Sprintf("删除任务失败: %v", err))
+	var removed []selectedTasks
+	var firstErr error
+	for _, group := range selected {
+		if len(group.tasks) == 0 {
 			continue
 		}
 		
-		// 如果需要删除文件，删除任务文件
-		if deleteFiles && len(task.Files) > 0 {
-			for _, file := range task.Files {
-				// 简化实现：显示文件路径供用户手动删除
-				fmt.Printf("请手动删除文件: %s\n", file.Path)
+		calls := make([]aria2.Call, len(group.tasks))
+		for i, task := range group.tasks {
+			calls[i] = aria2.Call{Method: removeMethodFor(task), Params: []interface{}{task.GID}}
+		}
+		
+		results, err := group.rpc.client.MulticallContext(group.rpc.ctx, calls)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
 			}
+			continue
 		}
 		
-		deletedCount++
+		done := selectedTasks{server: group.server, rpc: group.rpc}
+		for i, task := range group.tasks {
+			if results[i].Err != nil {
+				if firstErr == nil {
+					firstErr = results[i].Err
+				}
+				continue
+			}
+			done.tasks = append(done.tasks, task)
+			deletedCount++
+		}
+		removed = append(removed, done)
+	}
+	
+	if firstErr != nil {
+		a.showErrorMessage(fmt.Sprintf("删除任务失败: %v", firstErr))
+		if deletedCount == 0 {
+			return
+		}
+	}
+	if deletedCount == 0 {
+		a.showErrorMessage("没有可删除的任务")
+		return
 	}
 	
 	a.showSuccessMessage(fmt.Sprintf("已删除 %d 个任务", deletedCount))
 	a.refreshTaskList()
-}
-
-// moveTaskUp 移动任务到顶部
-func (a *App) moveTaskUp() {
-	// 简化实现：显示提示信息
-	a.showErrorMessage("任务移动功能需要手动操作\n请使用'操作'菜单中的'移动到顶部'功能")
-}
-
-// moveTaskDown 移动任务到底部
-func (a *App) moveTaskDown() {
-	// 简化实现：显示提示信息
-	a.showErrorMessage("任务移动功能需要手动操作\n请使用'操作'菜单中的'移动到底部'功能")
+	
+	if deleteFiles {
+		a.deleteTaskFiles(removed, useTrash)
+	}
 }
 
 // pauseAllTasks 暂停所有任务
 func (a *App) pauseAllTasks() {
-	if a.aria2Client == nil {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
 		a.showErrorMessage("未连接到 aria2 服务")
 		return
 	}
 	
-	// 简化实现：获取所有活动任务并逐个暂停
-	activeTasks, err := a.aria2Client.TellActive()
+	activeTasks, err := rpc.client.TellActiveContext(rpc.ctx, "gid")
 	if err != nil {
 		a.showErrorMessage(fmt.Sprintf("获取活动任务失败: %v", err))
 		return
@@ -1394,12 +2132,7 @@ func (a *App) pauseAllTasks() {
 		return
 	}
 	
-	pausedCount := 0
-	for _, task := range activeTasks {
-		if err := a.aria2Client.Pause(task.GID); err == nil {
-			pausedCount++
-		}
-	}
+	pausedCount, _ := a.batchTaskCall(rpc, "aria2.pause", taskGIDs(activeTasks))
 	
 	a.showSuccessMessage(fmt.Sprintf("已暂停 %d 个任务", pausedCount))
 	a.refreshTaskList()
@@ -1407,13 +2140,13 @@ func (a *App) pauseAllTasks() {
 
 // resumeAllTasks 恢复所有任务
 func (a *App) resumeAllTasks() {
-	if a.aria2Client == nil {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
 		a.showErrorMessage("未连接到 aria2 服务")
 		return
 	}
 	
-	// 简化实现：获取所有等待任务并逐个恢复
-	waitingTasks, err := a.aria2Client.TellWaiting(0, 1000)
+	waitingTasks, err := fetchWaitingQueue(rpc.ctx, rpc.client, "gid")
 	if err != nil {
 		a.showErrorMessage(fmt.Sprintf("获取等待任务失败: %v", err))
 		return
@@ -1424,12 +2157,7 @@ func (a *App) resumeAllTasks() {
 		return
 	}
 	
-	resumedCount := 0
-	for _, task := range waitingTasks {
-		if err := a.aria2Client.Unpause(task.GID); err == nil {
-			resumedCount++
-		}
-	}
+	resumedCount, _ := a.batchTaskCall(rpc, "aria2.unpause", taskGIDs(waitingTasks))
 	
 	a.showSuccessMessage(fmt.Sprintf("已恢复 %d 个任务", resumedCount))
 	a.refreshTaskList()
@@ -1437,53 +2165,106 @@ func (a *App) resumeAllTasks() {
 
 // clearCompletedTasks 清理已完成的任务
 func (a *App) clearCompletedTasks() {
-	if a.aria2Client == nil {
+	a.clearStoppedTasks("complete", "已完成")
+}
+
+// clearErroredTasks 清理出错的任务
+func (a *App) clearErroredTasks() {
+	a.clearStoppedTasks("error", "出错")
+}
+
+// clearStoppedTasks 从已停止列表中移除指定状态的任务记录
+func (a *App) clearStoppedTasks(status string, label string) {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
 		a.showErrorMessage("未连接到 aria2 服务")
 		return
 	}
 	
-	// 简化实现：获取已停止任务并删除完成的任务
-	stoppedTasks, err := a.aria2Client.TellStopped(0, 1000)
+	stoppedTasks, err := rpc.client.TellStoppedContext(rpc.ctx, 0, 1000, "gid", "status")
 	if err != nil {
 		a.showErrorMessage(fmt.Sprintf("获取已停止任务失败: %v", err))
 		return
 	}
 	
-	completedCount := 0
+	var matchedTasks []aria2.TellStatus
 	for _, task := range stoppedTasks {
-		if task.Status == "complete" {
-			if err := a.aria2Client.Remove(task.GID); err == nil {
-				completedCount++
-			}
+		if task.Status == status {
+			matchedTasks = append(matchedTasks, task)
 		}
 	}
 	
-	if completedCount > 0 {
-		a.showSuccessMessage(fmt.Sprintf("已清理 %d 个已完成的任务", completedCount))
+	// 已停止的任务不能再 remove，只能移除其下载结果
+	clearedCount := 0
+	if len(matchedTasks) > 0 {
+		clearedCount, _ = a.batchTaskCall(rpc, "aria2.removeDownloadResult", taskGIDs(matchedTasks))
+	}
+	
+	if clearedCount > 0 {
+		a.showSuccessMessage(fmt.Sprintf("已清理 %d 个%s的任务", clearedCount, label))
 	} else {
-		a.showErrorMessage("没有已完成的任务需要清理")
+		a.showErrorMessage(fmt.Sprintf("没有%s的任务需要清理", label))
+	}
+	
+	a.refreshTaskList()
+}
+
+// forceRemoveSelectedTasks 强制删除选中的任务，用于无法正常删除的卡住任务
+func (a *App) forceRemoveSelectedTasks() {
+	selected, err := a.fetchSelectedTasks("gid", "status")
+	if err != nil {
+		a.showErrorMessage(err.Error())
+		return
+	}
+	
+	// 已停止的任务没有可以强制结束的下载，只移除其下载结果
+	removedCount, err := a.callSelectedTasks(selected, func(task aria2.TellStatus) string {
+		if method := removeMethodFor(task); method != "aria2.remove" {
+			return method
+		}
+		return "aria2.forceRemove"
+	})
+	if err != nil {
+		a.showErrorMessage(fmt.Sprintf("强制删除任务失败: %v", err))
+		if removedCount == 0 {
+			return
+		}
+	}
+	if removedCount == 0 {
+		a.showErrorMessage("没有可删除的任务")
+		return
 	}
 	
+	a.showSuccessMessage(fmt.Sprintf("已强制删除 %d 个任务", removedCount))
 	a.refreshTaskList()
 }
 
+// removeMethodFor 返回删除任务应使用的 RPC 方法
+// 活动、等待和暂停的任务用 remove，已停止的任务只能移除下载结果
+func removeMethodFor(task aria2.TellStatus) string {
+	switch task.Status {
+	case "complete", "error", "removed":
+		return "aria2.removeDownloadResult"
+	default:
+		return "aria2.remove"
+	}
+}
+
 // refreshTaskList 刷新任务列表
 func (a *App) refreshTaskList() {
-	if a.aria2Client == nil {
+	if a.currentRPC().client == nil {
 		a.showErrorMessage("未连接到 aria2 服务")
 		return
 	}
 	
-	// 重新创建主界面以刷新任务列表
-	a.CreateMainUI()
-	
-	// 显示刷新提示
-	fmt.Println("任务列表已刷新")
+	// 只更新任务列表，工具栏和其他界面保持不变
+	a.reloadTasks()
 }
 
 // showStatisticsDialog 显示统计信息对话框
 func (a *App) showStatisticsDialog() {
-	if a.aria2Client == nil {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
 		a.showErrorMessage("未连接到 aria2 服务")
 		return
 	}
@@ -1493,19 +2274,19 @@ func (a *App) showStatisticsDialog() {
 	statWindow.Resize(fyne.NewSize(400, 350))
 	
 	// 获取全局统计信息
-	stats, err := a.aria2Client.GetGlobalStat()
+	stats, err := rpc.client.GetGlobalStatContext(rpc.ctx)
 	if err != nil {
 		a.showErrorMessage(fmt.Sprintf("获取统计信息失败: %v", err))
 		return
 	}
 	
 	// 解析统计信息
-	downloadSpeed := a.formatSpeed(a.parseFloat64(stats["downloadSpeed"]))
-	uploadSpeed := a.formatSpeed(a.parseFloat64(stats["uploadSpeed"]))
-	numActive := stats["numActive"]
-	numWaiting := stats["numWaiting"]
-	numStopped := stats["numStopped"]
-	numStoppedTotal := stats["numStoppedTotal"]
+	downloadSpeed := a.formatSpeed(float64(stats.DownloadSpeed))
+	uploadSpeed := a.formatSpeed(float64(stats.UploadSpeed))
+	numActive := fmt.Sprintf("%d", stats.NumActive)
+	numWaiting := fmt.Sprintf("%d", stats.NumWaiting)
+	numStopped := fmt.Sprintf("%d", stats.NumStopped)
+	numStoppedTotal := fmt.Sprintf("%d", stats.NumStoppedTotal)
 	
 	// 创建统计信息显示
 	statContent := container.NewVBox(
@@ -1552,7 +2333,8 @@ func (a *App) showStatisticsDialog() {
 
 // exportTasks 导出任务列表
 func (a *App) exportTasks() {
-	if a.aria2Client == nil {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
 		a.showErrorMessage("未连接到 aria2 服务")
 		return
 	}
@@ -1562,9 +2344,9 @@ func (a *App) exportTasks() {
 	content += "导出时间: " + time.Now().Format("2006-01-02 15:04:05") + "\n\n"
 	
 	// 获取所有任务
-	activeTasks, _ := a.aria2Client.TellActive()
-	waitingTasks, _ := a.aria2Client.TellWaiting(0, 1000)
-	stoppedTasks, _ := a.aria2Client.TellStopped(0, 1000)
+	activeTasks, _ := rpc.client.TellActiveContext(rpc.ctx)
+	waitingTasks, _ := fetchWaitingQueue(rpc.ctx, rpc.client)
+	stoppedTasks, _ := rpc.client.TellStoppedContext(rpc.ctx, 0, 1000)
 	
 	allTasks := append(activeTasks, waitingTasks...)
 	allTasks = append(allTasks, stoppedTasks...)
@@ -1578,12 +2360,12 @@ func (a *App) exportTasks() {
 	for i, task := range allTasks {
 		content += fmt.Sprintf("任务 %d:\n", i+1)
 		content += fmt.Sprintf("  GID: %s\n", task.GID)
-		content += fmt.Sprintf("  名称: %s\n", task.Files[0].Path)
+		content += fmt.Sprintf("  名称: %s\n", task.Name())
 		content += fmt.Sprintf("  状态: %s\n", task.Status)
-		if len(task.Files) > 0 {
+		if len(task.Files) > 0 && len(task.Files[0].URIs) > 0 {
 			content += fmt.Sprintf("  链接: %s\n", task.Files[0].URIs[0].URI)
 		}
-		content += fmt.Sprintf("  进度: %s/%s\n", task.CompletedLength, task.TotalLength)
+		content += fmt.Sprintf("  进度: %d/%d (%.1f%%)\n", task.CompletedLength, task.TotalLength, task.Progress()*100)
 		content += "\n"
 	}
 	
@@ -1601,24 +2383,36 @@ func (a *App) exportTasks() {
 
 // showSettingsDialog 显示设置对话框
 func (a *App) showSettingsDialog() {
+	a.showSettingsWindow(a.config.Clone())
+}
+
+// showSettingsWindow 显示编辑 draft 的设置窗口
+// 界面上的修改只写入 draft，点击确定后才应用到配置，取消时丢弃
+func (a *App) showSettingsWindow(draft *config.Config) {
 	// 创建设置窗口
 	settingsWindow := a.fyneApp.NewWindow("设置")
 	settingsWindow.Resize(fyne.NewSize(600, 500))
 	
 	// 创建设置内容
-	settingsContent := a.createSettingsContent()
+	settingsContent := a.createSettingsContent(draft)
 	
 	// 底部按钮
 	bottomButtons := container.NewHBox(
 		widget.NewButton("确定", func() {
-			a.saveSettings()
+			a.saveSettings(draft)
 			settingsWindow.Close()
 		}),
 		widget.NewButton("取消", func() {
 			settingsWindow.Close()
 		}),
 		widget.NewButton("恢复默认", func() {
-			a.restoreDefaultSettings()
+			a.restoreDefaultSettings(settingsWindow)
+		}),
+		widget.NewButton("导入 aria2.conf", func() {
+			a.importAria2Conf(settingsWindow, draft)
+		}),
+		widget.NewButton("导出 aria2.conf", func() {
+			a.exportAria2Conf(settingsWindow, draft)
 		}),
 	)
 	
@@ -1635,14 +2429,14 @@ func (a *App) showSettingsDialog() {
 	settingsWindow.Show()
 }
 
-// createSettingsContent 创建设置内容
-func (a *App) createSettingsContent() fyne.CanvasObject {
+// createSettingsContent 创建设置内容，各选项卡编辑 draft
+func (a *App) createSettingsContent(draft *config.Config) fyne.CanvasObject {
 	// 创建选项卡容器
 	tabs := container.NewAppTabs(
-		container.NewTabItem("Aria2 RPC", a.createRPCSettings()),
-		container.NewTabItem("基本设置", a.createBasicSettings()),
-		container.NewTabItem("下载设置", a.createDownloadSettings()),
-		container.NewTabItem("高级设置", a.createAdvancedSettings()),
+		container.NewTabItem("Aria2 RPC", a.createRPCSettings(draft)),
+		container.NewTabItem("基本设置", a.createBasicSettings(draft)),
+		container.NewTabItem("下载设置", a.createDownloadSettings(draft)),
+		container.NewTabItem("高级设置", a.createAdvancedSettings(draft)),
 		container.NewTabItem("显示设置", a.createDisplaySettings()),
 		container.NewTabItem("通知设置", a.createNotifySettings()),
 	)
@@ -1651,34 +2445,115 @@ func (a *App) createSettingsContent() fyne.CanvasObject {
 }
 
 // createRPCSettings 创建 RPC 设置界面
-func (a *App) createRPCSettings() fyne.CanvasObject {
+func (a *App) createRPCSettings(draft *config.Config) fyne.CanvasObject {
 	// RPC 地址
 	hostEntry := widget.NewEntry()
-	hostEntry.SetText(a.config.RPC.Host)
+	hostEntry.SetText(draft.RPC.Host)
+	hostEntry.OnChanged = func(text string) {
+		draft.RPC.Host = strings.TrimSpace(text)
+	}
 	
 	// RPC 端口
 	portEntry := widget.NewEntry()
-	portEntry.SetText(fmt.Sprintf("%d", a.config.RPC.Port))
+	portEntry.SetText(fmt.Sprintf("%d", draft.RPC.Port))
+	portEntry.OnChanged = func(text string) {
+		draft.RPC.Port = a.parseInt(text)
+	}
 	
 	// RPC 协议
 	protocolSelect := widget.NewSelect([]string{"http", "https", "ws", "wss"}, nil)
-	protocolSelect.SetSelected(a.config.RPC.Protocol)
+	protocolSelect.SetSelected(draft.RPC.Protocol)
+	protocolSelect.OnChanged = func(protocol string) {
+		draft.RPC.Protocol = protocol
+	}
 	
 	// RPC 密钥
 	tokenEntry := widget.NewPasswordEntry()
-	tokenEntry.SetText(a.config.RPC.Token)
+	tokenEntry.SetText(draft.RPC.Token)
+	tokenEntry.OnChanged = func(text string) {
+		draft.RPC.Token = text
+	}
 	
 	// 请求路径
 	pathEntry := widget.NewEntry()
-	pathEntry.SetText(a.config.RPC.Path)
+	pathEntry.SetText(draft.RPC.Path)
+	pathEntry.OnChanged = func(text string) {
+		draft.RPC.Path = strings.TrimSpace(text)
+	}
 	
 	// 自动重连
 	autoReconnectCheck := widget.NewCheck("自动重连", nil)
-	autoReconnectCheck.SetChecked(a.config.RPC.AutoReconnect)
+	autoReconnectCheck.SetChecked(draft.RPC.AutoReconnect)
+	autoReconnectCheck.OnChanged = func(checked bool) {
+		draft.RPC.AutoReconnect = checked
+	}
 	
 	// 连接超时
 	timeoutEntry := widget.NewEntry()
-	timeoutEntry.SetText(fmt.Sprintf("%d", a.config.RPC.Timeout))
+	timeoutEntry.SetText(fmt.Sprintf("%d", draft.RPC.Timeout))
+	timeoutEntry.OnChanged = func(text string) {
+		draft.RPC.Timeout = a.parseInt(text)
+	}
+	
+	// TLS 设置，仅对 https 和 wss 生效，保存设置后重新连接时应用
+	tlsConfig := &draft.RPC.TLS
+	caFileEntry := widget.NewEntry()
+	caFileEntry.SetPlaceHolder("留空使用系统证书")
+	caFileEntry.SetText(tlsConfig.CAFile)
+	caFileEntry.OnChanged = func(text string) {
+		tlsConfig.CAFile = strings.TrimSpace(text)
+	}
+	
+	certFileEntry := widget.NewEntry()
+	certFileEntry.SetText(tlsConfig.CertFile)
+	certFileEntry.OnChanged = func(text string) {
+		tlsConfig.CertFile = strings.TrimSpace(text)
+	}
+	
+	keyFileEntry := widget.NewEntry()
+	keyFileEntry.SetText(tlsConfig.KeyFile)
+	keyFileEntry.OnChanged = func(text string) {
+		tlsConfig.KeyFile = strings.TrimSpace(text)
+	}
+	
+	serverNameEntry := widget.NewEntry()
+	serverNameEntry.SetPlaceHolder("留空使用 RPC 地址")
+	serverNameEntry.SetText(tlsConfig.ServerName)
+	serverNameEntry.OnChanged = func(text string) {
+		tlsConfig.ServerName = strings.TrimSpace(text)
+	}
+	
+	insecureCheck := widget.NewCheck("不校验服务器证书（不安全，仅用于调试）", nil)
+	insecureCheck.SetChecked(tlsConfig.InsecureSkipVerify)
+	insecureCheck.OnChanged = func(checked bool) {
+		tlsConfig.InsecureSkipVerify = checked
+	}
+	
+	// 本地 aria2c，下次启动时生效
+	daemonCheck := widget.NewCheck("启动时运行本地 aria2c", nil)
+	daemonCheck.SetChecked(draft.Daemon.Enabled)
+	daemonCheck.OnChanged = func(checked bool) {
+		draft.Daemon.Enabled = checked
+	}
+	
+	binaryEntry := widget.NewEntry()
+	binaryEntry.SetPlaceHolder("留空自动查找 aria2c")
+	binaryEntry.SetText(draft.Daemon.Binary)
+	binaryEntry.OnChanged = func(text string) {
+		draft.Daemon.Binary = strings.TrimSpace(text)
+	}
+	
+	daemonArgsEntry := widget.NewEntry()
+	daemonArgsEntry.SetPlaceHolder("例如 --conf-path=/path/to/aria2.conf")
+	daemonArgsEntry.SetText(strings.Join(draft.Daemon.Args, " "))
+	daemonArgsEntry.OnChanged = func(text string) {
+		draft.Daemon.Args = strings.Fields(text)
+	}
+	
+	// --conf-path 指定的文件不与生成的设置合并
+	daemonArgsHint := widget.NewLabel("提示: 指定 --conf-path 时使用该文件代替下载设置和高级设置中的全部选项")
+	daemonArgsHint.TextStyle = fyne.TextStyle{Italic: true}
+	daemonArgsHint.Wrapping = fyne.TextWrapWord
 	
 	return container.NewVBox(
 		widget.NewCard("连接设置", "", container.NewVBox(
@@ -1691,34 +2566,54 @@ func (a *App) createRPCSettings() fyne.CanvasObject {
 			),
 			container.NewHBox(autoReconnectCheck, widget.NewLabel("连接超时(秒):"), timeoutEntry),
 		)),
+		widget.NewCard("TLS 设置", "用于 https 和 wss 连接", container.NewVBox(
+			container.NewGridWithColumns(2,
+				widget.NewLabel("CA 证书:"), caFileEntry,
+				widget.NewLabel("客户端证书:"), certFileEntry,
+				widget.NewLabel("客户端私钥:"), keyFileEntry,
+				widget.NewLabel("证书主机名:"), serverNameEntry,
+			),
+			insecureCheck,
+		)),
+		widget.NewCard("本地 aria2c", "由 aria2GoUI 启动和停止，使用随机端口和密钥，重新启动程序后生效", container.NewVBox(
+			daemonCheck,
+			container.NewGridWithColumns(2,
+				widget.NewLabel("aria2c 路径:"), binaryEntry,
+				widget.NewLabel("额外参数:"), daemonArgsEntry,
+			),
+			daemonArgsHint,
+		)),
 	)
 }
 
 // createBasicSettings 创建基本设置界面
-func (a *App) createBasicSettings() fyne.CanvasObject {
+func (a *App) createBasicSettings(draft *config.Config) fyne.CanvasObject {
 	// 语言选择
 	languageSelect := widget.NewSelect([]string{"zh_CN", "en_US"}, nil)
-	languageSelect.SetSelected(a.config.UI.Language)
+	languageSelect.SetSelected(draft.UI.Language)
 	
 	// 主题选择
 	themeSelect := widget.NewSelect([]string{"light", "dark", "auto"}, nil)
-	themeSelect.SetSelected(a.config.UI.Theme)
+	themeSelect.SetSelected(draft.UI.Theme)
 	
 	// 页面标题
 	titleEntry := widget.NewEntry()
-	titleEntry.SetText(a.config.UI.PageTitle)
+	titleEntry.SetText(draft.UI.PageTitle)
 	
 	// 刷新间隔
 	refreshEntry := widget.NewEntry()
-	refreshEntry.SetText(fmt.Sprintf("%d", a.config.UI.RefreshInterval))
+	refreshEntry.SetText(fmt.Sprintf("%d", draft.UI.RefreshInterval))
+	refreshEntry.OnChanged = func(text string) {
+		draft.UI.RefreshInterval = a.parseInt(text)
+	}
 	
 	// 启动时恢复任务
 	continueCheck := widget.NewCheck("启动时恢复未完成任务", nil)
-	continueCheck.SetChecked(a.config.General.ContinueTasks)
+	continueCheck.SetChecked(draft.General.ContinueTasks)
 	
 	// 最小化到托盘
 	trayCheck := widget.NewCheck("最小化到系统托盘", nil)
-	trayCheck.SetChecked(a.config.General.MinimizeToTray)
+	trayCheck.SetChecked(draft.General.MinimizeToTray)
 	
 	return container.NewVBox(
 		widget.NewCard("界面设置", "", container.NewVBox(
@@ -1737,26 +2632,41 @@ func (a *App) createBasicSettings() fyne.CanvasObject {
 }
 
 // createDownloadSettings 创建下载设置界面
-func (a *App) createDownloadSettings() fyne.CanvasObject {
+func (a *App) createDownloadSettings(draft *config.Config) fyne.CanvasObject {
 	// 下载目录
 	dirEntry := widget.NewEntry()
-	dirEntry.SetText(a.config.Download.DefaultDirectory)
+	dirEntry.SetText(draft.Download.DefaultDirectory)
+	dirEntry.OnChanged = func(text string) {
+		draft.Download.DefaultDirectory = strings.TrimSpace(text)
+	}
 	
 	// 最大同时下载数
 	maxConcurrentEntry := widget.NewEntry()
-	maxConcurrentEntry.SetText(fmt.Sprintf("%d", a.config.Download.MaxConcurrentDownloads))
+	maxConcurrentEntry.SetText(fmt.Sprintf("%d", draft.Download.MaxConcurrentDownloads))
+	maxConcurrentEntry.OnChanged = func(text string) {
+		draft.Download.MaxConcurrentDownloads = a.parseInt(text)
+	}
 	
 	// 单文件最大连接数
 	maxConnEntry := widget.NewEntry()
-	maxConnEntry.SetText(fmt.Sprintf("%d", a.config.Download.MaxConnectionPerServer))
+	maxConnEntry.SetText(fmt.Sprintf("%d", draft.Download.MaxConnectionPerServer))
+	maxConnEntry.OnChanged = func(text string) {
+		draft.Download.MaxConnectionPerServer = a.parseInt(text)
+	}
 	
 	// 下载速度限制
 	downSpeedEntry := widget.NewEntry()
-	downSpeedEntry.SetText(fmt.Sprintf("%d", a.config.Download.GlobalSpeedLimit))
+	downSpeedEntry.SetText(fmt.Sprintf("%d", draft.Download.GlobalSpeedLimit))
+	downSpeedEntry.OnChanged = func(text string) {
+		draft.Download.GlobalSpeedLimit = a.parseInt(text)
+	}
 	
 	// 上传速度限制
 	upSpeedEntry := widget.NewEntry()
-	upSpeedEntry.SetText(fmt.Sprintf("%d", a.config.Download.UploadSpeedLimit))
+	upSpeedEntry.SetText(fmt.Sprintf("%d", draft.Download.UploadSpeedLimit))
+	upSpeedEntry.OnChanged = func(text string) {
+		draft.Download.UploadSpeedLimit = a.parseInt(text)
+	}
 	
 	return container.NewVBox(
 		widget.NewCard("下载路径", "", container.NewVBox(
@@ -1780,34 +2690,55 @@ func (a *App) createDownloadSettings() fyne.CanvasObject {
 }
 
 // createAdvancedSettings 创建高级设置界面
-func (a *App) createAdvancedSettings() fyne.CanvasObject {
+func (a *App) createAdvancedSettings(draft *config.Config) fyne.CanvasObject {
 	// User-Agent
 	userAgentEntry := widget.NewEntry()
-	userAgentEntry.SetText(a.config.Advanced.UserAgent)
+	userAgentEntry.SetText(draft.Advanced.UserAgent)
+	userAgentEntry.OnChanged = func(text string) {
+		draft.Advanced.UserAgent = strings.TrimSpace(text)
+	}
 	
 	// HTTP 代理
 	httpProxyEntry := widget.NewEntry()
-	httpProxyEntry.SetText(a.config.Advanced.HTTProxy)
+	httpProxyEntry.SetText(draft.Advanced.HTTProxy)
+	httpProxyEntry.OnChanged = func(text string) {
+		draft.Advanced.HTTProxy = strings.TrimSpace(text)
+	}
 	
 	// FTP 代理
 	ftpProxyEntry := widget.NewEntry()
-	ftpProxyEntry.SetText(a.config.Advanced.FTPProxy)
+	ftpProxyEntry.SetText(draft.Advanced.FTPProxy)
+	ftpProxyEntry.OnChanged = func(text string) {
+		draft.Advanced.FTPProxy = strings.TrimSpace(text)
+	}
 	
 	// BT 端口范围
 	btPortEntry := widget.NewEntry()
-	btPortEntry.SetText(a.config.Advanced.BTPortRange)
+	btPortEntry.SetText(draft.Advanced.BTPortRange)
+	btPortEntry.OnChanged = func(text string) {
+		draft.Advanced.BTPortRange = strings.TrimSpace(text)
+	}
 	
 	// DHT 支持
 	dhtCheck := widget.NewCheck("启用 DHT", nil)
-	dhtCheck.SetChecked(a.config.Advanced.DHTEnabled)
+	dhtCheck.SetChecked(draft.Advanced.DHTEnabled)
+	dhtCheck.OnChanged = func(checked bool) {
+		draft.Advanced.DHTEnabled = checked
+	}
 	
 	// PEX 支持
 	pexCheck := widget.NewCheck("启用 PEX", nil)
-	pexCheck.SetChecked(a.config.Advanced.PEXEnabled)
+	pexCheck.SetChecked(draft.Advanced.PEXEnabled)
+	pexCheck.OnChanged = func(checked bool) {
+		draft.Advanced.PEXEnabled = checked
+	}
 	
 	// 种子文件下载
 	seedCheck := widget.NewCheck("自动下载种子文件", nil)
-	seedCheck.SetChecked(a.config.Advanced.SeedDownload)
+	seedCheck.SetChecked(draft.Advanced.SeedDownload)
+	seedCheck.OnChanged = func(checked bool) {
+		draft.Advanced.SeedDownload = checked
+	}
 	
 	return container.NewVBox(
 		widget.NewCard("网络设置", "", container.NewVBox(
@@ -1817,7 +2748,7 @@ func (a *App) createAdvancedSettings() fyne.CanvasObject {
 				widget.NewLabel("FTP 代理:"), ftpProxyEntry,
 			),
 		)),
-		widget.NewCard("BitTorrent 设置", "", container.NewVBox(
+		widget.NewCard("BitTorrent 设置", "端口范围和 DHT 需要重启 aria2 后生效", container.NewVBox(
 			container.NewGridWithColumns(2,
 				widget.NewLabel("端口范围:"), btPortEntry,
 			),
@@ -1916,13 +2847,14 @@ func (a *App) ShowAndRun() {
 
 // testQuickConnection 快速测试当前连接
 func (a *App) testQuickConnection() {
-	if a.aria2Client == nil {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
 		a.showErrorMessage("未配置 aria2 连接\n请点击'设置'按钮配置连接参数")
 		return
 	}
 	
 	// 测试连接
-	if version, err := a.aria2Client.GetVersion(); err != nil {
+	if version, err := rpc.client.GetVersionContext(rpc.ctx); err != nil {
 		errorMsg := fmt.Sprintf("连接测试失败: %v", err)
 		errorMsg += fmt.Sprintf("\n\n当前配置:\n协议: %s\n地址: %s\n端口: %d\n路径: %s", 
 			a.config.RPC.Protocol, a.config.RPC.Host, a.config.RPC.Port, a.config.RPC.Path)
@@ -1936,6 +2868,11 @@ func (a *App) testQuickConnection() {
 			a.config.RPC.Protocol, a.config.RPC.Host, a.config.RPC.Port, a.config.RPC.Path)
 		a.showSuccessMessage(successMsg)
 	}
+	
+	// 同时让连接监视器重新检查，立即更新状态指示器
+	if rpc.supervisor != nil {
+		rpc.supervisor.Check()
+	}
 }
 
 // ShowConnectionDialog 显示连接设置对话框
@@ -1984,6 +2921,10 @@ func (a *App) ShowConnectionDialog() {
 		),
 	)
 	
+	// 窗口关闭时取消进行中的连接测试
+	testCtx, cancelTest := context.WithCancel(context.Background())
+	connWindow.SetOnClosed(cancelTest)
+	
 	// 测试连接按钮
 	testBtn := widget.NewButton("测试连接", func() {
 		statusLabel.SetText("正在连接...")
@@ -2010,44 +2951,61 @@ func (a *App) ShowConnectionDialog() {
 		}
 		
 		// 创建临时客户端测试连接
-		tempClient := aria2.NewClient(
+		tempClient, err := a.newAria2Client(
 			hostEntry.Text,
 			port,
 			tokenEntry.Text,
 			protocolSelect.Selected,
 			pathEntry.Text,
+			a.config.RPC.TLS,
 		)
+		if err != nil {
+			statusLabel.SetText(fmt.Sprintf("错误: TLS 设置有误: %v", err))
+			statusLabel.Refresh()
+			return
+		}
 		
 		// 详细的连接诊断
 		diagnostic := fmt.Sprintf("连接到 %s://%s:%d%s", 
 			protocolSelect.Selected, hostEntry.Text, port, pathEntry.Text)
 		
-		if version, err := tempClient.GetVersion(); err != nil {
-			// 提供更详细的错误信息
-			errorMsg := fmt.Sprintf("连接失败: %v", err)
+		// 在后台测试连接，关闭窗口时放弃尚未完成的测试
+		go func() {
+			defer tempClient.Close()
 			
-			// 根据错误类型提供建议
-			if strings.Contains(err.Error(), "connection refused") {
-				errorMsg += "\n建议: 检查 aria2 是否正在运行，端口是否正确"
-			} else if strings.Contains(err.Error(), "timeout") {
-				errorMsg += "\n建议: 检查网络连接，防火墙设置"
-			} else if strings.Contains(err.Error(), "401") || strings.Contains(err.Error(), "Unauthorized") {
-				errorMsg += "\n建议: 检查 RPC 密钥是否正确"
-			} else if strings.Contains(err.Error(), "404") {
-				errorMsg += "\n建议: 检查请求路径是否正确（默认: /jsonrpc）"
+			version, err := tempClient.GetVersionContext(testCtx)
+			if testCtx.Err() != nil {
+				return
 			}
 			
-			errorMsg += fmt.Sprintf("\n\n诊断信息:\n%s", diagnostic)
-			statusLabel.SetText(errorMsg)
-		} else {
-			successMsg := "连接成功！"
-			if version != nil && version.Version != "" {
-				successMsg += fmt.Sprintf("\naria2 版本: %s", version.Version)
+			if err != nil {
+				// 提供更详细的错误信息
+				errorMsg := fmt.Sprintf("连接失败: %v", err)
+				
+				// 根据错误类型提供建议
+				switch {
+				case isConnectionRefused(err):
+					errorMsg += "\n建议: 检查 aria2 是否正在运行，端口是否正确"
+				case isTimeoutError(err):
+					errorMsg += "\n建议: 检查网络连接，防火墙设置"
+				case errors.Is(err, aria2.ErrUnauthorized):
+					errorMsg += "\n建议: 检查 RPC 密钥是否正确"
+				case errors.Is(err, aria2.ErrNotFound):
+					errorMsg += "\n建议: 检查请求路径是否正确（默认: /jsonrpc）"
+				}
+				
+				errorMsg += fmt.Sprintf("\n\n诊断信息:\n%s", diagnostic)
+				statusLabel.SetText(errorMsg)
+			} else {
+				successMsg := "连接成功！"
+				if version != nil && version.Version != "" {
+					successMsg += fmt.Sprintf("\naria2 版本: %s", version.Version)
+				}
+				successMsg += fmt.Sprintf("\n\n连接信息:\n%s", diagnostic)
+				statusLabel.SetText(successMsg)
 			}
-			successMsg += fmt.Sprintf("\n\n连接信息:\n%s", diagnostic)
-			statusLabel.SetText(successMsg)
-		}
-		statusLabel.Refresh()
+			statusLabel.Refresh()
+		}()
 	})
 	
 	// 底部按钮
@@ -2060,8 +3018,8 @@ func (a *App) ShowConnectionDialog() {
 			a.config.RPC.Token = tokenEntry.Text
 			a.config.RPC.Path = pathEntry.Text
 			
-			// 重新连接
-			a.reconnectAria2()
+			// 在后台重新连接，结果通过消息提示
+			a.reconnectAria2(nil)
 			connWindow.Close()
 		}),
 		widget.NewButton("取消", func() {
@@ -2286,9 +3244,18 @@ func (a *App) showCreateFolderDialog(parentDir string, callback func(string)) {
 	createWindow.Show()
 }
 
-// Close 关闭应用程序
+// Close 在 ShowAndRun 返回后释放资源：停止自动刷新，让 aria2 保存会话，并断开所有连接
 func (a *App) Close() {
-	a.window.Close()
+	a.stopAutoRefresh()
+	a.stopReconnect()
+	
+	// 关闭前让 aria2 保存会话，避免未保存的任务丢失
+	a.saveSessionOnExit()
+	
+	if a.supervisor != nil {
+		a.supervisor.Stop()
+	}
+	a.closeServerClients()
 }
 
 // MyTheme 自定义主题
@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+	"github.com/chenyb888/aria2GoUI/internal/aria2/aria2test"
+)
+
+// applyMoves 按 aria2 的规则在 queue 上依次执行 changePosition 调用，返回移动后的队列
+func applyMoves(t *testing.T, queue []string, calls []aria2.Call) []string {
+	t.Helper()
+
+	order := append([]string(nil), queue...)
+	for _, call := range calls {
+		if call.Method != "aria2.changePosition" || len(call.Params) != 3 {
+			t.Fatalf("unexpected call %+v", call)
+		}
+		gid, _ := call.Params[0].(string)
+		pos, _ := call.Params[1].(int)
+		if how, _ := call.Params[2].(string); how != string(aria2.PosSet) {
+			t.Fatalf("unexpected position mode in %+v", call)
+		}
+
+		from := -1
+		for i, g := range order {
+			if g == gid {
+				from = i
+				break
+			}
+		}
+		if from < 0 {
+			t.Fatalf("%s is not in the queue %v", gid, order)
+		}
+		order = append(order[:from], order[from+1:]...)
+		if pos > len(order) {
+			pos = len(order)
+		}
+		order = append(order[:pos], append([]string{gid}, order[pos:]...)...)
+	}
+	return order
+}
+
+func TestPlanQueueMoves(t *testing.T) {
+	queue := strings.Fields("a b c d e")
+
+	tests := []struct {
+		name     string
+		move     queueMove
+		selected string
+		// want 移动后的队列，为空表示不需要任何调用
+		want string
+	}{
+		{"top contiguous", moveToTop, "c d", "c d a b e"},
+		{"top non-contiguous", moveToTop, "b d", "b d a c e"},
+		{"top partly at top", moveToTop, "a c", "a c b d e"},
+		{"top already at top", moveToTop, "a b", ""},
+		{"top single", moveToTop, "e", "e a b c d"},
+		{"up contiguous", moveUp, "c d", "a c d b e"},
+		{"up non-contiguous", moveUp, "b d", "b a d c e"},
+		{"up adjacent pairs", moveUp, "b c e", "b c a e d"},
+		{"up first at top", moveUp, "a c", "a c b d e"},
+		{"up already at top", moveUp, "a b", ""},
+		{"down contiguous", moveDown, "b c", "a d b c e"},
+		{"down non-contiguous", moveDown, "b d", "a c b e d"},
+		{"down adjacent pairs", moveDown, "a c d", "b a e c d"},
+		{"down last at bottom", moveDown, "c e", "a b d c e"},
+		{"down already at bottom", moveDown, "d e", ""},
+		{"all selected", moveUp, "a b c d e", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := make(map[string]bool)
+			for _, gid := range strings.Fields(tt.selected) {
+				selected[gid] = true
+			}
+
+			calls := planQueueMoves(queue, selected, tt.move)
+			if calls == nil {
+				t.Fatal("got nil, want calls for selected waiting tasks")
+			}
+			if tt.want == "" {
+				if len(calls) != 0 {
+					t.Errorf("got %d calls, want none", len(calls))
+				}
+				return
+			}
+
+			if got, want := applyMoves(t, queue, calls), strings.Fields(tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("queue after moves = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestPlanQueueMovesNothingSelected(t *testing.T) {
+	queue := strings.Fields("a b c")
+
+	// 没有选中任务，或选中的任务不在等待队列中（如正在下载）
+	for _, selected := range []map[string]bool{nil, {}, {"x": true}} {
+		for _, move := range []queueMove{moveToTop, moveUp, moveDown} {
+			if calls := planQueueMoves(queue, selected, move); calls != nil {
+				t.Errorf("move %d with %v: got %v, want nil", move, selected, calls)
+			}
+		}
+	}
+	if calls := planQueueMoves(nil, map[string]bool{"a": true}, moveUp); calls != nil {
+		t.Errorf("empty queue: got %v, want nil", calls)
+	}
+}
+
+func TestFetchTasksPartialFailure(t *testing.T) {
+	srv := aria2test.NewServer("")
+	defer srv.Close()
+	client := srv.Client()
+	defer client.Close()
+
+	srv.SetMaxConcurrent(1)
+	active := srv.AddTask("http://example.com/active.iso")
+	srv.AddTask("http://example.com/waiting.iso")
+
+	tasks, err := fetchTasks(context.Background(), client, "gid", "status")
+	if err != nil || len(tasks) != 2 {
+		t.Fatalf("got %d tasks, %v, want 2 tasks", len(tasks), err)
+	}
+
+	// 等待列表获取失败时返回其他列表的任务和该错误
+	srv.FailMethod("aria2.tellWaiting", 1, "Injected failure")
+	tasks, err = fetchTasks(context.Background(), client, "gid", "status")
+	var rpcErr *aria2.RPCError
+	if !errors.As(err, &rpcErr) || !strings.Contains(err.Error(), "aria2.tellWaiting") {
+		t.Errorf("got %v, want the tellWaiting failure", err)
+	}
+	if len(tasks) != 1 || tasks[0].GID != active {
+		t.Errorf("got %+v, want only the active task", tasks)
+	}
+
+	// 整个请求失败时没有任务
+	srv.ClearFailures()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if tasks, err := fetchTasks(ctx, client); tasks != nil || err == nil {
+		t.Errorf("canceled fetch returned %+v, %v", tasks, err)
+	}
+}
+
+func TestFetchWaitingQueue(t *testing.T) {
+	srv := aria2test.NewServer("")
+	defer srv.Close()
+	client := srv.Client()
+	defer client.Close()
+
+	// 超过一页的队列，以及恰好一页的队列
+	srv.SetMaxConcurrent(0)
+	for _, total := range []int{waitingPageSize, 2*waitingPageSize + 5} {
+		for len(srv.Waiting()) < total {
+			srv.AddTask("http://example.com/file.iso")
+		}
+
+		queue, err := fetchWaitingQueue(context.Background(), client, "gid")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := taskGIDs(queue), srv.Waiting(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%d tasks: got %d tasks, want the whole queue of %d in order", total, len(got), len(want))
+		}
+	}
+}
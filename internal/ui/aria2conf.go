@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+	"github.com/chenyb888/aria2GoUI/internal/config"
+)
+
+// exportAria2Conf 将设置窗口中的下载和高级设置导出为 aria2.conf
+func (a *App) exportAria2Conf(parent fyne.Window, draft *config.Config) {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			a.showErrorMessage(fmt.Sprintf("打开文件失败: %v", err))
+			return
+		}
+		if writer == nil {
+			return
+		}
+		defer writer.Close()
+
+		if err := draft.WriteAria2Conf(writer); err != nil {
+			a.showErrorMessage(fmt.Sprintf("导出 aria2.conf 失败: %v", err))
+			return
+		}
+		a.showSuccessMessage(fmt.Sprintf("已导出到 %s", writer.URI().Path()))
+	}, parent)
+	saveDialog.SetFileName("aria2.conf")
+	saveDialog.SetFilter(storage.NewExtensionFileFilter([]string{".conf"}))
+	saveDialog.Show()
+}
+
+// importAria2Conf 从 aria2.conf 导入下载和高级设置到设置窗口编辑的 draft
+// 导入后重新打开设置窗口显示新的值，点击确定后保存
+func (a *App) importAria2Conf(settingsWindow fyne.Window, draft *config.Config) {
+	openDialog := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			a.showErrorMessage(fmt.Sprintf("打开文件失败: %v", err))
+			return
+		}
+		if reader == nil {
+			return
+		}
+		defer reader.Close()
+
+		options, err := config.ParseAria2Conf(reader)
+		if err != nil {
+			a.showErrorMessage(fmt.Sprintf("读取 aria2.conf 失败: %v", err))
+			return
+		}
+		if err := draft.ImportAria2Options(options); err != nil {
+			a.showErrorMessage(fmt.Sprintf("导入 aria2.conf 失败: %v", err))
+			return
+		}
+
+		a.showSuccessMessage(fmt.Sprintf("已导入 %d 个选项，点击确定保存", len(options)))
+		settingsWindow.Close()
+		a.showSettingsWindow(draft)
+	}, settingsWindow)
+	openDialog.SetFilter(storage.NewExtensionFileFilter([]string{".conf"}))
+	openDialog.Show()
+}
+
+// optionChange 一次保存设置对下载和高级设置的改动
+type optionChange struct {
+	// previous 和 current 为保存前后的 Aria2OptionValues
+	previous map[string]string
+	current  map[string]string
+	// skipDir 当前服务器配置有自己的下载目录，不发送下载设置中的默认目录
+	skipDir bool
+	// restartApplies 只在启动时读取的选项能否在重启后生效，即当前服务器为读取生成的 aria2.conf 的托管 aria2c
+	restartApplies bool
+}
+
+// newOptionChange 比较保存前的选项 previous 与当前配置，在 ApplySettings 之后调用
+func (a *App) newOptionChange(previous map[string]string) optionChange {
+	change := optionChange{
+		previous:       previous,
+		current:        a.config.Aria2OptionValues(),
+		restartApplies: a.config.UsesGeneratedConf(),
+	}
+	if profile := a.config.FindProfile(a.config.ActiveProfile); profile != nil && profile.DownloadDir != "" {
+		change.skipDir = true
+	}
+	return change
+}
+
+// keys 返回需要处理的选项：本次改动或删除的选项，以及 pending 中之前未能生效的选项
+func (c optionChange) keys(pending map[string]bool) map[string]bool {
+	keys := make(map[string]bool)
+	for key, value := range c.current {
+		if previous, ok := c.previous[key]; !ok || previous != value {
+			keys[key] = true
+		}
+	}
+	for key := range c.previous {
+		if _, ok := c.current[key]; !ok {
+			keys[key] = true
+		}
+	}
+	for key := range pending {
+		keys[key] = true
+	}
+	if c.skipDir {
+		delete(keys, "dir")
+	}
+	return keys
+}
+
+// applyAria2Options 通过 changeGlobalOption 将本次改动的下载和高级设置应用到运行中的 aria2
+// 每个选项单独调用，逐项报告失败；只在启动时读取的选项不发送，提示需要重启 aria2。
+// 发送失败的选项，以及当前服务器重启后也不会读取的选项，记入 pendingOptions 在下次保存设置时重试和提示
+func (a *App) applyAria2Options(change optionChange) {
+	a.namesMu.Lock()
+	keys := change.keys(a.pendingOptions)
+	a.namesMu.Unlock()
+
+	var changed, restart []string
+	for key := range keys {
+		// 从设置中删除的选项（如清空代理）不发送：aria2 的数值选项不接受空值，
+		// 运行中的 aria2 保留原来的值，重启后恢复默认行为
+		if _, ok := change.current[key]; ok && !aria2.RestartRequired(key) {
+			changed = append(changed, key)
+		} else {
+			restart = append(restart, key)
+		}
+	}
+	if len(changed) == 0 && len(restart) == 0 {
+		return
+	}
+	sort.Strings(changed)
+	sort.Strings(restart)
+
+	var failures []string
+	applied := make(map[string]bool, len(changed))
+	if len(changed) > 0 {
+		calls := make([]aria2.Call, len(changed))
+		for i, key := range changed {
+			calls[i] = aria2.Call{
+				Method: "aria2.changeGlobalOption",
+				Params: []interface{}{aria2.Options{key: change.current[key]}},
+			}
+		}
+
+		rpc := a.currentRPC()
+		if rpc.client == nil {
+			failures = append(failures, "未连接到 aria2")
+		} else if results, err := rpc.client.MulticallContext(rpc.ctx, calls); err != nil {
+			rpc.reportError(err)
+			failures = append(failures, err.Error())
+		} else {
+			for i, result := range results {
+				key := changed[i]
+				if result.Err != nil {
+					failures = append(failures, fmt.Sprintf("%s: %v", key, result.Err))
+					continue
+				}
+				applied[key] = true
+			}
+		}
+	}
+
+	a.namesMu.Lock()
+	for _, key := range changed {
+		a.setOptionPending(key, !applied[key])
+	}
+	// 托管的本地 aria2c 下次启动时通过生成的 aria2.conf 读取；其他情况下一直提示
+	for _, key := range restart {
+		a.setOptionPending(key, !change.restartApplies)
+	}
+	a.namesMu.Unlock()
+
+	var lines []string
+	if len(applied) > 0 {
+		lines = append(lines, fmt.Sprintf("已将 %d 项设置应用到 aria2", len(applied)))
+	}
+	if len(restart) > 0 {
+		if change.restartApplies {
+			lines = append(lines, "以下设置需要重启 aria2 后生效: "+strings.Join(restart, ", "))
+		} else {
+			lines = append(lines, "以下设置只在 aria2 启动时读取，当前服务器不使用生成的 aria2.conf，需要在服务器上修改: "+strings.Join(restart, ", "))
+		}
+	}
+	if len(failures) > 0 {
+		lines = append(lines, "以下设置应用失败:\n"+strings.Join(failures, "\n"))
+		a.showErrorMessage(strings.Join(lines, "\n"))
+		return
+	}
+	a.showSuccessMessage(strings.Join(lines, "\n"))
+}
+
+// setOptionPending 记录选项是否仍未在当前服务器上生效，调用方需持有 namesMu
+func (a *App) setOptionPending(key string, pending bool) {
+	if pending {
+		a.pendingOptions[key] = true
+	} else {
+		delete(a.pendingOptions, key)
+	}
+}
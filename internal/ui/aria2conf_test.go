@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptionChangeKeys(t *testing.T) {
+	previous := map[string]string{"dir": "/downloads", "max-concurrent-downloads": "5", "all-proxy": "http://proxy:3128"}
+
+	tests := []struct {
+		name    string
+		current map[string]string
+		pending map[string]bool
+		skipDir bool
+		want    map[string]bool
+	}{
+		// 没有改动时不发送任何选项
+		{"unchanged", map[string]string{"dir": "/downloads", "max-concurrent-downloads": "5", "all-proxy": "http://proxy:3128"}, nil, false, map[string]bool{}},
+		{"changed", map[string]string{"dir": "/downloads", "max-concurrent-downloads": "3", "all-proxy": "http://proxy:3128"}, nil, false, map[string]bool{"max-concurrent-downloads": true}},
+		{"removed", map[string]string{"dir": "/downloads", "max-concurrent-downloads": "5"}, nil, false, map[string]bool{"all-proxy": true}},
+		{"added", map[string]string{"dir": "/downloads", "max-concurrent-downloads": "5", "all-proxy": "http://proxy:3128", "split": "8"}, nil, false, map[string]bool{"split": true}},
+		// 之前未生效的选项即使本次没有改动也再次处理
+		{"pending", map[string]string{"dir": "/downloads", "max-concurrent-downloads": "5", "all-proxy": "http://proxy:3128"}, map[string]bool{"all-proxy": true}, false, map[string]bool{"all-proxy": true}},
+		// 服务器配置有自己的下载目录时不发送默认目录
+		{"skip dir", map[string]string{"dir": "/other", "max-concurrent-downloads": "5", "all-proxy": "http://proxy:3128"}, map[string]bool{"dir": true}, true, map[string]bool{}},
+		{"dir", map[string]string{"dir": "/other", "max-concurrent-downloads": "5", "all-proxy": "http://proxy:3128"}, nil, false, map[string]bool{"dir": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := optionChange{previous: previous, current: tt.current, skipDir: tt.skipDir}
+			if got := change.keys(tt.pending); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+	"github.com/chenyb888/aria2GoUI/internal/cleanup"
+	"github.com/chenyb888/aria2GoUI/internal/config"
+)
+
+// taskFileKeys 删除任务文件所需的字段
+var taskFileKeys = []string{"dir", "files", "infoHash", "bittorrent"}
+
+// removedTaskTimeout 等待 aria2 停止已删除任务的最长时间
+const removedTaskTimeout = 10 * time.Second
+
+// maxCleanupErrors 错误提示中最多列出的文件数
+const maxCleanupErrors = 10
+
+// deleteTaskFiles 删除已从 aria2 移除的任务的文件
+// 按服务器配置的路径映射找到本机上的文件，远程服务器没有对应的路径映射时不删除任何文件；
+// 正在下载的任务需要等 aria2 关闭文件后才能删除，等待和删除在后台进行，
+// 无法确认已停止的任务不删除其文件
+func (a *App) deleteTaskFiles(removed []selectedTasks, useTrash bool) {
+	type cleanupGroup struct {
+		rpc   rpcState
+		tasks []aria2.TellStatus
+		// targets 按 GID 记录需要删除的文件，names 为报告时显示的任务名称
+		targets map[string]cleanup.Task
+		names   map[string]string
+	}
+
+	// 服务器配置在界面线程中读取，切换服务器不影响后台删除
+	var groups []cleanupGroup
+	var errs []error
+	for _, group := range removed {
+		profile := a.profileFor(group.server)
+		g := cleanupGroup{
+			rpc:     group.rpc,
+			tasks:   group.tasks,
+			targets: make(map[string]cleanup.Task),
+			names:   make(map[string]string),
+		}
+		for _, task := range group.tasks {
+			target, err := cleanup.FromStatus(task, profile.LocalPath)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			g.targets[task.GID] = target
+			g.names[task.GID] = a.serverTaskName(group.server, task)
+		}
+		groups = append(groups, g)
+	}
+
+	go func() {
+		result := cleanup.Result{Errors: errs}
+		for _, group := range groups {
+			if len(group.targets) == 0 {
+				continue
+			}
+			for _, gid := range a.waitTasksStopped(group.rpc, group.tasks) {
+				if _, ok := group.targets[gid]; ok {
+					delete(group.targets, gid)
+					result.Errors = append(result.Errors,
+						fmt.Errorf("%s: 无法确认 aria2 已停止该任务，未删除其文件", group.names[gid]))
+				}
+			}
+
+			for _, task := range group.tasks {
+				target, ok := group.targets[task.GID]
+				if !ok {
+					continue
+				}
+				r := cleanup.Remove(target, useTrash)
+				result.Trashed = append(result.Trashed, r.Trashed...)
+				result.Deleted = append(result.Deleted, r.Deleted...)
+				result.Dirs = append(result.Dirs, r.Dirs...)
+				result.Errors = append(result.Errors, r.Errors...)
+			}
+		}
+		a.reportCleanup(result)
+	}()
+}
+
+// reportCleanup 显示删除文件的结果
+func (a *App) reportCleanup(result cleanup.Result) {
+	var lines []string
+	if result.Count() > 0 {
+		line := fmt.Sprintf("已删除 %d 个文件", result.Count())
+		if len(result.Trashed) > 0 {
+			line += fmt.Sprintf("，其中 %d 个放入回收站", len(result.Trashed))
+		}
+		lines = append(lines, line)
+	}
+	if len(result.Errors) == 0 {
+		if len(lines) == 0 {
+			lines = append(lines, "没有找到需要删除的文件")
+		}
+		a.showSuccessMessage(strings.Join(lines, "\n"))
+		return
+	}
+
+	lines = append(lines, "以下文件未删除:")
+	for i, err := range result.Errors {
+		if i == maxCleanupErrors {
+			lines = append(lines, fmt.Sprintf("……共 %d 项", len(result.Errors)))
+			break
+		}
+		lines = append(lines, err.Error())
+	}
+	a.showErrorMessage(strings.Join(lines, "\n"))
+}
+
+// profileFor 返回任务所属的服务器配置，server 为空表示当前服务器
+// 找不到配置时按 RPC 设置中的地址判断是否为本机
+func (a *App) profileFor(server string) *config.ServerProfile {
+	if server == "" {
+		server = a.clientProfile
+	}
+	if profile := a.config.FindProfile(server); profile != nil {
+		copied := *profile
+		return &copied
+	}
+	return &config.ServerProfile{Host: a.config.RPC.Host}
+}
+
+// waitTasksStopped 等待 aria2 停止已调用 aria2.remove 的任务，返回无法确认已停止的任务的 GID
+// aria2.remove 返回时下载可能尚未停止，此时删除文件会删掉 aria2 仍在写入的文件。
+// 只有 aria2 报告任务不存在或已停止才算停止；超时、传输错误以及切换服务器取消了调用时都无法确认
+func (a *App) waitTasksStopped(rpc rpcState, tasks []aria2.TellStatus) []string {
+	var pending []string
+	for _, task := range tasks {
+		if removeMethodFor(task) == "aria2.remove" {
+			pending = append(pending, task.GID)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(rpc.ctx, removedTaskTimeout)
+	defer cancel()
+
+	for {
+		var running []string
+		for _, gid := range pending {
+			task, err := rpc.client.TellStatusContext(ctx, gid, "status")
+			switch {
+			case errors.Is(err, aria2.ErrTaskNotFound):
+				// 任务的下载结果已被移除
+			case err != nil:
+				running = append(running, gid)
+			case task.Status != "removed" && task.Status != "complete" && task.Status != "error":
+				running = append(running, gid)
+			}
+		}
+		pending = running
+		if len(pending) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return pending
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
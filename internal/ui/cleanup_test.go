@@ -0,0 +1,95 @@
+package ui
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+	"github.com/chenyb888/aria2GoUI/internal/aria2/aria2test"
+)
+
+func TestWaitTasksStopped(t *testing.T) {
+	srv := aria2test.NewServer("")
+	defer srv.Close()
+	client := srv.Client()
+	defer client.Close()
+
+	srv.SetMaxConcurrent(4)
+	removed := srv.AddTask("http://example.com/removed.iso")
+	purged := srv.AddTask("http://example.com/purged.iso")
+	running := srv.AddTask("http://example.com/running.iso")
+	if err := client.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Remove(purged); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.RemoveDownloadResult(purged); err != nil {
+		t.Fatal(err)
+	}
+
+	// 调用 aria2.remove 时任务仍在下载，之后停止或下载结果被移除都算已停止
+	tasks := []aria2.TellStatus{
+		{GID: removed, Status: "active"},
+		{GID: purged, Status: "active"},
+		{GID: running, Status: "active"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	got := (&App{}).waitTasksStopped(rpcState{client: client, ctx: ctx}, tasks)
+	if want := []string{running}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unconfirmed = %v, want %v", got, want)
+	}
+}
+
+func TestWaitTasksStoppedUnconfirmed(t *testing.T) {
+	srv := aria2test.NewServer("")
+	defer srv.Close()
+	client := srv.Client()
+	defer client.Close()
+
+	gid := srv.AddTask("http://example.com/file.iso")
+	if err := client.Remove(gid); err != nil {
+		t.Fatal(err)
+	}
+	tasks := []aria2.TellStatus{{GID: gid, Status: "active"}}
+
+	t.Run("rpc error", func(t *testing.T) {
+		// 除任务不存在以外的错误不能说明任务已停止
+		srv.FailMethod("aria2.tellStatus", 1, "Internal error")
+		defer srv.ClearFailures()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		got := (&App{}).waitTasksStopped(rpcState{client: client, ctx: ctx}, tasks)
+		if want := []string{gid}; !reflect.DeepEqual(got, want) {
+			t.Errorf("unconfirmed = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		// 切换服务器取消了调用
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		start := time.Now()
+		got := (&App{}).waitTasksStopped(rpcState{client: client, ctx: ctx}, tasks)
+		if want := []string{gid}; !reflect.DeepEqual(got, want) {
+			t.Errorf("unconfirmed = %v, want %v", got, want)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("waited %v after the context was canceled", elapsed)
+		}
+	})
+
+	t.Run("stopped tasks", func(t *testing.T) {
+		// 调用 aria2.removeDownloadResult 的任务本来就已停止，无需等待
+		stopped := []aria2.TellStatus{{GID: "0123456789abcdef", Status: "complete"}}
+		if got := (&App{}).waitTasksStopped(rpcState{client: client, ctx: context.Background()}, stopped); got != nil {
+			t.Errorf("unconfirmed = %v, want none", got)
+		}
+	})
+}
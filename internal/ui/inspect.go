@@ -0,0 +1,180 @@
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+// taskInspector 任务详情中的文件、服务器、节点和 URI 页面
+type taskInspector struct {
+	app *App
+	rpc rpcState
+
+	// gid 当前显示的任务，定时刷新在后台协程中读取
+	mu  sync.Mutex
+	gid string
+
+	files   *fyne.Container
+	servers *fyne.Container
+	peers   *fyne.Container
+	uris    *fyne.Container
+}
+
+// newTaskInspector 创建任务检查页面，rpc 为任务所在服务器的客户端快照
+func newTaskInspector(a *App, rpc rpcState) *taskInspector {
+	return &taskInspector{
+		app:     a,
+		rpc:     rpc,
+		files:   container.NewVBox(),
+		servers: container.NewVBox(),
+		peers:   container.NewVBox(),
+		uris:    container.NewVBox(),
+	}
+}
+
+// tabs 返回检查页面对应的选项卡
+func (t *taskInspector) tabs() []*container.TabItem {
+	return []*container.TabItem{
+		container.NewTabItem("文件", container.NewScroll(t.files)),
+		container.NewTabItem("服务器", container.NewScroll(t.servers)),
+		container.NewTabItem("节点", container.NewScroll(t.peers)),
+		container.NewTabItem("URI", container.NewScroll(t.uris)),
+	}
+}
+
+// show 切换到指定任务并立即刷新
+func (t *taskInspector) show(gid string) {
+	t.mu.Lock()
+	t.gid = gid
+	t.mu.Unlock()
+
+	t.refresh()
+}
+
+// refresh 重新获取当前任务的文件、服务器、节点和 URI
+func (t *taskInspector) refresh() {
+	t.mu.Lock()
+	gid := t.gid
+	t.mu.Unlock()
+
+	a := t.app
+	if gid == "" {
+		return
+	}
+
+	// 四项信息合并为一次请求；HTTP 任务没有节点，BT 任务没有服务器，单项失败互不影响
+	results, err := t.rpc.client.MulticallContext(t.rpc.ctx, []aria2.Call{
+		{Method: "aria2.getFiles", Params: []interface{}{gid}},
+		{Method: "aria2.getServers", Params: []interface{}{gid}},
+		{Method: "aria2.getPeers", Params: []interface{}{gid}},
+		{Method: "aria2.getUris", Params: []interface{}{gid}},
+	})
+	if err != nil {
+		message := fmt.Sprintf("获取信息失败: %v", err)
+		for _, c := range []*fyne.Container{t.files, t.servers, t.peers, t.uris} {
+			setTableContent(c, nil, [][]string{{message}})
+		}
+		return
+	}
+
+	var files []aria2.FileInfo
+	if err := results[0].Decode(&files); err != nil {
+		setTableContent(t.files, nil, [][]string{{err.Error()}})
+	} else {
+		rows := make([][]string, 0, len(files))
+		for _, file := range files {
+			rows = append(rows, []string{
+				fmt.Sprintf("%d", file.Index),
+				filepath.Base(file.Path),
+				a.formatSize(float64(file.Length)),
+				fmt.Sprintf("%.1f%%", file.Progress()*100),
+				file.Selected,
+			})
+		}
+		setTableContent(t.files, []string{"序号", "文件", "大小", "进度", "选中"}, rows)
+	}
+
+	var servers []aria2.FileServers
+	if err := results[1].Decode(&servers); err != nil {
+		setTableContent(t.servers, nil, [][]string{{"没有服务器信息（BitTorrent 任务或任务未在下载）"}})
+	} else {
+		var rows [][]string
+		for _, file := range servers {
+			for _, server := range file.Servers {
+				rows = append(rows, []string{
+					fmt.Sprintf("%d", file.Index),
+					server.CurrentURI,
+					a.formatSpeed(float64(server.DownloadSpeed)),
+				})
+			}
+		}
+		setTableContent(t.servers, []string{"文件", "当前地址", "下载速度"}, rows)
+	}
+
+	var peers []aria2.Peer
+	if err := results[2].Decode(&peers); err != nil {
+		setTableContent(t.peers, nil, [][]string{{"没有节点信息（非 BitTorrent 任务）"}})
+	} else {
+		rows := make([][]string, 0, len(peers))
+		for _, peer := range peers {
+			rows = append(rows, []string{
+				fmt.Sprintf("%s:%s", peer.IP, peer.Port),
+				a.formatSpeed(float64(peer.DownloadSpeed)),
+				a.formatSpeed(float64(peer.UploadSpeed)),
+				peer.AmChoking,
+				peer.PeerChoking,
+				peer.Seeder,
+			})
+		}
+		setTableContent(t.peers, []string{"地址", "下载", "上传", "阻塞对方", "被阻塞", "做种"}, rows)
+	}
+
+	var uris []aria2.URI
+	if err := results[3].Decode(&uris); err != nil {
+		setTableContent(t.uris, nil, [][]string{{err.Error()}})
+	} else {
+		rows := make([][]string, 0, len(uris))
+		for _, uri := range uris {
+			rows = append(rows, []string{uri.Status, uri.URI})
+		}
+		setTableContent(t.uris, []string{"状态", "URI"}, rows)
+	}
+}
+
+// setTableContent 用标签网格显示表格内容，headers 为空时每行只显示一列
+func setTableContent(c *fyne.Container, headers []string, rows [][]string) {
+	columns := len(headers)
+	if columns == 0 {
+		columns = 1
+	}
+
+	var cells []fyne.CanvasObject
+	for _, header := range headers {
+		label := widget.NewLabel(header)
+		label.TextStyle = fyne.TextStyle{Bold: true}
+		cells = append(cells, label)
+	}
+	for _, row := range rows {
+		for i := 0; i < columns; i++ {
+			text := ""
+			if i < len(row) {
+				text = row[i]
+			}
+			cells = append(cells, widget.NewLabel(text))
+		}
+	}
+
+	if len(rows) == 0 {
+		c.Objects = []fyne.CanvasObject{container.NewGridWithColumns(columns, cells...), widget.NewLabel("暂无数据")}
+	} else {
+		c.Objects = []fyne.CanvasObject{container.NewGridWithColumns(columns, cells...)}
+	}
+	c.Refresh()
+}
@@ -0,0 +1,172 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+// optionsEditor aria2 选项编辑器
+// 列出全部选项供修改，保存时只提交改动过的选项
+type optionsEditor struct {
+	load func() (aria2.Options, error)
+	save func(aria2.Options) error
+
+	original aria2.Options
+	entries  map[string]*widget.Entry
+
+	filterEntry *widget.Entry
+	form        *fyne.Container
+	statusLabel *widget.Label
+	content     fyne.CanvasObject
+}
+
+// newOptionsEditor 创建选项编辑器，load 和 save 分别负责读取和提交选项
+func newOptionsEditor(load func() (aria2.Options, error), save func(aria2.Options) error) *optionsEditor {
+	e := &optionsEditor{
+		load:        load,
+		save:        save,
+		entries:     make(map[string]*widget.Entry),
+		filterEntry: widget.NewEntry(),
+		form:        container.NewVBox(),
+		statusLabel: widget.NewLabel(""),
+	}
+
+	// 选项较多，按名称过滤
+	e.filterEntry.SetPlaceHolder("按选项名过滤，例如 max-download-limit")
+	e.filterEntry.OnChanged = func(string) {
+		e.rebuildForm()
+	}
+
+	buttons := container.NewHBox(
+		widget.NewButtonWithIcon("保存选项", theme.DocumentSaveIcon(), func() {
+			e.apply()
+		}),
+		widget.NewButtonWithIcon("重新读取", theme.ViewRefreshIcon(), func() {
+			e.reload()
+		}),
+		e.statusLabel,
+	)
+
+	e.content = container.NewBorder(
+		e.filterEntry,
+		buttons,
+		nil,
+		nil,
+		container.NewVScroll(e.form),
+	)
+
+	return e
+}
+
+// reload 从 aria2 重新读取选项
+func (e *optionsEditor) reload() {
+	options, err := e.load()
+	if err != nil {
+		e.original = nil
+		e.entries = make(map[string]*widget.Entry)
+		e.rebuildForm()
+		e.statusLabel.SetText(fmt.Sprintf("读取选项失败: %v", err))
+		return
+	}
+
+	e.original = options
+	e.entries = make(map[string]*widget.Entry, len(options))
+	for key, value := range options {
+		entry := widget.NewEntry()
+		entry.SetText(value)
+		e.entries[key] = entry
+	}
+
+	e.rebuildForm()
+	e.statusLabel.SetText(fmt.Sprintf("共 %d 项选项", len(options)))
+}
+
+// rebuildForm 按过滤条件重新排列选项
+func (e *optionsEditor) rebuildForm() {
+	filter := strings.ToLower(strings.TrimSpace(e.filterEntry.Text))
+
+	keys := make([]string, 0, len(e.entries))
+	for key := range e.entries {
+		if filter == "" || strings.Contains(key, filter) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	objects := make([]fyne.CanvasObject, 0, len(keys)*2)
+	for _, key := range keys {
+		objects = append(objects, widget.NewLabel(key), e.entries[key])
+	}
+
+	e.form.Objects = []fyne.CanvasObject{container.NewGridWithColumns(2, objects...)}
+	e.form.Refresh()
+}
+
+// apply 提交改动过的选项
+func (e *optionsEditor) apply() {
+	changed := aria2.Options{}
+	for key, entry := range e.entries {
+		if entry.Text != e.original[key] {
+			changed[key] = entry.Text
+		}
+	}
+
+	if len(changed) == 0 {
+		e.statusLabel.SetText("选项没有变化")
+		return
+	}
+
+	if err := e.save(changed); err != nil {
+		e.statusLabel.SetText(fmt.Sprintf("保存选项失败: %v", err))
+		return
+	}
+
+	for key, value := range changed {
+		e.original[key] = value
+	}
+	e.statusLabel.SetText(fmt.Sprintf("已修改 %d 项选项", len(changed)))
+}
+
+// showGlobalOptionsDialog 显示 aria2 全局选项编辑窗口
+// 窗口中的读取和修改都发往打开窗口时连接的 aria2
+func (a *App) showGlobalOptionsDialog() {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
+		a.showErrorMessage("未连接到 aria2 服务")
+		return
+	}
+
+	optionsWindow := a.fyneApp.NewWindow("aria2 全局选项")
+	optionsWindow.Resize(fyne.NewSize(600, 500))
+
+	editor := newOptionsEditor(
+		func() (aria2.Options, error) {
+			return rpc.client.GetGlobalOptionContext(rpc.ctx)
+		},
+		func(options aria2.Options) error {
+			return rpc.client.ChangeGlobalOptionContext(rpc.ctx, options)
+		},
+	)
+	editor.reload()
+
+	mainContainer := container.NewBorder(
+		widget.NewLabel("修改后立即对运行中的 aria2 生效，无需重启"),
+		widget.NewButton("关闭", func() {
+			optionsWindow.Close()
+		}),
+		nil,
+		nil,
+		editor.content,
+	)
+
+	optionsWindow.SetContent(mainContainer)
+	optionsWindow.Show()
+}
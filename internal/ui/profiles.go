@@ -0,0 +1,315 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/chenyb888/aria2GoUI/internal/config"
+)
+
+// profileState 每个服务器配置各自的界面状态，切换回该服务器时恢复
+type profileState struct {
+	selection      *taskSelection
+	taskNames      map[string]string
+	autoRefresh    bool
+	pendingOptions map[string]bool
+}
+
+// swapProfileState 保存当前客户端所属服务器的界面状态，换成当前服务器配置的状态
+// 在更换客户端时调用；第一次使用的服务器从空状态开始
+func (a *App) swapProfileState() {
+	a.namesMu.Lock()
+	defer a.namesMu.Unlock()
+
+	if a.clientProfile != "" {
+		a.profileStates[a.clientProfile] = &profileState{
+			selection:      a.selection,
+			taskNames:      a.taskNames,
+			autoRefresh:    a.autoRefresh,
+			pendingOptions: a.pendingOptions,
+		}
+	}
+
+	name := a.config.ActiveProfile
+	state, ok := a.profileStates[name]
+	if !ok {
+		state = newProfileState()
+	}
+	a.selection = state.selection
+	a.taskNames = state.taskNames
+	a.autoRefresh = state.autoRefresh
+	a.pendingOptions = state.pendingOptions
+	a.clientProfile = name
+}
+
+// newProfileState 创建第一次使用的服务器的界面状态
+func newProfileState() *profileState {
+	return &profileState{
+		selection:      newTaskSelection(),
+		taskNames:      make(map[string]string),
+		autoRefresh:    true,
+		pendingOptions: make(map[string]bool),
+	}
+}
+
+// namesFor 返回服务器的任务名称缓存，server 为空表示当前服务器，调用方需持有 namesMu
+func (a *App) namesFor(server string) map[string]string {
+	if server == "" || server == a.clientProfile {
+		return a.taskNames
+	}
+
+	state, ok := a.profileStates[server]
+	if !ok {
+		state = newProfileState()
+		a.profileStates[server] = state
+	}
+	return state.taskNames
+}
+
+// createProfileSwitcher 创建工具栏中的服务器切换器
+func (a *App) createProfileSwitcher() fyne.CanvasObject {
+	profileSelect := widget.NewSelect(a.config.ProfileNames(), nil)
+	profileSelect.SetSelected(a.config.ActiveProfile)
+	// 设置初始值之后再绑定，避免创建界面时触发切换
+	profileSelect.OnChanged = func(name string) {
+		a.switchProfile(name)
+	}
+
+	combinedCheck := widget.NewCheck("合并视图", nil)
+	combinedCheck.SetChecked(a.combinedView)
+	combinedCheck.OnChanged = func(checked bool) {
+		a.setCombinedView(checked)
+	}
+
+	return container.NewHBox(
+		widget.NewLabel("服务器:"),
+		profileSelect,
+		widget.NewButtonWithIcon("", theme.StorageIcon(), func() {
+			a.showProfilesDialog()
+		}),
+		combinedCheck,
+	)
+}
+
+// switchProfile 切换到另一个服务器配置，连接失败时留在原来的服务器
+func (a *App) switchProfile(name string) {
+	if name == a.config.ActiveProfile {
+		return
+	}
+
+	previous := a.config.ActiveProfile
+	if err := a.config.UseProfile(name); err != nil {
+		a.showErrorMessage(err.Error())
+		return
+	}
+
+	a.reconnectAria2(func(ok bool) {
+		if !ok {
+			a.config.UseProfile(previous)
+			// 重建界面，让切换器回到原来的服务器
+			a.CreateMainUI()
+			return
+		}
+
+		// 记住上次使用的服务器，下次启动时直接连接
+		if err := a.config.SaveConfig(getConfigPath()); err != nil {
+			a.showErrorMessage(fmt.Sprintf("保存配置失败: %v", err))
+		}
+	})
+}
+
+// showProfilesDialog 显示服务器配置管理窗口
+func (a *App) showProfilesDialog() {
+	profileWindow := a.fyneApp.NewWindow("服务器配置")
+	profileWindow.Resize(fyne.NewSize(600, 400))
+
+	nameEntry := widget.NewEntry()
+	hostEntry := widget.NewEntry()
+	portEntry := widget.NewEntry()
+	protocolSelect := widget.NewSelect([]string{"http", "https", "ws", "wss"}, nil)
+	tokenEntry := widget.NewPasswordEntry()
+	pathEntry := widget.NewEntry()
+	dirEntry := widget.NewEntry()
+	dirEntry.SetPlaceHolder("留空使用下载设置中的目录")
+	// 删除任务文件时用来找到远程服务器的文件在本机上的位置，每行一条
+	mappingsEntry := widget.NewMultiLineEntry()
+	mappingsEntry.SetPlaceHolder("/downloads => /mnt/nas/downloads")
+	statusLabel := widget.NewLabel("")
+
+	// editing 正在编辑的服务器配置名称，为空表示新建
+	editing := ""
+
+	fillForm := func(profile config.ServerProfile) {
+		nameEntry.SetText(profile.Name)
+		hostEntry.SetText(profile.Host)
+		portEntry.SetText(fmt.Sprintf("%d", profile.Port))
+		protocolSelect.SetSelected(profile.Protocol)
+		tokenEntry.SetText(profile.Token)
+		pathEntry.SetText(profile.Path)
+		dirEntry.SetText(profile.DownloadDir)
+		mappingsEntry.SetText(config.FormatPathMappings(profile.PathMappings))
+	}
+
+	newProfile := func() {
+		editing = ""
+		nameEntry.Enable()
+		fillForm(config.ServerProfile{
+			Host:     "localhost",
+			Port:     6800,
+			Path:     "/jsonrpc",
+			Protocol: "http",
+		})
+		statusLabel.SetText("新建服务器配置")
+	}
+
+	profileList := widget.NewList(
+		func() int {
+			return len(a.config.Profiles)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			if id >= len(a.config.Profiles) {
+				return
+			}
+			name := a.config.Profiles[id].Name
+			if name == a.config.ActiveProfile {
+				name += "（当前）"
+			}
+			obj.(*widget.Label).SetText(name)
+		},
+	)
+	profileList.OnSelected = func(id widget.ListItemID) {
+		if id >= len(a.config.Profiles) {
+			return
+		}
+		profile := a.config.Profiles[id]
+		editing = profile.Name
+		// 名称用于记住界面状态和上次使用的服务器，已有配置不允许改名
+		nameEntry.Disable()
+		fillForm(profile)
+		statusLabel.SetText("")
+	}
+
+	// saveConfig 保存配置文件并刷新列表和工具栏
+	saveConfig := func(message string) {
+		profileList.UnselectAll()
+		profileList.Refresh()
+		// 合并视图中的客户端按修改后的配置重新创建
+		a.closeServerClients()
+		if err := a.config.SaveConfig(getConfigPath()); err != nil {
+			statusLabel.SetText(fmt.Sprintf("保存配置失败: %v", err))
+			return
+		}
+		statusLabel.SetText(message)
+		a.CreateMainUI()
+	}
+
+	saveProfile := func() {
+		port := a.parseInt(portEntry.Text)
+		if strings.TrimSpace(hostEntry.Text) == "" {
+			statusLabel.SetText("错误: 请输入 RPC 地址")
+			return
+		}
+		if port <= 0 || port > 65535 {
+			statusLabel.SetText("错误: 端口必须在 1-65535 范围内")
+			return
+		}
+		mappings, err := config.ParsePathMappings(mappingsEntry.Text)
+		if err != nil {
+			statusLabel.SetText(fmt.Sprintf("错误: %v", err))
+			return
+		}
+
+		profile := config.ServerProfile{
+			Name:         strings.TrimSpace(nameEntry.Text),
+			Host:         strings.TrimSpace(hostEntry.Text),
+			Port:         port,
+			Token:        tokenEntry.Text,
+			Path:         pathEntry.Text,
+			Protocol:     protocolSelect.Selected,
+			DownloadDir:  strings.TrimSpace(dirEntry.Text),
+			PathMappings: mappings,
+		}
+
+		if editing == "" {
+			if err := a.config.AddProfile(profile); err != nil {
+				statusLabel.SetText(fmt.Sprintf("错误: %v", err))
+				return
+			}
+			saveConfig(fmt.Sprintf("已添加服务器配置 %s", profile.Name))
+			newProfile()
+			return
+		}
+
+		// TLS 设置在设置窗口中编辑，这里保留原值
+		if existing := a.config.FindProfile(editing); existing != nil {
+			profile.TLS = existing.TLS
+		}
+		if err := a.config.UpdateProfile(profile); err != nil {
+			statusLabel.SetText(fmt.Sprintf("错误: %v", err))
+			return
+		}
+		saveConfig(fmt.Sprintf("已保存服务器配置 %s", profile.Name))
+
+		// 修改的是当前服务器，使用新设置重新连接
+		if profile.Name == a.config.ActiveProfile {
+			a.reconnectAria2(nil)
+		}
+	}
+
+	removeProfile := func() {
+		if editing == "" {
+			statusLabel.SetText("请先在左侧选择要删除的服务器配置")
+			return
+		}
+		if err := a.config.RemoveProfile(editing); err != nil {
+			statusLabel.SetText(fmt.Sprintf("错误: %v", err))
+			return
+		}
+
+		a.namesMu.Lock()
+		delete(a.profileStates, editing)
+		a.namesMu.Unlock()
+
+		saveConfig(fmt.Sprintf("已删除服务器配置 %s", editing))
+		newProfile()
+	}
+
+	form := container.NewGridWithColumns(2,
+		widget.NewLabel("名称:"), nameEntry,
+		widget.NewLabel("RPC 地址:"), hostEntry,
+		widget.NewLabel("RPC 端口:"), portEntry,
+		widget.NewLabel("协议:"), protocolSelect,
+		widget.NewLabel("密钥:"), tokenEntry,
+		widget.NewLabel("请求路径:"), pathEntry,
+		widget.NewLabel("默认下载目录:"), dirEntry,
+		widget.NewLabel("路径映射:"), mappingsEntry,
+	)
+
+	buttons := container.NewHBox(
+		widget.NewButtonWithIcon("新建", theme.ContentAddIcon(), newProfile),
+		widget.NewButtonWithIcon("保存", theme.DocumentSaveIcon(), saveProfile),
+		widget.NewButtonWithIcon("删除", theme.DeleteIcon(), removeProfile),
+		widget.NewButton("关闭", func() {
+			profileWindow.Close()
+		}),
+	)
+
+	newProfile()
+
+	content := container.NewHSplit(
+		profileList,
+		container.NewBorder(nil, container.NewVBox(statusLabel, buttons), nil, nil, container.NewVScroll(form)),
+	)
+	content.SetOffset(0.3)
+
+	profileWindow.SetContent(content)
+	profileWindow.Show()
+}
@@ -0,0 +1,422 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/driver/desktop"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+// taskRef 列表中的一个任务，server 为所属的服务器配置，普通列表中为空表示当前服务器
+type taskRef struct {
+	server string
+	gid    string
+}
+
+// listedTask 列表中的一行任务及其所属的服务器配置
+type listedTask struct {
+	server string
+	task   aria2.TellStatus
+}
+
+// ref 返回该行对应的任务
+func (t listedTask) ref() taskRef {
+	return taskRef{server: t.server, gid: t.task.GID}
+}
+
+// taskSelection 任务列表的选择状态
+// 点击只选中一个任务，Ctrl（macOS 上为 Command）点击增减单个任务，
+// Shift 点击选中从上次点击的任务到当前任务的范围；
+// 合并视图中可以同时选中多个服务器的任务，操作时按服务器分别发送
+type taskSelection struct {
+	mu       sync.Mutex
+	selected map[taskRef]bool
+	// anchor Shift 选择范围的起点，为上次不带 Shift 点击的任务
+	anchor taskRef
+	// focus 最近点击的任务，显示详情时优先显示
+	focus taskRef
+}
+
+// newTaskSelection 创建空的选择
+func newTaskSelection() *taskSelection {
+	return &taskSelection{selected: make(map[taskRef]bool)}
+}
+
+// click 按点击时按下的修饰键更新选择，rows 为列表当前的显示顺序，index 为点击的行
+func (s *taskSelection) click(rows []listedTask, index int, modifier fyne.KeyModifier) {
+	if index < 0 || index >= len(rows) {
+		return
+	}
+	ref := rows[index].ref()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.focus = ref
+
+	switch {
+	case modifier&fyne.KeyModifierShift != 0:
+		// 起点已不在列表中时只选中当前行
+		start := index
+		for i, row := range rows {
+			if row.ref() == s.anchor {
+				start = i
+				break
+			}
+		}
+		if start > index {
+			start, index = index, start
+		}
+		// 同时按下 Ctrl 时把范围加入已有的选择
+		if modifier&fyne.KeyModifierShortcutDefault == 0 {
+			s.selected = make(map[taskRef]bool)
+		}
+		for i := start; i <= index; i++ {
+			s.selected[rows[i].ref()] = true
+		}
+	case modifier&fyne.KeyModifierShortcutDefault != 0:
+		if s.selected[ref] {
+			delete(s.selected, ref)
+		} else {
+			s.selected[ref] = true
+		}
+		s.anchor = ref
+	default:
+		s.selected = map[taskRef]bool{ref: true}
+		s.anchor = ref
+	}
+}
+
+// selectOnly 只选中 ref
+func (s *taskSelection) selectOnly(ref taskRef) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.selected = map[taskRef]bool{ref: true}
+	s.anchor = ref
+	s.focus = ref
+}
+
+// setFocus 把 ref 记为最近点击的任务，不改变选择
+func (s *taskSelection) setFocus(ref taskRef) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.focus = ref
+}
+
+// focused 返回最近点击的任务，该任务已不再选中时返回 false
+func (s *taskSelection) focused() (taskRef, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.focus, s.selected[s.focus]
+}
+
+// selectMatching 选中 rows 中满足 match 的任务，match 为 nil 时全选
+func (s *taskSelection) selectMatching(rows []listedTask, match func(task aria2.TellStatus) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.selected = make(map[taskRef]bool)
+	for _, row := range rows {
+		if match == nil || match(row.task) {
+			s.selected[row.ref()] = true
+		}
+	}
+}
+
+// clear 取消所有选择
+func (s *taskSelection) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.selected = make(map[taskRef]bool)
+	s.anchor = taskRef{}
+	s.focus = taskRef{}
+}
+
+// contains 判断任务是否被选中
+func (s *taskSelection) contains(ref taskRef) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.selected[ref]
+}
+
+// count 返回选中的任务数量
+func (s *taskSelection) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.selected)
+}
+
+// prune 取消选择 servers 上已经不在 rows 中的任务，返回选择是否变化
+// 获取任务失败的服务器不在 servers 中，保留原来的选择
+func (s *taskSelection) prune(rows []listedTask, servers ...string) bool {
+	present := make(map[taskRef]bool, len(rows))
+	for _, row := range rows {
+		present[row.ref()] = true
+	}
+	checked := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		checked[server] = true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	changed := false
+	for ref := range s.selected {
+		if checked[ref.server] && !present[ref] {
+			delete(s.selected, ref)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// selectionGroup 同一服务器上选中的任务
+type selectionGroup struct {
+	server string
+	gids   []string
+}
+
+// groups 按服务器分组返回选中的任务，服务器和 GID 都按名称排序
+func (s *taskSelection) groups() []selectionGroup {
+	s.mu.Lock()
+	byServer := make(map[string][]string)
+	for ref := range s.selected {
+		byServer[ref.server] = append(byServer[ref.server], ref.gid)
+	}
+	s.mu.Unlock()
+
+	groups := make([]selectionGroup, 0, len(byServer))
+	for server, gids := range byServer {
+		sort.Strings(gids)
+		groups = append(groups, selectionGroup{server: server, gids: gids})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].server < groups[j].server
+	})
+	return groups
+}
+
+// selectableList 可以选择任务的列表，普通列表和合并视图都实现该接口
+type selectableList interface {
+	// listed 按显示顺序返回列表中的任务
+	listed() []listedTask
+	// refreshSelection 选择变化后重新显示各行的选中状态
+	refreshSelection()
+}
+
+// currentList 返回当前显示的任务列表，主界面尚未创建时返回 nil
+func (a *App) currentList() selectableList {
+	a.viewMu.Lock()
+	defer a.viewMu.Unlock()
+	return a.listView
+}
+
+// selectionChanged 选择变化后更新列表的选中状态和状态栏中的数量
+func (a *App) selectionChanged() {
+	if list := a.currentList(); list != nil {
+		list.refreshSelection()
+	}
+	a.updateSelectionStatus()
+}
+
+// updateSelectionStatus 在状态栏显示选中的任务数量
+func (a *App) updateSelectionStatus() {
+	a.statusMu.Lock()
+	label := a.selectionLabel
+	a.statusMu.Unlock()
+	if label == nil {
+		return
+	}
+
+	if count := a.selection.count(); count > 0 {
+		label.SetText(fmt.Sprintf("已选择 %d 个任务", count))
+	} else {
+		label.SetText("")
+	}
+}
+
+// clickTask 处理列表中任务的点击
+func (a *App) clickTask(ref taskRef, modifier fyne.KeyModifier) {
+	list := a.currentList()
+	if list == nil {
+		return
+	}
+
+	rows := list.listed()
+	for i, row := range rows {
+		if row.ref() == ref {
+			a.selection.click(rows, i, modifier)
+			break
+		}
+	}
+	a.selectionChanged()
+}
+
+// selectTasks 选中当前列表中满足 match 的任务，match 为 nil 时全选
+func (a *App) selectTasks(match func(task aria2.TellStatus) bool) {
+	list := a.currentList()
+	if list == nil {
+		return
+	}
+
+	a.selection.selectMatching(list.listed(), match)
+	a.selectionChanged()
+}
+
+// selectTasksByStatus 选中当前列表中指定状态的任务
+func (a *App) selectTasksByStatus(status string) {
+	a.selectTasks(func(task aria2.TellStatus) bool {
+		return task.Status == status
+	})
+}
+
+// clearSelection 取消选择所有任务
+func (a *App) clearSelection() {
+	a.selection.clear()
+	a.selectionChanged()
+}
+
+// createSelectMenu 创建主菜单中的选择菜单
+func (a *App) createSelectMenu() *fyne.Menu {
+	byStatus := func(label, status string) *fyne.MenuItem {
+		return fyne.NewMenuItem(label, func() {
+			a.selectTasksByStatus(status)
+		})
+	}
+
+	selectAll := fyne.NewMenuItem("全选", func() {
+		a.selectTasks(nil)
+	})
+	selectAll.Shortcut = &fyne.ShortcutSelectAll{}
+
+	return fyne.NewMenu("选择",
+		selectAll,
+		fyne.NewMenuItem("全不选", func() {
+			a.clearSelection()
+		}),
+		fyne.NewMenuItemSeparator(),
+		byStatus("选择下载中的任务", "active"),
+		byStatus("选择等待中的任务", "waiting"),
+		byStatus("选择已暂停的任务", "paused"),
+		byStatus("选择已完成的任务", "complete"),
+		byStatus("选择出错的任务", "error"),
+	)
+}
+
+// selectedTasks 同一服务器上选中任务的最新状态，rpc 为该服务器的客户端快照
+type selectedTasks struct {
+	server string
+	rpc    rpcState
+	tasks  []aria2.TellStatus
+}
+
+// fetchSelectedTasks 按服务器获取选中任务的最新状态，keys 为需要的字段，必须包含 gid
+// 已经不存在的任务被跳过；没有选中任何任务时返回错误
+func (a *App) fetchSelectedTasks(keys ...string) ([]selectedTasks, error) {
+	groups := a.selection.groups()
+	if len(groups) == 0 {
+		return nil, errors.New("请先在列表中选择任务")
+	}
+
+	result := make([]selectedTasks, 0, len(groups))
+	for _, group := range groups {
+		rpc, err := a.clientForProfile(group.server)
+		if err != nil {
+			return nil, err
+		}
+
+		calls := make([]aria2.Call, len(group.gids))
+		for i, gid := range group.gids {
+			calls[i] = aria2.Call{Method: "aria2.tellStatus", Params: []interface{}{gid, keys}}
+		}
+		results, err := rpc.client.MulticallContext(rpc.ctx, calls)
+		if err != nil {
+			return nil, fmt.Errorf("获取任务失败: %w", err)
+		}
+
+		var tasks []aria2.TellStatus
+		for _, r := range results {
+			var task aria2.TellStatus
+			if err := r.Decode(&task); err == nil {
+				tasks = append(tasks, task)
+			}
+		}
+		result = append(result, selectedTasks{server: group.server, rpc: rpc, tasks: tasks})
+	}
+
+	return result, nil
+}
+
+// callSelectedTasks 对选中的任务按服务器分别批量调用 methodFor 返回的方法，返回空字符串的任务跳过
+// 返回成功数量和第一个错误；没有任何任务需要调用时返回 0 和 nil
+func (a *App) callSelectedTasks(selected []selectedTasks, methodFor func(task aria2.TellStatus) string) (int, error) {
+	count := 0
+	var firstErr error
+	for _, group := range selected {
+		var calls []aria2.Call
+		for _, task := range group.tasks {
+			if method := methodFor(task); method != "" {
+				calls = append(calls, aria2.Call{Method: method, Params: []interface{}{task.GID}})
+			}
+		}
+		if len(calls) == 0 {
+			continue
+		}
+
+		n, err := a.batchCalls(group.rpc, calls)
+		count += n
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return count, firstErr
+}
+
+// rowSelector 任务行的点击处理，普通列表和合并视图的行共用
+// 桌面上在 MouseDown 中记录修饰键，随后的 Tapped 据此决定如何选择
+type rowSelector struct {
+	app      *App
+	ref      taskRef
+	modifier fyne.KeyModifier
+}
+
+// MouseDown 实现 desktop.Mouseable，记录按下的修饰键
+func (s *rowSelector) MouseDown(event *desktop.MouseEvent) {
+	s.modifier = event.Modifier
+}
+
+// MouseUp 实现 desktop.Mouseable
+func (s *rowSelector) MouseUp(*desktop.MouseEvent) {
+}
+
+// Tapped 点击选择任务
+func (s *rowSelector) Tapped(*fyne.PointEvent) {
+	s.app.clickTask(s.ref, s.modifier)
+	s.modifier = 0
+}
+
+// DoubleTapped 双击只选中该任务并显示详情
+func (s *rowSelector) DoubleTapped(*fyne.PointEvent) {
+	s.app.selection.selectOnly(s.ref)
+	s.app.selectionChanged()
+	s.app.showTaskDetailDialog()
+}
+
+// TappedSecondary 右键显示操作菜单，点击未选中的任务时只选中该任务
+func (s *rowSelector) TappedSecondary(*fyne.PointEvent) {
+	if !s.app.selection.contains(s.ref) {
+		s.app.selection.selectOnly(s.ref)
+		s.app.selectionChanged()
+	} else {
+		s.app.selection.setFocus(s.ref)
+	}
+	s.app.showTaskContextMenu()
+}
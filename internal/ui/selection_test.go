@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+func TestTaskSelectionFocus(t *testing.T) {
+	var rows []listedTask
+	for _, gid := range []string{"d", "c", "b", "a"} {
+		rows = append(rows, listedTask{task: aria2.TellStatus{GID: gid}})
+	}
+	shift := fyne.KeyModifierShift
+	ctrl := fyne.KeyModifierShortcutDefault
+
+	tests := []struct {
+		name   string
+		clicks []int
+		mods   []fyne.KeyModifier
+		want   string
+	}{
+		{"click", []int{2}, []fyne.KeyModifier{0}, "b"},
+		// Shift 范围选择时为最后点击的行，而不是 GID 最小的任务
+		{"shift range", []int{0, 2}, []fyne.KeyModifier{0, shift}, "b"},
+		{"shift range upwards", []int{3, 1}, []fyne.KeyModifier{0, shift}, "c"},
+		{"ctrl add", []int{0, 3, 1}, []fyne.KeyModifier{0, ctrl, ctrl}, "c"},
+		// 最近点击的任务被 Ctrl 取消选择
+		{"ctrl remove", []int{0, 1, 1}, []fyne.KeyModifier{0, ctrl, ctrl}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTaskSelection()
+			for i, index := range tt.clicks {
+				s.click(rows, index, tt.mods[i])
+			}
+			focus, ok := s.focused()
+			if tt.want == "" {
+				if ok {
+					t.Errorf("focused %+v, want none", focus)
+				}
+				return
+			}
+			if !ok || focus.gid != tt.want {
+				t.Errorf("focused %+v, %v, want %s", focus, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskSelectionFocusCleared(t *testing.T) {
+	ref := taskRef{server: "home", gid: "a"}
+	s := newTaskSelection()
+	s.selectOnly(ref)
+	if focus, ok := s.focused(); !ok || focus != ref {
+		t.Fatalf("focused %+v, %v after selectOnly", focus, ok)
+	}
+
+	// 任务从列表中消失后不再是焦点
+	if !s.prune(nil, "home") {
+		t.Fatal("prune kept the removed task")
+	}
+	if _, ok := s.focused(); ok {
+		t.Error("pruned task is still focused")
+	}
+
+	s.selectOnly(ref)
+	s.clear()
+	if _, ok := s.focused(); ok {
+		t.Error("focused after clear")
+	}
+}
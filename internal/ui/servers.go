@@ -0,0 +1,304 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+// serverTasks 一个服务器的任务及获取失败的原因，只有部分列表失败时 tasks 为获取到的任务
+type serverTasks struct {
+	server string
+	tasks  []aria2.TellStatus
+	err    error
+}
+
+// serverClient 合并视图中其他服务器的客户端
+// 每个服务器的调用使用各自的上下文，切换当前服务器不会取消发往其他服务器的调用
+type serverClient struct {
+	client aria2.API
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// setCombinedView 切换合并视图，选中的任务随之清空
+func (a *App) setCombinedView(enabled bool) {
+	a.combinedView = enabled
+	a.selection.clear()
+	if !enabled {
+		a.closeServerClients()
+	}
+	// 普通列表和合并视图是不同的控件，需要重建界面
+	a.CreateMainUI()
+}
+
+// clientForProfile 返回服务器配置对应的客户端及其调用上下文，name 为空或为当前服务器时返回当前客户端的快照
+// 其他服务器的客户端在第一次使用时创建，之后复用
+func (a *App) clientForProfile(name string) (rpcState, error) {
+	rpc := a.currentRPC()
+	if name == "" || name == a.clientProfile {
+		if rpc.client == nil {
+			return rpcState{}, errors.New("未连接到 aria2 服务")
+		}
+		return rpc, nil
+	}
+
+	a.serversMu.Lock()
+	defer a.serversMu.Unlock()
+
+	if server, ok := a.serverClients[name]; ok {
+		return rpcState{client: server.client, ctx: server.ctx}, nil
+	}
+
+	profile := a.config.FindProfile(name)
+	if profile == nil {
+		return rpcState{}, fmt.Errorf("服务器配置 %q 不存在", name)
+	}
+	client, err := a.newAria2Client(profile.Host, profile.Port, profile.Token, profile.Protocol, profile.Path, profile.TLS)
+	if err != nil {
+		return rpcState{}, fmt.Errorf("TLS 设置有误: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.serverClients[name] = serverClient{client: client, ctx: ctx, cancel: cancel}
+	return rpcState{client: client, ctx: ctx}, nil
+}
+
+// closeServerClients 取消合并视图中其他服务器上进行中的调用并关闭其客户端
+func (a *App) closeServerClients() {
+	a.serversMu.Lock()
+	defer a.serversMu.Unlock()
+
+	for name, server := range a.serverClients {
+		server.cancel()
+		server.client.Close()
+		delete(a.serverClients, name)
+	}
+}
+
+// getCombinedTasks 并发获取所有服务器的任务，结果按服务器配置的顺序排列
+// 每个服务器的请求受各自的连接超时限制，离线的服务器只记录错误，不影响其他服务器；
+// 当前服务器断开期间不发起请求，由连接监视器按退避间隔重连
+func (a *App) getCombinedTasks() []serverTasks {
+	names := a.config.ProfileNames()
+	results := make([]serverTasks, len(names))
+	rpcs := make([]rpcState, len(names))
+	for i, name := range names {
+		results[i].server = name
+		rpcs[i], results[i].err = a.clientForProfile(name)
+		if results[i].err == nil && rpcs[i].supervisor != nil {
+			results[i].err = a.connectionError()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range results {
+		if results[i].err != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *serverTasks, rpc rpcState) {
+			defer wg.Done()
+
+			tasks, err := fetchTasks(rpc.ctx, rpc.client, taskListKeys...)
+			if err != nil {
+				// 当前服务器的失败交给连接监视器处理，其他服务器没有监视器
+				rpc.reportError(err)
+				result.err = err
+				// 只有部分列表获取失败时仍显示获取到的任务
+				if tasks == nil {
+					return
+				}
+			}
+			a.resolveTaskNames(rpc, result.server, tasks)
+			result.tasks = tasks
+		}(&results[i], rpcs[i])
+	}
+	wg.Wait()
+
+	return results
+}
+
+// combinedListView 合并视图的任务列表
+// 列表控件只创建一次，刷新时替换数据，滚动位置和选中的任务保持不变
+type combinedListView struct {
+	app *App
+
+	mu   sync.Mutex
+	rows []listedTask
+
+	list    *widget.List
+	empty   fyne.CanvasObject
+	content *fyne.Container
+	notices *fyne.Container
+}
+
+// createCombinedTaskList 创建合并视图，列出所有服务器的任务并标明所属服务器
+func (a *App) createCombinedTaskList() fyne.CanvasObject {
+	v := &combinedListView{
+		app:     a,
+		empty:   a.createEmptyState(),
+		content: container.NewMax(),
+		notices: container.NewVBox(),
+	}
+	v.list = widget.NewList(
+		func() int {
+			v.mu.Lock()
+			defer v.mu.Unlock()
+			return len(v.rows)
+		},
+		func() fyne.CanvasObject {
+			return newCombinedRow(a)
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			v.mu.Lock()
+			if id >= len(v.rows) {
+				v.mu.Unlock()
+				return
+			}
+			row := v.rows[id]
+			v.mu.Unlock()
+			obj.(*combinedRow).update(row)
+		},
+	)
+
+	v.show(a.getCombinedTasks())
+	a.setTaskView(v, nil, v.show)
+
+	header := container.NewGridWithColumns(5,
+		widget.NewLabelWithStyle("服务器", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("名称", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("状态", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("进度", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		widget.NewLabelWithStyle("速度", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+	)
+
+	return container.NewBorder(container.NewVBox(v.notices, header), nil, nil, nil, v.content)
+}
+
+// show 显示各服务器的任务，获取失败的服务器显示在列表上方
+func (v *combinedListView) show(results []serverTasks) {
+	var rows []listedTask
+	var warnings []fyne.CanvasObject
+	var fetched []string
+	for _, result := range results {
+		if result.err != nil {
+			warnings = append(warnings, container.NewHBox(
+				widget.NewIcon(theme.WarningIcon()),
+				widget.NewLabel(fmt.Sprintf("%s: 获取任务失败 (%v)", result.server, result.err)),
+			))
+		} else {
+			fetched = append(fetched, result.server)
+		}
+		for _, task := range result.tasks {
+			rows = append(rows, listedTask{server: result.server, task: task})
+		}
+	}
+
+	v.mu.Lock()
+	v.rows = rows
+	v.mu.Unlock()
+
+	// 已经消失的任务不再保持选中，获取失败的服务器保留原来的选择
+	if v.app.selection.prune(rows, fetched...) {
+		v.app.updateSelectionStatus()
+	}
+
+	v.notices.Objects = warnings
+	v.notices.Refresh()
+
+	if len(rows) == 0 {
+		v.content.Objects = []fyne.CanvasObject{v.empty}
+	} else {
+		v.content.Objects = []fyne.CanvasObject{v.list}
+	}
+	v.content.Refresh()
+	v.list.Refresh()
+}
+
+// listed 实现 selectableList
+func (v *combinedListView) listed() []listedTask {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return append([]listedTask(nil), v.rows...)
+}
+
+// refreshSelection 实现 selectableList
+func (v *combinedListView) refreshSelection() {
+	v.list.Refresh()
+}
+
+// combinedRow 合并视图中的任务行，点击时选中该任务及其所属服务器
+type combinedRow struct {
+	widget.BaseWidget
+	rowSelector
+
+	background  *canvas.Rectangle
+	serverLabel *widget.Label
+	nameLabel   *widget.Label
+	statusLabel *widget.Label
+	progressBar *widget.ProgressBar
+	speedLabel  *widget.Label
+}
+
+// newCombinedRow 创建合并视图的行模板
+func newCombinedRow(a *App) *combinedRow {
+	row := &combinedRow{
+		rowSelector: rowSelector{app: a},
+		background:  canvas.NewRectangle(theme.SelectionColor()),
+		serverLabel: widget.NewLabel(""),
+		nameLabel:   widget.NewLabel(""),
+		statusLabel: widget.NewLabel(""),
+		progressBar: widget.NewProgressBar(),
+		speedLabel:  widget.NewLabel(""),
+	}
+	row.nameLabel.Truncation = fyne.TextTruncateEllipsis
+	row.background.Hide()
+	row.ExtendBaseWidget(row)
+	return row
+}
+
+// CreateRenderer 实现 fyne.Widget
+func (r *combinedRow) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewMax(
+		r.background,
+		container.NewGridWithColumns(5,
+			r.serverLabel, r.nameLabel, r.statusLabel, r.progressBar, r.speedLabel,
+		),
+	))
+}
+
+// update 显示一行任务数据
+func (r *combinedRow) update(row listedTask) {
+	task := row.task
+	r.ref = row.ref()
+
+	speedText := "0 B/s"
+	if task.DownloadSpeed > 0 {
+		speedText = r.app.formatSpeed(float64(task.DownloadSpeed))
+		if eta, ok := task.ETA(); ok {
+			speedText += " 剩余 " + r.app.formatDuration(eta)
+		}
+	}
+
+	r.serverLabel.SetText(row.server)
+	r.nameLabel.SetText(r.app.serverTaskName(row.server, task))
+	r.statusLabel.SetText(task.Status)
+	r.progressBar.SetValue(task.Progress())
+	r.speedLabel.SetText(speedText)
+
+	if r.app.selection.contains(r.ref) {
+		r.background.Show()
+	} else {
+		r.background.Hide()
+	}
+}
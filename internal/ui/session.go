@@ -0,0 +1,119 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// exitSaveTimeout 退出时保存会话的最长等待时间，aria2 无响应时不阻塞退出
+const exitSaveTimeout = 3 * time.Second
+
+// createServerMenu 创建“服务器”菜单
+func (a *App) createServerMenu() *fyne.Menu {
+	return fyne.NewMenu("服务器",
+		fyne.NewMenuItem("保存会话", func() {
+			a.saveSession()
+		}),
+		fyne.NewMenuItem("会话信息", func() {
+			a.showSessionInfo()
+		}),
+		fyne.NewMenuItemSeparator(),
+		fyne.NewMenuItem("关闭 aria2", func() {
+			a.confirmShutdown(false)
+		}),
+		fyne.NewMenuItem("强制关闭 aria2", func() {
+			a.confirmShutdown(true)
+		}),
+	)
+}
+
+// saveSession 让 aria2 保存当前会话
+func (a *App) saveSession() {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
+		a.showErrorMessage("未连接到 aria2 服务")
+		return
+	}
+
+	if err := rpc.client.SaveSessionContext(rpc.ctx); err != nil {
+		a.showErrorMessage(fmt.Sprintf("保存会话失败: %v\n\n请确认 aria2 启动时指定了 --save-session", err))
+		return
+	}
+	a.showSuccessMessage("会话已保存")
+}
+
+// showSessionInfo 显示当前 aria2 会话的 ID
+func (a *App) showSessionInfo() {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
+		a.showErrorMessage("未连接到 aria2 服务")
+		return
+	}
+
+	info, err := rpc.client.GetSessionInfoContext(rpc.ctx)
+	if err != nil {
+		a.showErrorMessage(fmt.Sprintf("获取会话信息失败: %v", err))
+		return
+	}
+	dialog.ShowInformation("会话信息", fmt.Sprintf("会话 ID: %s", info.SessionID), a.window)
+}
+
+// confirmShutdown 确认后关闭 aria2，force 为 true 时强制关闭
+// 确认时关闭的是打开对话框时连接的 aria2
+func (a *App) confirmShutdown(force bool) {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
+		a.showErrorMessage("未连接到 aria2 服务")
+		return
+	}
+
+	title := "关闭 aria2"
+	message := "确定要关闭 aria2 吗？\n活动任务会先停止，关闭后需要重新启动 aria2 才能继续下载。"
+	if force {
+		title = "强制关闭 aria2"
+		message = "确定要强制关闭 aria2 吗？\n不会等待 BitTorrent tracker 注销等操作，关闭后需要重新启动 aria2 才能继续下载。"
+	}
+
+	dialog.ShowConfirm(title, message, func(confirmed bool) {
+		if !confirmed {
+			return
+		}
+
+		var err error
+		if force {
+			err = rpc.client.ForceShutdownContext(rpc.ctx)
+		} else {
+			err = rpc.client.ShutdownContext(rpc.ctx)
+		}
+		if err != nil {
+			a.showErrorMessage(fmt.Sprintf("关闭 aria2 失败: %v", err))
+			return
+		}
+
+		a.showSuccessMessage("aria2 正在关闭")
+		// 让连接监视器尽快发现连接断开
+		if rpc.supervisor != nil {
+			rpc.supervisor.Check()
+		}
+	}, a.window)
+}
+
+// saveSessionOnExit 退出前保存会话，失败时只记录日志
+func (a *App) saveSessionOnExit() {
+	rpc := a.currentRPC()
+	if rpc.client == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(rpc.ctx, exitSaveTimeout)
+	defer cancel()
+
+	if err := rpc.client.SaveSessionContext(ctx); err != nil {
+		log.Printf("退出时保存会话失败: %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+// taskListView 当前服务器的任务列表
+// 行模板只创建一次，刷新时按 GID 的变化更新：只有任务数据变化时刷新对应的行，
+// 增删任务或调整顺序时重新绑定可见的行；列表控件本身保持不变，滚动位置和选中的任务不受影响
+type taskListView struct {
+	app *App
+
+	mu    sync.Mutex
+	tasks []aria2.TellStatus
+	rows  map[string]int // GID 对应的行号
+
+	list    *widget.List
+	empty   fyne.CanvasObject
+	content *fyne.Container
+}
+
+// newTaskListView 创建任务列表并显示任务快照中的任务
+func newTaskListView(a *App) *taskListView {
+	v := &taskListView{
+		app:   a,
+		rows:  make(map[string]int),
+		empty: a.createEmptyState(),
+	}
+
+	v.list = widget.NewList(
+		func() int {
+			v.mu.Lock()
+			defer v.mu.Unlock()
+			return len(v.tasks)
+		},
+		func() fyne.CanvasObject {
+			return a.createTaskItem()
+		},
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			v.mu.Lock()
+			tasks := v.tasks
+			v.mu.Unlock()
+			a.updateTaskItem(id, obj, tasks)
+		},
+	)
+	v.content = container.NewMax()
+
+	v.show(taskChanges{reordered: true})
+	return v
+}
+
+// show 按任务快照的变化更新列表
+func (v *taskListView) show(changes taskChanges) {
+	tasks := v.app.tasks.snapshot()
+	rows := make(map[string]int, len(tasks))
+	for i, task := range tasks {
+		rows[task.GID] = i
+	}
+
+	// 列表的回调会读取 tasks，刷新列表前先释放锁
+	v.mu.Lock()
+	v.tasks = tasks
+	v.rows = rows
+	v.mu.Unlock()
+
+	// 已经消失的任务不再保持选中
+	if len(changes.removed) > 0 && v.app.selection.prune(v.listed(), "") {
+		v.app.updateSelectionStatus()
+	}
+
+	// 没有任务时显示空状态
+	if len(tasks) == 0 {
+		v.setContent(v.empty)
+		return
+	}
+	if v.setContent(v.list) {
+		return
+	}
+
+	if len(changes.added) > 0 || len(changes.removed) > 0 || changes.reordered {
+		v.list.Refresh()
+		return
+	}
+	for _, gid := range changes.updated {
+		if id, ok := rows[gid]; ok {
+			v.list.RefreshItem(id)
+		}
+	}
+}
+
+// setContent 切换列表和空状态，返回是否发生了切换
+func (v *taskListView) setContent(object fyne.CanvasObject) bool {
+	if len(v.content.Objects) == 1 && v.content.Objects[0] == object {
+		return false
+	}
+	v.content.Objects = []fyne.CanvasObject{object}
+	v.content.Refresh()
+	return true
+}
+
+// listed 实现 selectableList
+func (v *taskListView) listed() []listedTask {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	rows := make([]listedTask, len(v.tasks))
+	for i, task := range v.tasks {
+		rows[i] = listedTask{task: task}
+	}
+	return rows
+}
+
+// refreshSelection 实现 selectableList
+func (v *taskListView) refreshSelection() {
+	v.list.Refresh()
+}
+
+// taskRow 任务列表中的一行，行模板在列表滚动时复用，显示的任务由 update 绑定
+type taskRow struct {
+	widget.BaseWidget
+	rowSelector
+
+	background  *canvas.Rectangle
+	nameLabel   *widget.Label
+	statusLabel *widget.Label
+	progressBar *widget.ProgressBar
+	speedLabel  *widget.Label
+	sizeLabel   *widget.Label
+}
+
+// newTaskRow 创建任务行模板
+func newTaskRow(a *App) *taskRow {
+	row := &taskRow{
+		rowSelector: rowSelector{app: a},
+		background:  canvas.NewRectangle(theme.SelectionColor()),
+		nameLabel:   widget.NewLabel(""),
+		statusLabel: widget.NewLabel(""),
+		progressBar: widget.NewProgressBar(),
+		speedLabel:  widget.NewLabel(""),
+		sizeLabel:   widget.NewLabel(""),
+	}
+	row.nameLabel.Truncation = fyne.TextTruncateEllipsis
+	row.background.Hide()
+	row.ExtendBaseWidget(row)
+	return row
+}
+
+// CreateRenderer 实现 fyne.Widget
+func (r *taskRow) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewMax(
+		r.background,
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, r.statusLabel, r.nameLabel),
+			r.progressBar,
+			container.NewHBox(r.speedLabel, r.sizeLabel),
+		),
+	))
+}
+
+// update 显示一个任务的数据
+func (r *taskRow) update(task aria2.TellStatus) {
+	r.ref = taskRef{gid: task.GID}
+
+	sizeText := "0 B / 0 B"
+	if task.TotalLength > 0 {
+		sizeText = fmt.Sprintf("%s / %s", r.app.formatSize(float64(task.CompletedLength)), r.app.formatSize(float64(task.TotalLength)))
+	}
+
+	speedText := "0 B/s"
+	if task.DownloadSpeed > 0 {
+		speedText = r.app.formatSpeed(float64(task.DownloadSpeed))
+		if eta, ok := task.ETA(); ok {
+			speedText += " 剩余 " + r.app.formatDuration(eta)
+		}
+	}
+
+	r.nameLabel.SetText(r.app.taskName(task))
+	r.statusLabel.SetText(task.Status)
+	r.progressBar.SetValue(task.Progress())
+	r.speedLabel.SetText(speedText)
+	r.sizeLabel.SetText(sizeText)
+
+	if r.app.selection.contains(r.ref) {
+		r.background.Show()
+	} else {
+		r.background.Hide()
+	}
+}
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+)
+
+// taskChanges 一次刷新中新增、变化和消失的任务 GID
+type taskChanges struct {
+	added   []string
+	updated []string
+	removed []string
+	// reordered 任务的先后顺序发生了变化，如调整了队列位置
+	reordered bool
+}
+
+// empty 判断这次刷新是否没有任何变化
+func (c taskChanges) empty() bool {
+	return len(c.added) == 0 && len(c.updated) == 0 && len(c.removed) == 0 && !c.reordered
+}
+
+// taskStore 当前服务器任务的最新快照，按 GID 索引并保留 aria2 返回的顺序
+type taskStore struct {
+	mu    sync.RWMutex
+	tasks map[string]aria2.TellStatus
+	order []string
+	// client 快照所属的客户端，由 reset 设置
+	client aria2.API
+}
+
+// newTaskStore 创建空的任务快照
+func newTaskStore() *taskStore {
+	return &taskStore{tasks: make(map[string]aria2.TellStatus)}
+}
+
+// replace 用 client 上获取的任务替换快照，返回与上一次快照相比的变化
+// 获取期间已经切换了客户端时丢弃这些任务，返回空的变化
+func (s *taskStore) replace(client aria2.API, tasks []aria2.TellStatus) taskChanges {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if client != s.client {
+		return taskChanges{}
+	}
+
+	var changes taskChanges
+	next := make(map[string]aria2.TellStatus, len(tasks))
+	order := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		// aria2 在任务状态切换的瞬间可能在两个列表中返回同一任务，只保留第一次出现的
+		if _, ok := next[task.GID]; ok {
+			continue
+		}
+		next[task.GID] = task
+		order = append(order, task.GID)
+
+		previous, ok := s.tasks[task.GID]
+		switch {
+		case !ok:
+			changes.added = append(changes.added, task.GID)
+		case !reflect.DeepEqual(previous, task):
+			changes.updated = append(changes.updated, task.GID)
+		}
+	}
+	for _, gid := range s.order {
+		if _, ok := next[gid]; !ok {
+			changes.removed = append(changes.removed, gid)
+		}
+	}
+	changes.reordered = !sameOrder(s.order, order)
+
+	s.tasks = next
+	s.order = order
+	return changes
+}
+
+// snapshot 按 aria2 返回的顺序返回所有任务
+func (s *taskStore) snapshot() []aria2.TellStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tasks := make([]aria2.TellStatus, len(s.order))
+	for i, gid := range s.order {
+		tasks[i] = s.tasks[gid]
+	}
+	return tasks
+}
+
+// reset 清空快照并改为接收 client 的任务，切换服务器后 GID 不再对应原来的任务
+func (s *taskStore) reset(client aria2.API) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks = make(map[string]aria2.TellStatus)
+	s.order = nil
+	s.client = client
+}
+
+// sameOrder 判断两组 GID 的顺序是否相同
+func sameOrder(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// minRefreshInterval 最短的自动刷新间隔，避免配置为 0 时不停地请求
+const minRefreshInterval = time.Second
+
+// taskPoller 在后台按刷新间隔调用 poll
+// poll 在同一个协程中依次调用，不会并发执行
+type taskPoller struct {
+	interval func() time.Duration
+	poll     func()
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startTaskPoller 启动后台刷新，interval 在每次等待前读取，修改刷新间隔后无需重启
+func startTaskPoller(interval func() time.Duration, poll func()) *taskPoller {
+	p := &taskPoller{
+		interval: interval,
+		poll:     poll,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// run 刷新循环
+func (p *taskPoller) run() {
+	defer close(p.done)
+
+	for {
+		interval := p.interval()
+		if interval < minRefreshInterval {
+			interval = minRefreshInterval
+		}
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-p.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		p.poll()
+	}
+}
+
+// stopAndWait 停止后台刷新，等待进行中的刷新结束
+func (p *taskPoller) stopAndWait() {
+	close(p.stop)
+	<-p.done
+}
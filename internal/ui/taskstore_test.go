@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/chenyb888/aria2GoUI/internal/aria2"
+	"github.com/chenyb888/aria2GoUI/internal/config"
+)
+
+// statuses 按 "gid:status" 创建任务
+func statuses(specs ...string) []aria2.TellStatus {
+	tasks := make([]aria2.TellStatus, len(specs))
+	for i, spec := range specs {
+		tasks[i] = aria2.TellStatus{GID: spec[:1], Status: spec[2:]}
+	}
+	return tasks
+}
+
+func TestTaskStoreReplace(t *testing.T) {
+	tests := []struct {
+		name      string
+		previous  []aria2.TellStatus
+		next      []aria2.TellStatus
+		want      taskChanges
+		wantOrder []string
+	}{
+		{
+			name:      "first load",
+			next:      statuses("a:active", "b:waiting"),
+			want:      taskChanges{added: []string{"a", "b"}, reordered: true},
+			wantOrder: []string{"a", "b"},
+		},
+		// 没有变化的任务不报告
+		{
+			name:      "unchanged",
+			previous:  statuses("a:active", "b:waiting"),
+			next:      statuses("a:active", "b:waiting"),
+			wantOrder: []string{"a", "b"},
+		},
+		{
+			name:      "updated",
+			previous:  statuses("a:active", "b:waiting", "c:paused"),
+			next:      statuses("a:active", "b:active", "c:paused"),
+			want:      taskChanges{updated: []string{"b"}},
+			wantOrder: []string{"a", "b", "c"},
+		},
+		{
+			name:      "removed",
+			previous:  statuses("a:active", "b:waiting", "c:paused"),
+			next:      statuses("a:active", "c:paused"),
+			want:      taskChanges{removed: []string{"b"}, reordered: true},
+			wantOrder: []string{"a", "c"},
+		},
+		// 保留 aria2 返回的顺序
+		{
+			name:      "reordered",
+			previous:  statuses("a:waiting", "b:waiting", "c:waiting"),
+			next:      statuses("c:waiting", "a:waiting", "b:waiting"),
+			want:      taskChanges{reordered: true},
+			wantOrder: []string{"c", "a", "b"},
+		},
+		{
+			name:      "added and removed",
+			previous:  statuses("a:active", "b:waiting"),
+			next:      statuses("c:active", "a:active"),
+			want:      taskChanges{added: []string{"c"}, removed: []string{"b"}, reordered: true},
+			wantOrder: []string{"c", "a"},
+		},
+		// 状态切换的瞬间同一任务出现在两个列表中，只保留第一次出现的
+		{
+			name:      "duplicate",
+			previous:  statuses("a:active"),
+			next:      statuses("a:complete", "b:waiting", "a:active"),
+			want:      taskChanges{added: []string{"b"}, updated: []string{"a"}, reordered: true},
+			wantOrder: []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := aria2.NewClient("127.0.0.1", 6800, "", "http", "/jsonrpc")
+			store := newTaskStore()
+			store.reset(client)
+			store.replace(client, tt.previous)
+
+			if got := store.replace(client, tt.next); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %+v, want %+v", got, tt.want)
+			}
+			var order []string
+			for _, task := range store.snapshot() {
+				order = append(order, task.GID)
+			}
+			if !reflect.DeepEqual(order, tt.wantOrder) {
+				t.Errorf("order = %v, want %v", order, tt.wantOrder)
+			}
+		})
+	}
+}
+
+func TestTaskStoreReplaceOtherClient(t *testing.T) {
+	old := aria2.NewClient("127.0.0.1", 6800, "", "http", "/jsonrpc")
+	current := aria2.NewClient("127.0.0.1", 6801, "", "http", "/jsonrpc")
+	store := newTaskStore()
+	store.reset(old)
+	store.replace(old, statuses("a:active"))
+
+	// 切换客户端后清空快照，旧客户端迟到的结果被丢弃
+	store.reset(current)
+	if changes := store.replace(old, statuses("a:active", "b:waiting")); !changes.empty() {
+		t.Errorf("stale result reported %+v", changes)
+	}
+	if tasks := store.snapshot(); len(tasks) != 0 {
+		t.Errorf("snapshot = %+v, want empty", tasks)
+	}
+}
+
+func TestTaskPollerStop(t *testing.T) {
+	polls := make(chan struct{}, 16)
+	poller := startTaskPoller(func() time.Duration {
+		// 小于最短间隔时按 minRefreshInterval 刷新
+		return 0
+	}, func() {
+		polls <- struct{}{}
+	})
+
+	select {
+	case <-polls:
+	case <-time.After(5 * time.Second):
+		t.Fatal("poller never polled")
+	}
+
+	// 停止后不再刷新
+	poller.stopAndWait()
+	for len(polls) > 0 {
+		<-polls
+	}
+	select {
+	case <-polls:
+		t.Fatal("polled after stopAndWait")
+	case <-time.After(minRefreshInterval + 200*time.Millisecond):
+	}
+}
+
+func TestAutoRefreshToggle(t *testing.T) {
+	a := &App{config: config.DefaultConfig()}
+	defer a.stopAutoRefresh()
+
+	a.startAutoRefresh()
+	first := a.poller
+	if first == nil {
+		t.Fatal("auto refresh did not start")
+	}
+	// 已在刷新时不启动第二个
+	a.startAutoRefresh()
+	if a.poller != first {
+		t.Error("started a second poller")
+	}
+
+	a.stopAutoRefresh()
+	if a.poller != nil {
+		t.Error("poller kept after stop")
+	}
+	select {
+	case <-first.done:
+	default:
+		t.Error("stopped poller is still running")
+	}
+	a.stopAutoRefresh()
+
+	// 重新打开后启动新的刷新
+	a.startAutoRefresh()
+	if a.poller == nil || a.poller == first {
+		t.Fatal("auto refresh did not restart")
+	}
+	select {
+	case <-a.poller.done:
+		t.Error("restarted poller is not running")
+	default:
+	}
+}
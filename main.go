@@ -1,16 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"fyne.io/fyne/v2/app"
 	"github.com/chenyb888/aria2GoUI/internal/config"
 	"github.com/chenyb888/aria2GoUI/internal/aria2"
+	"github.com/chenyb888/aria2GoUI/internal/daemon"
 	"github.com/chenyb888/aria2GoUI/internal/ui"
 )
 
+// daemonStartTimeout 等待本地 aria2c 开始监听 RPC 端口的最长时间
+const daemonStartTimeout = 10 * time.Second
+
 func main() {
 	// 设置环境变量以支持中文字体，使用单个字体文件而非字体集合
 	fontPath := "C:\\Windows\\Fonts\\simhei.ttf" // 黑体（单个字体文件）
@@ -38,18 +45,33 @@ func main() {
 		cfg = config.DefaultConfig()
 	}
 
+	// 托管模式下先启动本地 aria2c，并切换到本地服务器配置
+	var manager *daemon.Manager
+	if cfg.Daemon.Enabled {
+		manager, err = startDaemon(cfg, configPath)
+		if err != nil {
+			log.Printf("启动本地 aria2c 失败: %v", err)
+		}
+	}
+
 	// 创建 UI 应用
 	uiApp := ui.NewApp()
 	uiApp.SetConfig(cfg)
 
-	// 创建 aria2 客户端
-	aria2Client := aria2.NewClient(
+	// 创建 aria2 客户端，TLS 设置有误时使用默认 TLS 配置
+	tlsConfig, err := aria2.TLSOptionsFrom(cfg.RPC.TLS).Config()
+	if err != nil {
+		log.Printf("TLS 设置有误: %v，使用默认 TLS 配置", err)
+	}
+	aria2Client := aria2.NewClientTLS(
 		cfg.RPC.Host,
 		cfg.RPC.Port,
 		cfg.RPC.Token,
 		cfg.RPC.Protocol,
 		cfg.RPC.Path,
+		tlsConfig,
 	)
+	aria2Client.SetTimeout(time.Duration(cfg.RPC.Timeout) * time.Second)
 	uiApp.SetAria2Client(aria2Client)
 
 	// 测试连接
@@ -66,6 +88,73 @@ func main() {
 
 	// 显示并运行
 	uiApp.ShowAndRun()
+
+	// 主窗口关闭后保存会话并停止后台刷新，再停止本地 aria2c
+	uiApp.Close()
+	if manager != nil {
+		manager.Stop()
+	}
+}
+
+// startDaemon 启动托管的本地 aria2c，RPC 可用后切换到本地服务器配置
+func startDaemon(cfg *config.Config, configPath string) (*daemon.Manager, error) {
+	logFile := cfg.Daemon.LogFile
+	if logFile == "" {
+		logFile = filepath.Join(filepath.Dir(configPath), "aria2c.log")
+	}
+	if err := os.MkdirAll(filepath.Dir(logFile), 0755); err != nil {
+		return nil, err
+	}
+
+	// 下载和高级设置与 RPC 密钥一起写入 aria2.conf 交给 aria2c，
+	// 额外参数中指定了 --conf-path 时以用户的文件代替下载和高级设置
+	userConf, args := cfg.Daemon.SplitConfPath()
+	var conf bytes.Buffer
+	if userConf != "" {
+		data, err := os.ReadFile(userConf)
+		if err != nil {
+			return nil, err
+		}
+		conf.Write(data)
+	} else if err := cfg.WriteAria2Conf(&conf); err != nil {
+		return nil, err
+	}
+
+	manager, err := daemon.NewManager(daemon.Options{
+		Binary:   cfg.Daemon.Binary,
+		Port:     cfg.Daemon.Port,
+		Args:     args,
+		ConfFile: filepath.Join(filepath.Dir(configPath), "aria2.conf"),
+		Conf:     conf.Bytes(),
+		LogFile:  logFile,
+	}, func(state daemon.State, err error) {
+		if err != nil {
+			log.Printf("本地 aria2c %s: %v", state, err)
+		} else {
+			log.Printf("本地 aria2c %s", state)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := manager.Start(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), daemonStartTimeout)
+	defer cancel()
+	if err := manager.WaitReady(ctx); err != nil {
+		manager.Stop()
+		return nil, err
+	}
+
+	if err := cfg.UseLocalProfile(manager.Port(), manager.Secret()); err != nil {
+		manager.Stop()
+		return nil, err
+	}
+
+	log.Printf("本地 aria2c 已启动: %s，端口 %d，日志 %s", manager.Binary(), manager.Port(), logFile)
+	return manager, nil
 }
 
 // testConnection 测试 aria2 连接
@@ -81,4 +170,4 @@ func getConfigPath() string {
 		return "config.json"
 	}
 	return filepath.Join(homeDir, ".aria2goui", "config.json")
-}
\ No newline at end of file
+}